@@ -5,9 +5,10 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"flag"
+	"fmt"
 	"log"
-	"net/http"
 	"math/big"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -20,10 +21,13 @@ import (
 	"github.com/worldland/worldland-node/internal/auth"
 	"github.com/worldland/worldland-node/internal/container"
 	"github.com/worldland/worldland-node/internal/domain"
+	"github.com/worldland/worldland-node/internal/inventory"
 	"github.com/worldland/worldland-node/internal/mining"
 	"github.com/worldland/worldland-node/internal/port"
+	"github.com/worldland/worldland-node/internal/receipt"
 	"github.com/worldland/worldland-node/internal/rental"
 	"github.com/worldland/worldland-node/internal/services"
+	"github.com/worldland/worldland-node/internal/topology"
 )
 
 // Default certificate directory
@@ -110,6 +114,47 @@ func saveCertificates(certDir string, bundle *auth.CertificateBundle) (certPath,
 	return certPath, keyPath, caPath, nil
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parsePairs parses a comma-separated list of key=value pairs
+func parsePairs(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, item := range splitList(s) {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid pair %q (want key=value)", item)
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+// newCPUAllocator reads the host's NUMA topology for the provider's GPUs
+func newCPUAllocator(gpus domain.GPUProvider, sysRoot string, reserved []int) (*topology.Allocator, error) {
+	specs, err := gpus.GetSpecs()
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]topology.GPU, 0, len(specs))
+	for _, spec := range specs {
+		devices = append(devices, topology.GPU{UUID: spec.UUID, Index: spec.Index, BusID: spec.PCIBusID})
+	}
+	topo, err := topology.Read(sysRoot, devices)
+	if err != nil {
+		return nil, err
+	}
+	return topology.NewAllocator(topo, reserved), nil
+}
+
 func main() {
 	log.Println("Worldland Node starting...")
 
@@ -137,6 +182,67 @@ func main() {
 	memoryGB := flag.Int("memory-gb", 24, "GPU memory in GB for registration")
 	pricePerSec := flag.String("price-per-sec", "2777777777778", "Price per second in wei (default: 0.01 WLC/hr)")
 
+	// Rental disk quota flags
+	rentalDiskQuotaGB := flag.Int64("rental-disk-quota-gb", 0, "Default writable layer quota per rental in GB (0 = unlimited)")
+	maxDiskQuotaGB := flag.Int64("max-disk-quota-gb", 0, "Largest writable layer quota a rental may request in GB, also applied to rentals without one (0 = no limit)")
+	diskQuotaAction := flag.String("disk-quota-action", "flag", "Action when a rental exceeds its disk quota: flag or stop")
+	diskQuotaInterval := flag.Duration("disk-quota-interval", 2*time.Minute, "Interval between rental disk usage checks")
+	usageInterval := flag.Duration("usage-interval", time.Minute, "Interval between rental resource usage samples")
+	restartPolicy := flag.String("restart-policy", "on-failure:3", "Default policy when a rental container exits, is OOM killed or turns unhealthy: never or on-failure[:N]")
+	dataExport := flag.Bool("rental-data-export", true, "Serve a stopped rental's workspace read-only over SFTP on its SSH port until cleanup")
+	exportImage := flag.String("rental-export-image", container.DefaultExportImage, "Image serving data exports, pinned by image ID at startup (needs sh, busybox user tools and OpenSSH; pin by digest to fix the version)")
+	shmFraction := flag.Float64("rental-shm-fraction", 0.5, "Default /dev/shm size as a fraction of a rental's memory limit (0 = Docker's 64 MB)")
+	shmMaxGB := flag.Int64("rental-shm-max-gb", 0, "Largest /dev/shm a rental may get in GB (0 = its memory limit)")
+	rentalUlimits := flag.String("rental-ulimits", "memlock=-1,stack=67108864", "Comma-separated default ulimits for rentals in name=soft[:hard] form (-1 = unlimited)")
+	ulimitMax := flag.String("rental-ulimit-max", "memlock=-1,nofile=1048576,stack=67108864", "Comma-separated highest ulimits a rental may request; unlisted limits can't be requested")
+	ipcMode := flag.String("rental-ipc-mode", "private", "Default IPC mode for rentals: private, shareable or host (empty = Docker default)")
+	ipcModes := flag.String("rental-ipc-modes", "private,shareable", "Comma-separated IPC modes a rental may request")
+	securityPreset := flag.String("rental-security-profile", "default", "Rental container security preset: default (Docker defaults, renters get sudo) or strict (minimal capabilities, no-new-privileges, pids limit, no sudo)")
+	pidsLimit := flag.Int64("rental-pids-limit", 0, "Max processes per rental container (0 = the preset's limit)")
+	readOnlyRootfs := flag.Bool("rental-readonly-rootfs", false, "Mount rental images read-only with writable /etc and /var volumes (images must ship sshd and sudo)")
+	seccompProfile := flag.String("rental-seccomp-profile", "", "Seccomp profile JSON file for rentals (empty = Docker default, unconfined = none)")
+	requireUserNS := flag.Bool("rental-require-userns", false, "Refuse rentals unless Docker runs with --userns-remap")
+	rentalRuntime := flag.String("rental-runtime", "nvidia", "OCI runtime for rentals without a runtime class, as name[:env|device-request|cdi] (e.g., runc uses --gpus style device requests)")
+	runtimeClasses := flag.String("rental-runtime-classes", "", "Comma-separated class=runtime[:env|device-request|cdi] pairs rentals may ask for (e.g., sandboxed=runsc)")
+	cpuPinning := flag.Bool("cpu-pinning", true, "Pin each rental to dedicated CPUs on its GPU's NUMA node")
+	reservedCPUs := flag.String("reserved-cpus", "", "CPU list kept for the host and never given to rentals (e.g., 0-1,32-33)")
+	sysfsRoot := flag.String("sysfs-root", "/sys", "sysfs mount used to read CPU and GPU NUMA topology")
+	cdiSpecDirs := flag.String("cdi-spec-dirs", strings.Join(container.DefaultCDISpecDirs, ","), "Comma-separated CDI spec directories, lowest priority first, for runtimes attaching GPUs in cdi mode")
+	monitorInterval := flag.Duration("rental-monitor-interval", 15*time.Second, "Interval between rental container crash checks")
+	shutdownMode := flag.String("shutdown-mode", "drain", "On SIGINT/SIGTERM: drain (wait for rentals, then stop them) or detach (leave rental containers running for the next node process)")
+	drainTimeout := flag.Duration("drain-timeout", time.Hour, "Max time a drain shutdown waits for rentals to end before stopping them")
+	receiptInterval := flag.Duration("receipt-interval", 15*time.Minute, "Interval between signed usage receipts for running rentals (requires a wallet key)")
+	receiptStore := flag.String("receipt-store", filepath.Join(filepath.Dir(defaultCertDir()), "receipts.jsonl"), "Local JSON lines copy of every signed usage receipt")
+	receiptChainID := flag.Int64("receipt-chain-id", receipt.DefaultChainID, "EIP-712 domain chain ID for usage receipts")
+	usageRetention := flag.Duration("usage-retention", 24*time.Hour, "How long usage of ended rentals stays retrievable")
+
+	// Rental network isolation flags
+	networkIsolation := flag.Bool("rental-network-isolation", true, "Run each rental on its own Docker network with an egress firewall")
+	egressAllow := flag.String("egress-allow", "", "Comma-separated CIDRs rentals may always reach (e.g., 10.0.5.0/24)")
+	egressDeny := flag.String("egress-deny", "", "Comma-separated extra CIDRs rentals may not reach")
+
+	// Image cache flags
+	imageCacheMaxGB := flag.Int64("image-cache-max-gb", 0, "Evict least recently used images above this total size in GB (0 = never evict)")
+	warmImages := flag.String("warm-images", "", "Comma-separated images to prefetch at startup and never evict")
+	imageCacheInterval := flag.Duration("image-cache-interval", 10*time.Minute, "Interval between image cache eviction passes")
+
+	// Private registry flags
+	credHelpers := flag.String("registry-credential-helpers", "", "Comma-separated registry=helper pairs for the operator's registries (e.g., 123.dkr.ecr.us-east-1.amazonaws.com=ecr-login)")
+
+	// Rental image policy flags
+	imageAllow := flag.String("image-allow", "", "Comma-separated repository patterns rentals may use (e.g., docker.io/nvidia/*,ghcr.io/corp/**); empty allows all")
+	imageDeny := flag.String("image-deny", "", "Comma-separated repository patterns rentals may never use")
+	imageRequireDigest := flag.String("image-require-digest", "", "Comma-separated repository patterns that must be pinned by @sha256 digest")
+	imageMaxSizeGB := flag.Int64("image-max-size-gb", 0, "Maximum rental image size in GB, checked against the registry manifest before pulling and on disk after (0 = unlimited)")
+	imageResolveDigest := flag.Bool("image-resolve-digest", false, "Resolve rental image tags to digests at admission")
+
+	// Rental access flags
+	envDenylist := flag.String("env-denylist", strings.Join(rental.DefaultEnvDenylist, ","), "Comma-separated env var patterns renters may not set (e.g., NVIDIA_*)")
+	diagnosticCommands := flag.String("diagnostic-commands", "", "Comma-separated name=command pairs support staff may run in rentals (e.g., gpu=nvidia-smi,disk=df -h); empty uses the built-in set")
+	diagnosticTimeout := flag.Duration("diagnostic-timeout", 30*time.Second, "Max run time of a diagnostic command")
+	diagnosticAuditLog := flag.String("diagnostic-audit-log", filepath.Join(filepath.Dir(defaultCertDir()), "diagnostics-audit.jsonl"), "JSON lines audit trail of diagnostic commands")
+	accessReadyTimeout := flag.Duration("access-ready-timeout", 5*time.Minute, "Max time to wait for a rental's SSH/Jupyter/code-server to accept connections")
+
 	flag.Parse()
 
 	// Validate minimum price: 0.01 WLC/hr = 2777777777778 wei/sec
@@ -150,6 +256,16 @@ func main() {
 		log.Fatalf("price-per-sec must be at least 2777777777778 (0.01 WLC/hr), got: %s", *pricePerSec)
 	}
 
+	if *diskQuotaAction != "flag" && *diskQuotaAction != "stop" {
+		log.Fatalf("Invalid disk-quota-action: %s (expected flag or stop)", *diskQuotaAction)
+	}
+	if *maxDiskQuotaGB > 0 && *rentalDiskQuotaGB > *maxDiskQuotaGB {
+		log.Fatalf("Invalid rental-disk-quota-gb: %d exceeds max-disk-quota-gb %d", *rentalDiskQuotaGB, *maxDiskQuotaGB)
+	}
+	if *shutdownMode != "drain" && *shutdownMode != "detach" {
+		log.Fatalf("Invalid shutdown-mode: %s (expected drain or detach)", *shutdownMode)
+	}
+
 	if *hostAddr == "" {
 		log.Println("Warning: host address not specified, defaulting to localhost")
 		*hostAddr = "localhost"
@@ -363,12 +479,179 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize Docker service: %v", err)
 	}
+	dockerService.WithGPUs(gpuProvider)
+	if cdiSpecs, err := container.LoadCDISpecs(splitList(*cdiSpecDirs)...); err != nil {
+		log.Printf("Warning: CDI specs not loaded: %v", err)
+	} else {
+		dockerService.WithCDI(cdiSpecs)
+		log.Printf("CDI devices: %d", len(cdiSpecs.Devices()))
+	}
+
+	if *networkIsolation {
+		dockerService.WithEgressFirewall(container.NewEgressFirewall())
+	}
+	imagePolicy := container.ImagePolicy{
+		Allow:         splitList(*imageAllow),
+		Deny:          splitList(*imageDeny),
+		RequireDigest: splitList(*imageRequireDigest),
+		MaxSizeBytes:  *imageMaxSizeGB * 1024 * 1024 * 1024,
+		ResolveDigest: *imageResolveDigest,
+	}
+	if err := imagePolicy.Validate(); err != nil {
+		log.Fatalf("Invalid image policy: %v", err)
+	}
+	dockerService.WithImagePolicy(imagePolicy)
+	if *credHelpers != "" {
+		helpers, err := parsePairs(*credHelpers)
+		if err != nil {
+			log.Fatalf("Invalid -registry-credential-helpers: %v", err)
+		}
+		dockerService.WithCredentialHelpers(helpers)
+	}
+
+	// Image cache: the mining image is always kept warm so mining can resume after rentals
+	warm := splitList(*warmImages)
+	if *enableMining {
+		warm = append(warm, *miningImage)
+	}
+	imageCache := container.NewImageCache(dockerService, *imageCacheMaxGB*1024*1024*1024, warm)
+	dockerService.WithImageCache(imageCache)
 
 	// Create port manager (30000-32000 range, 30-minute grace period)
 	portManager := port.NewPortManager(30000, 32000, 30*time.Minute)
 
 	// Create rental executor
 	rentalExecutor := rental.NewRentalExecutor(dockerService, portManager, 30*time.Minute)
+	rentalExecutor.WithDiskQuota(*rentalDiskQuotaGB*1024*1024*1024, *diskQuotaAction == "stop")
+	rentalExecutor.WithMaxDiskQuota(*maxDiskQuotaGB * 1024 * 1024 * 1024)
+	rentalExecutor.WithReadinessProber(rental.NewServiceProber(), *accessReadyTimeout)
+	rentalExecutor.WithEnvDenylist(splitList(*envDenylist))
+	rentalExecutor.WithMetering(gpuProvider, *usageRetention)
+	defaultRestartPolicy, err := rental.ParseRestartPolicy(*restartPolicy)
+	if err != nil {
+		log.Fatalf("Invalid -restart-policy: %v", err)
+	}
+	rentalExecutor.WithRestartPolicy(defaultRestartPolicy)
+	if *dataExport {
+		if err := dockerService.PinExportImage(context.Background(), *exportImage); err != nil {
+			log.Printf("Warning: data export disabled: %v", err)
+			*dataExport = false
+		}
+	}
+	rentalExecutor.WithDataExport(*dataExport)
+	ipcLimits := rental.IPCLimits{
+		ShmFraction: *shmFraction,
+		ShmMaxBytes: *shmMaxGB * 1024 * 1024 * 1024,
+		IPCModes:    splitList(*ipcModes),
+	}
+	if ipcLimits.Ulimits, err = container.ParseUlimits(*rentalUlimits); err != nil {
+		log.Fatalf("Invalid -rental-ulimits: %v", err)
+	}
+	if ipcLimits.MaxUlimits, err = container.ParseUlimits(*ulimitMax); err != nil {
+		log.Fatalf("Invalid -rental-ulimit-max: %v", err)
+	}
+	if ipcLimits.IPCMode, err = container.ParseIPCMode(*ipcMode); err != nil {
+		log.Fatalf("Invalid -rental-ipc-mode: %v", err)
+	}
+	for _, mode := range ipcLimits.IPCModes {
+		if _, err := container.ParseIPCMode(mode); err != nil {
+			log.Fatalf("Invalid -rental-ipc-modes: %v", err)
+		}
+	}
+	rentalExecutor.WithIPCLimits(ipcLimits)
+	securityProfile, err := container.SecurityPreset(*securityPreset)
+	if err != nil {
+		log.Fatalf("Invalid -rental-security-profile: %v", err)
+	}
+	if *pidsLimit > 0 {
+		securityProfile.PidsLimit = *pidsLimit
+	}
+	if *readOnlyRootfs {
+		securityProfile.ReadOnlyRootfs = true
+		securityProfile.WritablePaths = container.DefaultWritablePaths
+	}
+	if securityProfile.Seccomp, err = container.LoadSeccompProfile(*seccompProfile); err != nil {
+		log.Fatalf("Invalid -rental-seccomp-profile: %v", err)
+	}
+	securityProfile.RequireUserNS = *requireUserNS
+	if remapped, err := dockerService.UserNamespaceRemapped(context.Background()); err != nil {
+		log.Printf("Warning: could not detect Docker user namespace remapping: %v", err)
+	} else if remapped {
+		log.Println("Docker user namespace remapping active: rental root is unprivileged on the host")
+	} else if *requireUserNS {
+		log.Println("Warning: -rental-require-userns is set but Docker is not running with --userns-remap; rentals will be refused")
+	}
+	rentalExecutor.WithSecurityProfile(securityProfile)
+	classes := make(map[string]container.Runtime)
+	if classes[rental.DefaultRuntimeClass], err = container.ParseRuntime(*rentalRuntime); err != nil {
+		log.Fatalf("Invalid -rental-runtime: %v", err)
+	}
+	if *runtimeClasses != "" {
+		pairs, err := parsePairs(*runtimeClasses)
+		if err != nil {
+			log.Fatalf("Invalid -rental-runtime-classes: %v", err)
+		}
+		for class, spec := range pairs {
+			if classes[class], err = container.ParseRuntime(spec); err != nil {
+				log.Fatalf("Invalid -rental-runtime-classes: %v", err)
+			}
+		}
+	}
+	availableRuntimes, err := dockerService.Runtimes(context.Background())
+	if err != nil {
+		log.Printf("Warning: could not list Docker runtimes, rental runtimes are unchecked: %v", err)
+	} else {
+		log.Printf("Docker runtimes: %s", strings.Join(availableRuntimes, ", "))
+	}
+	rentalExecutor.WithRuntimes(classes, availableRuntimes)
+	if usable := rentalExecutor.RuntimeClasses(); len(usable) < len(classes) {
+		log.Printf("Warning: some rental runtime classes use runtimes not registered with Docker and will be refused; usable: %v", usable)
+	}
+	if *cpuPinning && !isCPUNode {
+		reserved, err := topology.ParseCPUList(*reservedCPUs)
+		if err != nil {
+			log.Fatalf("Invalid -reserved-cpus: %v", err)
+		}
+		if allocator, err := newCPUAllocator(gpuProvider, *sysfsRoot, reserved); err != nil {
+			log.Printf("Warning: CPU pinning disabled: %v", err)
+		} else {
+			rentalExecutor.WithCPUPinning(allocator)
+		}
+	}
+	if !isCPUNode {
+		// Whole GPUs and MIG slices are assigned to one rental at a time
+		if specs, err := gpuProvider.GetSpecs(); err != nil {
+			log.Printf("Warning: GPU assignments untracked: %v", err)
+		} else {
+			for _, spec := range specs {
+				if spec.IsMIGSlice() {
+					log.Printf("MIG slice %s: %s on GPU %d (%d MB)", spec.UUID, spec.MIGProfile, spec.Index, spec.MemoryTotal)
+				}
+			}
+			rentalExecutor.WithGPUAllocator(inventory.NewAllocator(specs))
+		}
+	}
+	var diagCommands map[string][]string
+	if *diagnosticCommands != "" {
+		pairs, err := parsePairs(*diagnosticCommands)
+		if err != nil {
+			log.Fatalf("Invalid -diagnostic-commands: %v", err)
+		}
+		diagCommands = make(map[string][]string, len(pairs))
+		for name, command := range pairs {
+			diagCommands[name] = strings.Fields(command)
+		}
+	}
+	rentalExecutor.WithDiagnostics(diagCommands, *diagnosticTimeout, 0, rental.NewFileAuditLog(*diagnosticAuditLog))
+	if *networkIsolation {
+		egressPolicy := container.DefaultEgressPolicy()
+		egressPolicy.AllowCIDRs = splitList(*egressAllow)
+		egressPolicy.DenyCIDRs = splitList(*egressDeny)
+		if err := egressPolicy.Validate(); err != nil {
+			log.Fatalf("Invalid egress policy: %v", err)
+		}
+		rentalExecutor.WithNetworkIsolation(egressPolicy)
+	}
 
 	// Initialize mining daemon if enabled
 	var miningDaemon *mining.MiningDaemon
@@ -380,7 +663,10 @@ func main() {
 				specs, err := testNVML.GetSpecs()
 				if err == nil {
 					for _, spec := range specs {
-						gpuUUIDs = append(gpuUUIDs, spec.UUID)
+						// Mining runs on whole GPUs only
+						if spec.Rentable() && !spec.IsMIGSlice() {
+							gpuUUIDs = append(gpuUUIDs, spec.UUID)
+						}
 					}
 				}
 				testNVML.Shutdown()
@@ -402,10 +688,28 @@ func main() {
 		log.Printf("Mining daemon initialized: image=%s gpus=%d", *miningImage, len(gpuUUIDs))
 	}
 
+	// Re-adopt rentals left running by a previous node process (detach shutdown)
+	adopted, err := rentalExecutor.AdoptRentals(context.Background())
+	if err != nil {
+		log.Printf("Warning: rental adoption incomplete: %v", err)
+	}
+	for _, state := range adopted {
+		log.Printf("Adopted running rental: session=%s container=%s ssh=%d", state.SessionID, state.ContainerID, state.SSHPort)
+		if miningDaemon != nil && state.GPUDeviceID != "" {
+			_ = miningDaemon.PauseForRental(context.Background(), []string{state.GPUDeviceID})
+		}
+	}
+
 	// Create daemon for GPU monitoring and Hub connection
 	// Wire rental executor so daemon can handle start_rental/stop_rental mTLS commands
 	daemon := services.NewNodeDaemon(gpuProvider, *nodeID)
 	daemon.WithRentalExecutor(rentalExecutor, *hostAddr)
+	daemon.WithImageCache(imageCache)
+	if siweClient != nil {
+		issuer := receipt.NewIssuer(siweClient, receipt.NewFileStore(*receiptStore), *receiptChainID)
+		daemon.WithReceipts(issuer, *receiptInterval, *pricePerSec)
+		log.Printf("Usage receipts enabled (signed by %s, stored in %s)", walletAddress, *receiptStore)
+	}
 
 	// Wire mining daemon if enabled
 	if miningDaemon != nil {
@@ -426,6 +730,21 @@ func main() {
 
 	log.Println("Node daemon running (Docker rental executor enabled)")
 
+	// Fallback disk quota enforcement for storage drivers without storage-opt support
+	go rentalExecutor.MonitorDiskQuotas(context.Background(), *diskQuotaInterval)
+	go rentalExecutor.MeterUsage(context.Background(), *usageInterval)
+	go rentalExecutor.MonitorRentals(context.Background(), *monitorInterval)
+
+	// Warm the image cache, then keep it within its size limit
+	go func() {
+		for _, r := range imageCache.PrefetchWarm(context.Background()) {
+			if r.Error != "" {
+				log.Printf("Warning: failed to prefetch %s: %s", r.Image, r.Error)
+			}
+		}
+		imageCache.Run(context.Background(), *imageCacheInterval)
+	}()
+
 	// Start mining daemon in background if configured
 	if miningDaemon != nil {
 		go func() {
@@ -446,7 +765,11 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/rentals/start", rentalHandler.HandleStartRental)
 	mux.HandleFunc("/rentals/stop", rentalHandler.HandleStopRental)
+	mux.HandleFunc("/rentals/rebuild", rentalHandler.HandleRebuildRental)
 	mux.HandleFunc("/rentals/status", rentalHandler.HandleGetStatus)
+	mux.HandleFunc("/rentals/logs", rentalHandler.HandleGetLogs)
+	mux.HandleFunc("/rentals/usage", rentalHandler.HandleGetUsage)
+	mux.HandleFunc("/node/drain", rentalHandler.HandleDrain)
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -476,13 +799,42 @@ func main() {
 
 	log.Printf("Node ready - API on port %s, metrics daemon connected to %s", *apiPort, *hubAddr)
 
-	// Graceful shutdown
+	// Graceful shutdown. SIGUSR1 only enables drain mode.
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+	for sig := <-sigCh; sig == syscall.SIGUSR1; sig = <-sigCh {
+		rentalExecutor.SetDraining(true)
+		log.Printf("Drain mode enabled by signal (live rentals: %d)", rentalExecutor.LiveRentals())
+	}
 
 	log.Println("Shutting down...")
 
+	// Rentals first, while the Hub connection can still report their end
+	if *shutdownMode == "detach" {
+		log.Printf("Detaching: %d rentals keep running for the next node process", rentalExecutor.LiveRentals())
+	} else {
+		rentalExecutor.SetDraining(true)
+		log.Printf("Draining %d rentals for up to %s (signal again to stop them now)", rentalExecutor.LiveRentals(), *drainTimeout)
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), *drainTimeout)
+		go func() {
+			for sig := range sigCh {
+				if sig != syscall.SIGUSR1 {
+					cancelDrain()
+					return
+				}
+			}
+		}()
+		if err := rentalExecutor.WaitForRentals(drainCtx); err != nil {
+			log.Printf("Drain interrupted, stopping %d remaining rentals", rentalExecutor.LiveRentals())
+			stopCtx, cancelStop := context.WithTimeout(context.Background(), time.Minute)
+			if err := rentalExecutor.StopAll(stopCtx); err != nil {
+				log.Printf("Rental stop error: %v", err)
+			}
+			cancelStop()
+		}
+		cancelDrain()
+	}
+
 	// Stop mining daemon first (releases GPUs)
 	if miningDaemon != nil {
 		miningDaemon.Close()
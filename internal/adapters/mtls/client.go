@@ -23,7 +23,8 @@ type CommandAck struct {
 	CommandID string                 `json:"command_id"`
 	Status    string                 `json:"status"` // "ok" or "error"
 	Error     string                 `json:"error,omitempty"`
-	Payload   map[string]interface{} `json:"payload,omitempty"` // Additional response data
+	ErrorCode string                 `json:"error_code,omitempty"` // Machine-readable reason, e.g. IMAGE_DENIED
+	Payload   map[string]interface{} `json:"payload,omitempty"`    // Additional response data
 }
 
 // Client handles mTLS connection to Hub
@@ -1,6 +1,12 @@
 package nvml
 
-import "github.com/worldland/worldland-node/internal/domain"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/worldland/worldland-node/internal/domain"
+)
 
 // MockGPUProvider provides fake GPU data for testing
 type MockGPUProvider struct {
@@ -33,5 +39,35 @@ func (p *MockGPUProvider) GetSpecs() ([]domain.GPUSpec, error) {
 	return p.Specs, nil
 }
 
+// MIGLayout returns parent in MIG mode followed by one slice per profile,
+// e.g. MIGLayout(a100, "3g.40gb", "2g.20gb", "1g.10gb"). Slice UUIDs are
+// derived from the parent's and slice memory from the profile.
+func MIGLayout(parent domain.GPUSpec, profiles ...string) []domain.GPUSpec {
+	parent.MIGMode = true
+	specs := []domain.GPUSpec{parent}
+	for j, profile := range profiles {
+		specs = append(specs, domain.GPUSpec{
+			UUID:        fmt.Sprintf("MIG-%s-%d", strings.TrimPrefix(parent.UUID, "GPU-"), j),
+			Name:        parent.Name,
+			MemoryTotal: profileMemoryMB(profile),
+			DriverVer:   parent.DriverVer,
+			Index:       parent.Index,
+			PCIBusID:    parent.PCIBusID,
+			ParentUUID:  parent.UUID,
+			MIGProfile:  profile,
+			MIGIndex:    j,
+		})
+	}
+	return specs
+}
+
+// profileMemoryMB reads the memory of a MIG profile such as "1g.10gb"
+func profileMemoryMB(profile string) uint64 {
+	_, mem, _ := strings.Cut(profile, ".")
+	mem, _, _ = strings.Cut(mem, "+") // e.g. "1g.10gb+me"
+	gb, _ := strconv.ParseUint(strings.TrimSuffix(mem, "gb"), 10, 64)
+	return gb * 1024
+}
+
 // Compile-time interface check
 var _ domain.GPUProvider = (*MockGPUProvider)(nil)
@@ -5,6 +5,7 @@ package nvml
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	"github.com/worldland/worldland-node/internal/domain"
@@ -61,6 +62,7 @@ func (p *NVMLProvider) GetMetrics() ([]domain.GPUMetrics, error) {
 
 		metrics = append(metrics, domain.GPUMetrics{
 			UUID:        uuid,
+			Index:       i,
 			Name:        name,
 			MemoryTotal: memInfo.Total / (1024 * 1024),
 			MemoryUsed:  memInfo.Used / (1024 * 1024),
@@ -89,16 +91,66 @@ func (p *NVMLProvider) GetSpecs() ([]domain.GPUSpec, error) {
 		name, _ := device.GetName()
 		memInfo, _ := device.GetMemoryInfo()
 		driver, _ := nvml.SystemGetDriverVersion()
+		pci, _ := device.GetPciInfo()
 
-		specs = append(specs, domain.GPUSpec{
+		spec := domain.GPUSpec{
 			UUID:        uuid,
 			Name:        name,
 			MemoryTotal: memInfo.Total / (1024 * 1024),
 			DriverVer:   driver,
-		})
+			Index:       i,
+			PCIBusID:    strings.TrimRight(string(pci.BusId[:]), "\x00"),
+		}
+		if mode, _, ret := device.GetMigMode(); ret == nvml.SUCCESS && mode == nvml.DEVICE_MIG_ENABLE {
+			spec.MIGMode = true
+			specs = append(specs, spec)
+			specs = append(specs, migSpecs(device, spec)...)
+			continue
+		}
+		specs = append(specs, spec)
 	}
 	return specs, nil
 }
 
+// migSpecs lists the MIG slices created on a GPU in MIG mode
+func migSpecs(device nvml.Device, parent domain.GPUSpec) []domain.GPUSpec {
+	count, ret := device.GetMaxMigDeviceCount()
+	if ret != nvml.SUCCESS {
+		return nil
+	}
+	var slices []domain.GPUSpec
+	for j := 0; j < count; j++ {
+		mig, ret := device.GetMigDeviceHandleByIndex(j)
+		if ret != nvml.SUCCESS {
+			continue // No slice at this index
+		}
+		uuid, _ := mig.GetUUID()
+		name, _ := mig.GetName()
+		memInfo, _ := mig.GetMemoryInfo()
+
+		slices = append(slices, domain.GPUSpec{
+			UUID:        uuid,
+			Name:        parent.Name,
+			MemoryTotal: memInfo.Total / (1024 * 1024),
+			DriverVer:   parent.DriverVer,
+			Index:       parent.Index,
+			PCIBusID:    parent.PCIBusID,
+			ParentUUID:  parent.UUID,
+			MIGProfile:  migProfile(name),
+			MIGIndex:    j,
+		})
+	}
+	return slices
+}
+
+// migProfile extracts the profile from a MIG device name such as
+// "NVIDIA A100-SXM4-40GB MIG 1g.5gb"
+func migProfile(name string) string {
+	if i := strings.LastIndex(name, "MIG "); i >= 0 {
+		return name[i+len("MIG "):]
+	}
+	return name
+}
+
 // Compile-time interface check
 var _ domain.GPUProvider = (*NVMLProvider)(nil)
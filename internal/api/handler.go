@@ -4,19 +4,37 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/worldland/worldland-node/internal/container"
 	"github.com/worldland/worldland-node/internal/rental"
 )
 
 // StartRentalRequest is the JSON body for POST /rentals/start
 type StartRentalRequest struct {
-	SessionID    string `json:"sessionId"`
-	GPUDeviceID  string `json:"gpuDeviceId"`  // NVIDIA UUID
-	Image        string `json:"image"`        // Container image
+	SessionID   string `json:"sessionId"`
+	GPUDeviceID string `json:"gpuDeviceId"` // NVIDIA UUID
+	Image       string `json:"image"`       // Container image
 	SSHPassword string `json:"sshPassword"`
-	MemoryBytes  int64  `json:"memoryBytes"`
-	CPUCount     int64  `json:"cpuCount"`
+	MemoryBytes int64  `json:"memoryBytes"`
+	CPUCount    int64  `json:"cpuCount"`
+
+	DiskQuotaBytes int64                    `json:"diskQuotaBytes,omitempty"` // 0 = node default
+	ScratchMounts  []container.ScratchMount `json:"scratchMounts,omitempty"`
+	ShmSizeBytes   int64                    `json:"shmSizeBytes,omitempty"` // 0 = node default
+	Ulimits        []container.Ulimit       `json:"ulimits,omitempty"`      // memlock, nofile, stack (-1 = unlimited)
+	IPCMode        string                   `json:"ipcMode,omitempty"`      // Within the node's allowed modes
+	RuntimeClass   string                   `json:"runtimeClass,omitempty"` // Empty = node default
+	EgressPolicy   *container.EgressPolicy  `json:"egressPolicy,omitempty"` // Restricts the node policy; nil = node policy as is
+	ExposedPorts   []container.PortMapping  `json:"exposedPorts,omitempty"` // hostPort is ignored
+	AccessMode     string                   `json:"accessMode,omitempty"`   // ssh (default), jupyter or code-server
+	Env            container.Env            `json:"env,omitempty"`
+	InitCommand    string                   `json:"initCommand,omitempty"`
+	WorkDir        string                   `json:"workDir,omitempty"`
+	RegistryAuth   *container.RegistryAuth  `json:"registryAuth,omitempty"`  // Private image credentials
+	RestartPolicy  string                   `json:"restartPolicy,omitempty"` // never or on-failure[:N] (empty = node default)
 }
 
 // StartRentalResponse is returned on successful start
@@ -26,6 +44,12 @@ type StartRentalResponse struct {
 	SSHPort    int    `json:"sshPort"`
 	SSHUser    string `json:"sshUser"`
 	SSHCommand string `json:"sshCommand"`
+
+	Ports []container.PortMapping `json:"ports,omitempty"`
+
+	AccessMode  string `json:"accessMode"`
+	AccessURL   string `json:"accessUrl,omitempty"`
+	AccessToken string `json:"accessToken,omitempty"`
 }
 
 // StopRentalRequest is the JSON body for POST /rentals/stop
@@ -39,6 +63,30 @@ type StopRentalResponse struct {
 	Message   string `json:"message"`
 }
 
+// RebuildRentalRequest is the JSON body for POST /rentals/rebuild
+type RebuildRentalRequest struct {
+	SessionID    string                  `json:"sessionId"`
+	Image        string                  `json:"image"`
+	RegistryAuth *container.RegistryAuth `json:"registryAuth,omitempty"`
+}
+
+// RebuildRentalResponse is returned on successful rebuild
+type RebuildRentalResponse struct {
+	StartRentalResponse
+	ContainerID string `json:"containerId"`
+}
+
+// DrainRequest is the JSON body for POST /node/drain
+type DrainRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DrainResponse reports the node's drain state
+type DrainResponse struct {
+	Draining    bool `json:"draining"`
+	LiveRentals int  `json:"liveRentals"` // Rentals starting, running or stopping
+}
+
 // ErrorResponse for error cases
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -49,7 +97,13 @@ type ErrorResponse struct {
 type RentalExecutorInterface interface {
 	StartRental(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error)
 	StopRental(ctx context.Context, sessionID string) error
+	RebuildRental(ctx context.Context, req rental.RebuildRentalRequest) (*rental.ConnectionInfo, error)
 	GetRentalStatus(sessionID string) (*rental.RentalState, error)
+	RentalLogs(ctx context.Context, sessionID string, opts container.LogOptions, w io.Writer) error
+	GetUsage(sessionID string) (*rental.UsageRecord, error)
+	SetDraining(draining bool)
+	Draining() bool
+	LiveRentals() int
 }
 
 // RentalHandler handles HTTP requests for rental operations
@@ -92,6 +146,15 @@ func (h *RentalHandler) HandleStartRental(w http.ResponseWriter, r *http.Request
 		h.writeError(w, http.StatusBadRequest, "sshPassword is required", "MISSING_SSH_PASSWORD")
 		return
 	}
+	var restartPolicy *rental.RestartPolicy
+	if req.RestartPolicy != "" {
+		policy, err := rental.ParseRestartPolicy(req.RestartPolicy)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error(), "INVALID_RESTART_POLICY")
+			return
+		}
+		restartPolicy = &policy
+	}
 
 	// Default values per CONTEXT.md
 	if req.Image == "" {
@@ -106,38 +169,92 @@ func (h *RentalHandler) HandleStartRental(w http.ResponseWriter, r *http.Request
 
 	// Execute rental start
 	execReq := rental.StartRentalRequest{
-		SessionID:    req.SessionID,
-		GPUDeviceID:  req.GPUDeviceID,
-		Image:        req.Image,
+		SessionID:   req.SessionID,
+		GPUDeviceID: req.GPUDeviceID,
+		Image:       req.Image,
 		SSHPassword: req.SSHPassword,
-		MemoryBytes:  req.MemoryBytes,
-		CPUCount:     req.CPUCount,
-		Host:         h.hostAddr,
+		MemoryBytes: req.MemoryBytes,
+		CPUCount:    req.CPUCount,
+		Host:        h.hostAddr,
+
+		DiskQuotaBytes: req.DiskQuotaBytes,
+		ScratchMounts:  req.ScratchMounts,
+		ShmSizeBytes:   req.ShmSizeBytes,
+		Ulimits:        req.Ulimits,
+		IPCMode:        req.IPCMode,
+		RuntimeClass:   req.RuntimeClass,
+		EgressPolicy:   req.EgressPolicy,
+		ExposedPorts:   req.ExposedPorts,
+		AccessMode:     container.AccessMode(req.AccessMode),
+		Env:            req.Env,
+		InitCommand:    req.InitCommand,
+		WorkDir:        req.WorkDir,
+		RegistryAuth:   req.RegistryAuth,
+		RestartPolicy:  restartPolicy,
 	}
 
 	connInfo, err := h.executor.StartRental(r.Context(), execReq)
 	if err != nil {
-		if errors.Is(err, rental.ErrSessionAlreadyActive) {
-			h.writeError(w, http.StatusConflict, "rental already exists", "RENTAL_EXISTS")
-			return
-		}
-		if errors.Is(err, rental.ErrContainerNotHealthy) {
-			h.writeError(w, http.StatusServiceUnavailable, "container failed to start", "CONTAINER_NOT_READY")
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, err.Error(), "INTERNAL_ERROR")
+		h.writeRentalError(w, err)
 		return
 	}
 
-	resp := StartRentalResponse{
-		SessionID:  req.SessionID,
+	h.writeJSON(w, http.StatusOK, startResponse(req.SessionID, connInfo))
+}
+
+// startResponse builds the connection details returned for a started rental
+func startResponse(sessionID string, connInfo *rental.ConnectionInfo) StartRentalResponse {
+	return StartRentalResponse{
+		SessionID:  sessionID,
 		SSHHost:    connInfo.Host,
 		SSHPort:    connInfo.Port,
 		SSHUser:    connInfo.User,
 		SSHCommand: connInfo.Command,
+		Ports:      connInfo.Ports,
+
+		AccessMode:  string(connInfo.AccessMode),
+		AccessURL:   connInfo.AccessURL,
+		AccessToken: connInfo.AccessToken,
 	}
+}
 
-	h.writeJSON(w, http.StatusOK, resp)
+// HandleRebuildRental handles POST /rentals/rebuild. The rental keeps its
+// GPU, ports, credentials and workspace; the container is replaced.
+func (h *RentalHandler) HandleRebuildRental(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	var req RebuildRentalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.SessionID == "" {
+		h.writeError(w, http.StatusBadRequest, "sessionId is required", "MISSING_SESSION_ID")
+		return
+	}
+	if req.Image == "" {
+		h.writeError(w, http.StatusBadRequest, "image is required", "MISSING_IMAGE")
+		return
+	}
+
+	connInfo, err := h.executor.RebuildRental(r.Context(), rental.RebuildRentalRequest{
+		SessionID:    req.SessionID,
+		Image:        req.Image,
+		RegistryAuth: req.RegistryAuth,
+		Host:         h.hostAddr,
+	})
+	if err != nil {
+		h.writeRentalError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, RebuildRentalResponse{
+		StartRentalResponse: startResponse(req.SessionID, connInfo),
+		ContainerID:         connInfo.ContainerID,
+	})
 }
 
 // HandleStopRental handles POST /rentals/stop
@@ -201,6 +318,136 @@ func (h *RentalHandler) HandleGetStatus(w http.ResponseWriter, r *http.Request)
 	h.writeJSON(w, http.StatusOK, state)
 }
 
+// HandleGetUsage handles GET /rentals/usage?sessionId=xxx. Usage stays
+// available after the rental ends (see -usage-retention).
+func (h *RentalHandler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		h.writeError(w, http.StatusBadRequest, "sessionId query param required", "MISSING_SESSION_ID")
+		return
+	}
+
+	usage, err := h.executor.GetUsage(sessionID)
+	if err != nil {
+		if errors.Is(err, rental.ErrSessionNotFound) {
+			h.writeError(w, http.StatusNotFound, "no usage recorded for rental", "USAGE_NOT_FOUND")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, err.Error(), "INTERNAL_ERROR")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, usage)
+}
+
+// HandleDrain handles GET and POST /node/drain. POST turns drain mode on or
+// off; both return the current state.
+func (h *RentalHandler) HandleDrain(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req DrainRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+			return
+		}
+		h.executor.SetDraining(req.Enabled)
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, DrainResponse{
+		Draining:    h.executor.Draining(),
+		LiveRentals: h.executor.LiveRentals(),
+	})
+}
+
+// HandleGetLogs handles GET /rentals/logs?sessionId=xxx&tail=N&follow=true.
+// Logs are streamed as plain text; with follow the response stays open until
+// the container exits, the client disconnects or maxBytes is reached.
+func (h *RentalHandler) HandleGetLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	query := r.URL.Query()
+	sessionID := query.Get("sessionId")
+	if sessionID == "" {
+		h.writeError(w, http.StatusBadRequest, "sessionId query param required", "MISSING_SESSION_ID")
+		return
+	}
+
+	var opts container.LogOptions
+	if v := query.Get("tail"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			h.writeError(w, http.StatusBadRequest, "tail must be a non-negative integer", "INVALID_REQUEST")
+			return
+		}
+		opts.Tail = n
+	}
+	if v := query.Get("maxBytes"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			h.writeError(w, http.StatusBadRequest, "maxBytes must be a non-negative integer", "INVALID_REQUEST")
+			return
+		}
+		opts.MaxBytes = n
+	}
+	if opts.MaxBytes == 0 || opts.MaxBytes > container.DefaultLogMaxBytes {
+		opts.MaxBytes = container.DefaultLogMaxBytes
+	}
+	opts.Follow = query.Get("follow") == "true" || query.Get("follow") == "1"
+
+	out := &logWriter{w: w}
+	err := h.executor.RentalLogs(r.Context(), sessionID, opts, out)
+	if out.started || errors.Is(err, container.ErrLogLimitReached) {
+		return
+	}
+	if err != nil {
+		if errors.Is(err, rental.ErrSessionNotFound) {
+			h.writeError(w, http.StatusNotFound, "rental not found", "RENTAL_NOT_FOUND")
+			return
+		}
+		if errors.Is(err, rental.ErrLogsUnavailable) {
+			h.writeError(w, http.StatusConflict, err.Error(), "LOGS_UNAVAILABLE")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, err.Error(), "INTERNAL_ERROR")
+		return
+	}
+	// No output yet
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// logWriter streams log output, sending headers on the first write so errors
+// before any output can still be reported as JSON
+type logWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	if !l.started {
+		l.started = true
+		l.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		l.w.WriteHeader(http.StatusOK)
+	}
+	n, err := l.w.Write(p)
+	if f, ok := l.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
 // writeJSON writes a JSON response
 func (h *RentalHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -212,3 +459,12 @@ func (h *RentalHandler) writeJSON(w http.ResponseWriter, status int, data interf
 func (h *RentalHandler) writeError(w http.ResponseWriter, status int, message, code string) {
 	h.writeJSON(w, status, ErrorResponse{Error: message, Code: code})
 }
+
+// writeRentalError writes a start or rebuild failure with its code and status
+func (h *RentalHandler) writeRentalError(w http.ResponseWriter, err error) {
+	code, status := rental.ErrorCode(err)
+	if code == "" {
+		code = "INTERNAL_ERROR"
+	}
+	h.writeError(w, status, err.Error(), code)
+}
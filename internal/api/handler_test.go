@@ -5,12 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
 	"github.com/worldland/worldland-node/internal/rental"
 )
 
@@ -18,7 +22,13 @@ import (
 type MockRentalExecutor struct {
 	StartRentalFn     func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error)
 	StopRentalFn      func(ctx context.Context, sessionID string) error
+	RebuildRentalFn   func(ctx context.Context, req rental.RebuildRentalRequest) (*rental.ConnectionInfo, error)
 	GetRentalStatusFn func(sessionID string) (*rental.RentalState, error)
+	RentalLogsFn      func(ctx context.Context, sessionID string, opts container.LogOptions, w io.Writer) error
+	GetUsageFn        func(sessionID string) (*rental.UsageRecord, error)
+
+	draining    bool
+	liveRentals int
 }
 
 func (m *MockRentalExecutor) StartRental(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
@@ -35,6 +45,13 @@ func (m *MockRentalExecutor) StopRental(ctx context.Context, sessionID string) e
 	return errors.New("StopRentalFn not implemented")
 }
 
+func (m *MockRentalExecutor) RebuildRental(ctx context.Context, req rental.RebuildRentalRequest) (*rental.ConnectionInfo, error) {
+	if m.RebuildRentalFn != nil {
+		return m.RebuildRentalFn(ctx, req)
+	}
+	return nil, errors.New("RebuildRentalFn not implemented")
+}
+
 func (m *MockRentalExecutor) GetRentalStatus(sessionID string) (*rental.RentalState, error) {
 	if m.GetRentalStatusFn != nil {
 		return m.GetRentalStatusFn(sessionID)
@@ -42,6 +59,24 @@ func (m *MockRentalExecutor) GetRentalStatus(sessionID string) (*rental.RentalSt
 	return nil, errors.New("GetRentalStatusFn not implemented")
 }
 
+func (m *MockRentalExecutor) RentalLogs(ctx context.Context, sessionID string, opts container.LogOptions, w io.Writer) error {
+	if m.RentalLogsFn != nil {
+		return m.RentalLogsFn(ctx, sessionID, opts, w)
+	}
+	return errors.New("RentalLogsFn not implemented")
+}
+
+func (m *MockRentalExecutor) GetUsage(sessionID string) (*rental.UsageRecord, error) {
+	if m.GetUsageFn != nil {
+		return m.GetUsageFn(sessionID)
+	}
+	return nil, errors.New("GetUsageFn not implemented")
+}
+
+func (m *MockRentalExecutor) SetDraining(draining bool) { m.draining = draining }
+func (m *MockRentalExecutor) Draining() bool            { return m.draining }
+func (m *MockRentalExecutor) LiveRentals() int          { return m.liveRentals }
+
 func TestHandleStartRental_Success(t *testing.T) {
 	mock := &MockRentalExecutor{
 		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
@@ -364,3 +399,472 @@ func TestHandleGetStatus_NotFound_Returns404(t *testing.T) {
 
 	assert.Equal(t, "RENTAL_NOT_FOUND", errResp.Code)
 }
+
+func TestHandleStartRental_ExposedPorts(t *testing.T) {
+	var received rental.StartRentalRequest
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			received = req
+			return &rental.ConnectionInfo{
+				Host: "provider.example.com",
+				Port: 30001,
+				User: "ubuntu",
+				Ports: []container.PortMapping{
+					{ContainerPort: 8888, HostPort: 30002, Protocol: "tcp"},
+				},
+			}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","exposedPorts":[{"containerPort":8888}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []container.PortMapping{{ContainerPort: 8888}}, received.ExposedPorts)
+
+	var resp StartRentalResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, []container.PortMapping{{ContainerPort: 8888, HostPort: 30002, Protocol: "tcp"}}, resp.Ports)
+}
+
+func TestHandleStartRental_InvalidExposedPort_Returns400(t *testing.T) {
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			return nil, rental.ErrInvalidExposedPort
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","exposedPorts":[{"containerPort":22}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var errResp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+	assert.Equal(t, "INVALID_EXPOSED_PORT", errResp.Code)
+}
+
+func TestHandleStartRental_JupyterMode(t *testing.T) {
+	var received rental.StartRentalRequest
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			received = req
+			return &rental.ConnectionInfo{
+				Host:        "provider.example.com",
+				Port:        30001,
+				User:        "ubuntu",
+				AccessMode:  container.AccessModeJupyter,
+				AccessPort:  30002,
+				AccessURL:   "http://provider.example.com:30002/lab?token=tok",
+				AccessToken: "tok",
+			}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","accessMode":"jupyter"}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, container.AccessModeJupyter, received.AccessMode)
+
+	var resp StartRentalResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "jupyter", resp.AccessMode)
+	assert.Equal(t, "http://provider.example.com:30002/lab?token=tok", resp.AccessURL)
+	assert.Equal(t, "tok", resp.AccessToken)
+}
+
+func TestHandleStartRental_InvalidAccessMode_Returns400(t *testing.T) {
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			return nil, container.ErrInvalidAccessMode
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","accessMode":"vnc"}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var errResp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+	assert.Equal(t, "INVALID_ACCESS_MODE", errResp.Code)
+}
+
+func TestHandleStartRental_PassesEnvInitCommandAndWorkDir(t *testing.T) {
+	var received rental.StartRentalRequest
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			received = req
+			return &rental.ConnectionInfo{Host: "provider.example.com", Port: 30001, User: "ubuntu"}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw",` +
+		`"env":{"HF_HOME":"/data/hf"},"initCommand":"python serve.py","workDir":"/workspace"}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, container.Env{"HF_HOME": "/data/hf"}, received.Env)
+	assert.Equal(t, "python serve.py", received.InitCommand)
+	assert.Equal(t, "/workspace", received.WorkDir)
+}
+
+func TestHandleStartRental_RestartPolicy(t *testing.T) {
+	var received rental.StartRentalRequest
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			received = req
+			return &rental.ConnectionInfo{Host: "provider.example.com", Port: 30001, User: "ubuntu"}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","restartPolicy":"on-failure:2"}`)
+	rec := httptest.NewRecorder()
+	handler.HandleStartRental(rec, httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, received.RestartPolicy)
+	assert.Equal(t, rental.RestartPolicy{Mode: rental.RestartOnFailure, MaxRestarts: 2}, *received.RestartPolicy)
+
+	body = []byte(`{"sessionId":"session-456","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","restartPolicy":"always"}`)
+	rec = httptest.NewRecorder()
+	handler.HandleStartRental(rec, httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "INVALID_RESTART_POLICY")
+}
+
+func TestHandleStartRental_DeniedEnv_Returns400(t *testing.T) {
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			return nil, rental.ErrEnvNotAllowed
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","env":{"NVIDIA_VISIBLE_DEVICES":"all"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var errResp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+	assert.Equal(t, "ENV_NOT_ALLOWED", errResp.Code)
+}
+
+func TestHandleStartRental_ImagePolicyRejection_Returns403(t *testing.T) {
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			return nil, &container.ImagePolicyError{Code: container.ImageCodeNotAllowed, Image: req.Image, Reason: "not allowed"}
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw","image":"evil/miner:latest"}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	var errResp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+	assert.Equal(t, container.ImageCodeNotAllowed, errResp.Code)
+	assert.Contains(t, errResp.Error, "evil/miner:latest")
+}
+
+func TestHandleGetStatus_ReportsPullProgress(t *testing.T) {
+	mock := &MockRentalExecutor{
+		GetRentalStatusFn: func(sessionID string) (*rental.RentalState, error) {
+			return &rental.RentalState{
+				SessionID: sessionID,
+				Image:     "pytorch/pytorch",
+				Pull:      &container.PullProgress{Image: "pytorch/pytorch", CurrentBytes: 5 << 30, TotalBytes: 20 << 30},
+			}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/rentals/status?sessionId=session-123", nil)
+	rec := httptest.NewRecorder()
+
+	handler.HandleGetStatus(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var state rental.RentalState
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&state))
+	require.NotNil(t, state.Pull)
+	assert.Equal(t, int64(5<<30), state.Pull.CurrentBytes)
+	assert.Equal(t, int64(20<<30), state.Pull.TotalBytes)
+}
+
+func TestHandleGetStatus_ReportsPhase(t *testing.T) {
+	mock := &MockRentalExecutor{
+		GetRentalStatusFn: func(sessionID string) (*rental.RentalState, error) {
+			return &rental.RentalState{
+				SessionID:     sessionID,
+				Phase:         rental.PhaseFailed,
+				FailureReason: "failed health check",
+				History: []rental.PhaseTransition{
+					{To: rental.PhasePending},
+					{From: rental.PhasePending, To: rental.PhaseFailed, Reason: "failed health check"},
+				},
+			}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/rentals/status?sessionId=session-123", nil)
+	rec := httptest.NewRecorder()
+
+	handler.HandleGetStatus(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var state rental.RentalState
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&state))
+	assert.Equal(t, rental.PhaseFailed, state.Phase)
+	assert.Equal(t, "failed health check", state.FailureReason)
+	assert.Len(t, state.History, 2)
+}
+
+func TestHandleStartRental_StoppedWhileStarting_Returns409(t *testing.T) {
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			return nil, rental.ErrStartAborted
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw"}`)
+	req := httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.HandleStartRental(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var errResp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+	assert.Equal(t, "RENTAL_STOPPED", errResp.Code)
+}
+
+func TestHandleGetLogs_StreamsOutput(t *testing.T) {
+	var gotOpts container.LogOptions
+	mock := &MockRentalExecutor{
+		RentalLogsFn: func(ctx context.Context, sessionID string, opts container.LogOptions, w io.Writer) error {
+			gotOpts = opts
+			_, err := io.WriteString(w, "sshd listening on 22\n")
+			return err
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/rentals/logs?sessionId=session-123&tail=50&follow=true", nil)
+	rec := httptest.NewRecorder()
+
+	handler.HandleGetLogs(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "sshd listening on 22\n", rec.Body.String())
+	assert.Equal(t, 50, gotOpts.Tail)
+	assert.True(t, gotOpts.Follow)
+	assert.Equal(t, int64(container.DefaultLogMaxBytes), gotOpts.MaxBytes)
+}
+
+func TestHandleGetLogs_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		wantCode int
+		wantErr  string
+	}{
+		{"missing session", "", nil, http.StatusBadRequest, "MISSING_SESSION_ID"},
+		{"bad tail", "sessionId=s&tail=abc", nil, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"not found", "sessionId=s", rental.ErrSessionNotFound, http.StatusNotFound, "RENTAL_NOT_FOUND"},
+		{"no container", "sessionId=s", rental.ErrLogsUnavailable, http.StatusConflict, "LOGS_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockRentalExecutor{
+				RentalLogsFn: func(ctx context.Context, sessionID string, opts container.LogOptions, w io.Writer) error {
+					return tt.err
+				},
+			}
+			handler := NewRentalHandler(mock, "provider.example.com")
+
+			req := httptest.NewRequest(http.MethodGet, "/rentals/logs?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.HandleGetLogs(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			var errResp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+			assert.Equal(t, tt.wantErr, errResp.Code)
+		})
+	}
+}
+
+func TestHandleGetUsage_ReturnsRecord(t *testing.T) {
+	ended := time.Now()
+	mock := &MockRentalExecutor{
+		GetUsageFn: func(sessionID string) (*rental.UsageRecord, error) {
+			if sessionID != "session-123" {
+				return nil, rental.ErrSessionNotFound
+			}
+			return &rental.UsageRecord{SessionID: sessionID, Samples: 60, CPUSeconds: 1800, GPUMemoryPeakMB: 40000, EndedAt: &ended}, nil
+		},
+	}
+
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/rentals/usage?sessionId=session-123", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleGetUsage(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var usage rental.UsageRecord
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&usage))
+	assert.Equal(t, 60, usage.Samples)
+	assert.Equal(t, 1800.0, usage.CPUSeconds)
+	assert.Equal(t, uint64(40000), usage.GPUMemoryPeakMB)
+	assert.NotNil(t, usage.EndedAt)
+
+	req = httptest.NewRequest(http.MethodGet, "/rentals/usage?sessionId=unknown", nil)
+	rec = httptest.NewRecorder()
+	handler.HandleGetUsage(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandleDrain(t *testing.T) {
+	mock := &MockRentalExecutor{liveRentals: 2}
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	rec := httptest.NewRecorder()
+	handler.HandleDrain(rec, httptest.NewRequest(http.MethodPost, "/node/drain", bytes.NewReader([]byte(`{"enabled":true}`))))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, mock.draining)
+	var resp DrainResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, DrainResponse{Draining: true, LiveRentals: 2}, resp)
+
+	rec = httptest.NewRecorder()
+	handler.HandleDrain(rec, httptest.NewRequest(http.MethodGet, "/node/drain", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"draining":true`)
+}
+
+func TestHandleStartRental_Draining_Returns503(t *testing.T) {
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			return nil, rental.ErrDraining
+		},
+	}
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw"}`)
+	rec := httptest.NewRecorder()
+	handler.HandleStartRental(rec, httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "NODE_DRAINING")
+}
+
+func TestHandleStartRental_IPCSettings(t *testing.T) {
+	var got rental.StartRentalRequest
+	mock := &MockRentalExecutor{
+		StartRentalFn: func(ctx context.Context, req rental.StartRentalRequest) (*rental.ConnectionInfo, error) {
+			got = req
+			return nil, fmt.Errorf("%w: ipc mode \"host\" not allowed", rental.ErrInvalidIPCSettings)
+		},
+	}
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	body := []byte(`{"sessionId":"session-123","gpuDeviceId":"GPU-uuid-456","sshPassword":"pw",` +
+		`"shmSizeBytes":4294967296,"ulimits":[{"name":"memlock","soft":-1,"hard":-1}],"ipcMode":"host"}`)
+	rec := httptest.NewRecorder()
+	handler.HandleStartRental(rec, httptest.NewRequest(http.MethodPost, "/rentals/start", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "INVALID_IPC_SETTINGS")
+	assert.Equal(t, int64(4<<30), got.ShmSizeBytes)
+	assert.Equal(t, []container.Ulimit{{Name: "memlock", Soft: -1, Hard: -1}}, got.Ulimits)
+	assert.Equal(t, "host", got.IPCMode)
+}
+
+func TestHandleRebuildRental(t *testing.T) {
+	mock := &MockRentalExecutor{
+		RebuildRentalFn: func(ctx context.Context, req rental.RebuildRentalRequest) (*rental.ConnectionInfo, error) {
+			if req.SessionID != "session-123" {
+				return nil, rental.ErrSessionNotFound
+			}
+			if req.Image == "stopped" {
+				return nil, rental.ErrRentalNotRunning
+			}
+			return &rental.ConnectionInfo{Host: req.Host, Port: 30001, User: "ubuntu", ContainerID: "container-new"}, nil
+		},
+	}
+	handler := NewRentalHandler(mock, "provider.example.com")
+
+	rebuild := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.HandleRebuildRental(rec, httptest.NewRequest(http.MethodPost, "/rentals/rebuild", bytes.NewReader([]byte(body))))
+		return rec
+	}
+
+	rec := rebuild(`{"sessionId":"session-123","image":"nvidia/cuda:12.4.1-runtime-ubuntu22.04"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp RebuildRentalResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "container-new", resp.ContainerID)
+	assert.Equal(t, 30001, resp.SSHPort)
+	assert.Equal(t, "provider.example.com", resp.SSHHost)
+
+	assert.Equal(t, http.StatusBadRequest, rebuild(`{"sessionId":"session-123"}`).Code)
+	assert.Equal(t, http.StatusNotFound, rebuild(`{"sessionId":"unknown","image":"img"}`).Code)
+	assert.Equal(t, http.StatusConflict, rebuild(`{"sessionId":"session-123","image":"stopped"}`).Code)
+}
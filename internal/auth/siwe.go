@@ -178,6 +178,17 @@ func (c *SIWEClient) signMessage(message string) (string, error) {
 	return "0x" + hex.EncodeToString(signature), nil
 }
 
+// SignHash signs a 32-byte digest (e.g. an EIP-712 hash) with the wallet
+// key. The signature uses v = 27/28 like signMessage.
+func (c *SIWEClient) SignHash(hash []byte) ([]byte, error) {
+	signature, err := crypto.Sign(hash, c.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
 func (c *SIWEClient) loginWithSignature(message, signature string) (string, error) {
 	payload := map[string]string{
 		"message":   message,
@@ -0,0 +1,164 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AccessMode selects the service a renter uses to reach the container
+type AccessMode string
+
+const (
+	AccessModeSSH        AccessMode = "ssh"
+	AccessModeJupyter    AccessMode = "jupyter"
+	AccessModeCodeServer AccessMode = "code-server"
+)
+
+// Container ports of the web access services
+const (
+	jupyterPort    = 8888
+	codeServerPort = 8080
+)
+
+var ErrInvalidAccessMode = errors.New("invalid access mode")
+
+// ParseAccessMode validates an access mode string. Empty selects SSH.
+func ParseAccessMode(s string) (AccessMode, error) {
+	switch m := AccessMode(s); m {
+	case "":
+		return AccessModeSSH, nil
+	case AccessModeSSH, AccessModeJupyter, AccessModeCodeServer:
+		return m, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidAccessMode, s)
+	}
+}
+
+// ServicePort returns the container port of the mode's web service (0 for SSH)
+func (m AccessMode) ServicePort() int {
+	switch m {
+	case AccessModeJupyter:
+		return jupyterPort
+	case AccessModeCodeServer:
+		return codeServerPort
+	default:
+		return 0
+	}
+}
+
+// NeedsToken reports whether the mode's service is protected by an access token
+func (m AccessMode) NeedsToken() bool {
+	return m.ServicePort() != 0
+}
+
+// URL returns the address a renter opens in the browser (empty for SSH)
+func (m AccessMode) URL(host string, hostPort int, token string) string {
+	switch m {
+	case AccessModeJupyter:
+		return fmt.Sprintf("http://%s:%d/lab?token=%s", host, hostPort, token)
+	case AccessModeCodeServer:
+		// code-server uses password login; the token is the password
+		return fmt.Sprintf("http://%s:%d/", host, hostPort)
+	default:
+		return ""
+	}
+}
+
+// setupScript returns the entrypoint script for the mode
+func (m AccessMode) setupScript() string {
+	switch m {
+	case AccessModeJupyter:
+		return jupyterSetupScript
+	case AccessModeCodeServer:
+		return codeServerSetupScript
+	default:
+		return sshSetupScript
+	}
+}
+
+// sshInstallScript installs and configures SSH server inside any base image
+// (CUDA, PyTorch, TensorFlow, etc.). SSH is available in every access mode.
+const sshInstallScript = `set -e
+export DEBIAN_FRONTEND=noninteractive
+# Images that ship sshd and sudo skip the install (required with a read-only root)
+if ! command -v sshd > /dev/null 2>&1 || ! command -v sudo > /dev/null 2>&1; then
+  apt-get update -qq
+  apt-get install -y -qq openssh-server sudo > /dev/null 2>&1
+fi
+
+# Create user with password
+useradd -m -s /bin/bash "$USER_NAME" 2>/dev/null || true
+# The home directory may be a workspace volume created by root
+chown "$USER_NAME": "/home/$USER_NAME"
+echo "$USER_NAME:$SSH_PASSWORD" | chpasswd
+# No sudo under no-new-privileges (RENTAL_SUDO=0); the line persists in /etc across restarts
+if [ "$RENTAL_SUDO" != "0" ]; then
+  grep -qxF "$USER_NAME ALL=(ALL) NOPASSWD:ALL" /etc/sudoers ||
+    echo "$USER_NAME ALL=(ALL) NOPASSWD:ALL" >> /etc/sudoers
+fi
+
+# Configure sshd
+mkdir -p /run/sshd
+sed -i 's/#PasswordAuthentication yes/PasswordAuthentication yes/' /etc/ssh/sshd_config
+sed -i 's/PasswordAuthentication no/PasswordAuthentication yes/' /etc/ssh/sshd_config
+sed -i 's/#PermitRootLogin.*/PermitRootLogin no/' /etc/ssh/sshd_config
+
+# Renter env for SSH login shells (sshd doesn't pass the container env on)
+: > /etc/profile.d/worldland-env.sh
+for key in $RENTAL_ENV_KEYS; do
+  printf 'export %s=%q\n' "$key" "${!key}" >> /etc/profile.d/worldland-env.sh
+done
+chmod 600 /etc/profile.d/worldland-env.sh
+chown "$USER_NAME" /etc/profile.d/worldland-env.sh
+
+# Working directory for logins and the init command
+if [ -n "$WORK_DIR" ]; then
+  mkdir -p "$WORK_DIR"
+  chown "$USER_NAME" "$WORK_DIR"
+  # .bashrc persists in the workspace across rebuilds
+  grep -qxF "cd \"$WORK_DIR\"" "/home/$USER_NAME/.bashrc" 2>/dev/null ||
+    echo "cd \"$WORK_DIR\"" >> "/home/$USER_NAME/.bashrc"
+fi
+
+# Renter init command runs alongside the access service
+if [ -n "$INIT_COMMAND" ]; then
+  (cd "${WORK_DIR:-/home/$USER_NAME}" && sudo -E -u "$USER_NAME" -H bash -c "$INIT_COMMAND") \
+    > /var/log/worldland-init.log 2>&1 &
+fi
+`
+
+// sshSetupScript is the entrypoint script for SSH rentals
+const sshSetupScript = sshInstallScript + `
+echo "SSH server ready on port 22"
+exec /usr/sbin/sshd -D
+`
+
+// jupyterSetupScript runs sshd in the background and JupyterLab in the
+// foreground as the rental user
+const jupyterSetupScript = sshInstallScript + `
+/usr/sbin/sshd
+
+if ! command -v jupyter > /dev/null 2>&1; then
+  apt-get install -y -qq python3-pip > /dev/null 2>&1
+  pip3 install -q jupyterlab 2>/dev/null || pip3 install -q --break-system-packages jupyterlab
+fi
+
+echo "JupyterLab starting on port 8888"
+exec sudo -E -u "$USER_NAME" -H jupyter lab --ip=0.0.0.0 --port=8888 --no-browser \
+  --ServerApp.token="$ACCESS_TOKEN" --ServerApp.root_dir="/home/$USER_NAME"
+`
+
+// codeServerSetupScript runs sshd in the background and code-server in the
+// foreground as the rental user, using the access token as its password
+const codeServerSetupScript = sshInstallScript + `
+/usr/sbin/sshd
+
+if ! command -v code-server > /dev/null 2>&1; then
+  apt-get install -y -qq curl ca-certificates > /dev/null 2>&1
+  curl -fsSL https://code-server.dev/install.sh | sh > /dev/null
+fi
+
+echo "code-server starting on port 8080"
+exec sudo -E -u "$USER_NAME" -H env PASSWORD="$ACCESS_TOKEN" \
+  code-server --bind-addr 0.0.0.0:8080 --auth password "/home/$USER_NAME"
+`
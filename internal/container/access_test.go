@@ -0,0 +1,63 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAccessMode(t *testing.T) {
+	mode, err := ParseAccessMode("")
+	require.NoError(t, err)
+	assert.Equal(t, AccessModeSSH, mode)
+
+	mode, err = ParseAccessMode("code-server")
+	require.NoError(t, err)
+	assert.Equal(t, AccessModeCodeServer, mode)
+
+	_, err = ParseAccessMode("vnc")
+	assert.ErrorIs(t, err, ErrInvalidAccessMode)
+}
+
+func TestAccessMode_URL(t *testing.T) {
+	assert.Equal(t, "http://node.example.com:30002/lab?token=abc", AccessModeJupyter.URL("node.example.com", 30002, "abc"))
+	assert.Equal(t, "http://node.example.com:30002/", AccessModeCodeServer.URL("node.example.com", 30002, "abc"))
+	assert.Empty(t, AccessModeSSH.URL("node.example.com", 30001, ""))
+}
+
+func TestCreateContainer_JupyterModeUsesJupyterScript(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:   "session-abc",
+		Image:       "img",
+		SSHPort:     30001,
+		AccessMode:  AccessModeJupyter,
+		AccessToken: "tok123",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{jupyterSetupScript}, []string(mock.LastCreateConfig.Cmd))
+	assert.Contains(t, mock.LastCreateConfig.Env, "ACCESS_TOKEN=tok123")
+}
+
+func TestCreateContainer_DefaultModeUsesSSHScript(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID: "session-abc",
+		Image:     "img",
+		SSHPort:   30001,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{sshSetupScript}, []string(mock.LastCreateConfig.Cmd))
+	for _, e := range mock.LastCreateConfig.Env {
+		assert.NotContains(t, e, "ACCESS_TOKEN")
+	}
+}
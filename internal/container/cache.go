@@ -0,0 +1,303 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+)
+
+// CachedImage describes an image present on the node
+type CachedImage struct {
+	ID        string    `json:"id"`
+	Tags      []string  `json:"tags,omitempty"`
+	Digests   []string  `json:"digests,omitempty"`
+	SizeBytes int64     `json:"sizeBytes"`
+	LastUsed  time.Time `json:"lastUsed"`
+	Warm      bool      `json:"warm,omitempty"` // Operator-configured, never evicted
+	InUse     bool      `json:"inUse,omitempty"`
+}
+
+// PrefetchResult reports the outcome of pulling one image
+type PrefetchResult struct {
+	Image string `json:"image"`
+	Ref   string `json:"ref,omitempty"` // Reference actually pulled (digest-pinned under policy)
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"` // ImageCode* rejection, or PrefetchCodePullFailed
+}
+
+// PrefetchCodePullFailed marks a prefetch whose pull failed. Prefetches pull
+// without renter credentials, so private images fail this way unless an
+// operator credential helper covers their registry.
+const PrefetchCodePullFailed = "PULL_FAILED"
+
+// ImageCache keeps frequently used images on disk and evicts the least
+// recently used ones when the cache grows past its size limit. Images used by
+// any container (running rentals, mining, rentals in their grace period) and
+// warm images are never evicted. Last-use times are kept in memory only:
+// after a node restart images rank by creation time until used again.
+type ImageCache struct {
+	docker   *DockerService
+	maxBytes int64    // Evict down to this total size (0 = never evict)
+	warm     []string // Normalized references kept pulled
+
+	mu        sync.Mutex
+	lastUsed  map[string]time.Time // Normalized reference or image ID -> last use
+	refs      []string             // Tags and digests as of the last image listing
+	refsStale bool                 // Set when images were pulled or removed since
+}
+
+// NewImageCache creates an image cache over the Docker service. warm lists
+// images to prefetch and protect from eviction.
+func NewImageCache(docker *DockerService, maxBytes int64, warm []string) *ImageCache {
+	c := &ImageCache{
+		docker:    docker,
+		maxBytes:  maxBytes,
+		lastUsed:  make(map[string]time.Time),
+		refsStale: true,
+	}
+	for _, ref := range warm {
+		c.warm = append(c.warm, normalizeRef(ref))
+	}
+	return c
+}
+
+// WithImageCache records image use on pulls and container creation and
+// start for LRU eviction
+func (s *DockerService) WithImageCache(c *ImageCache) *DockerService {
+	s.imageCache = c
+	return s
+}
+
+// Touch marks an image as used now
+func (c *ImageCache) Touch(ref string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastUsed[normalizeRef(ref)] = time.Now()
+}
+
+// changed notes that images were pulled or removed, so Refs lists them
+// again on its next call
+func (c *ImageCache) changed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refsStale = true
+}
+
+// Refs returns the tags and digests of images on the node. Images are only
+// listed again after this node pulled or removed one.
+func (c *ImageCache) Refs(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	if !c.refsStale {
+		refs := c.refs
+		c.mu.Unlock()
+		return refs, nil
+	}
+	// Cleared before listing so a change during the listing marks it again
+	c.refsStale = false
+	c.mu.Unlock()
+
+	images, err := c.docker.cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		c.changed()
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	refs := []string{}
+	for _, img := range images {
+		refs = append(refs, img.RepoTags...)
+		refs = append(refs, img.RepoDigests...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refs = refs
+	return refs, nil
+}
+
+// Prefetch pulls images through the node's image policy. Errors are reported
+// per image so one bad reference doesn't stop the rest. Pulls carry no renter
+// credentials: private images only prefetch from registries covered by an
+// operator credential helper.
+func (c *ImageCache) Prefetch(ctx context.Context, refs []string) []PrefetchResult {
+	results := make([]PrefetchResult, 0, len(refs))
+	for _, ref := range refs {
+		res := PrefetchResult{Image: ref}
+		pulled, err := c.docker.PrepareImage(ctx, ref, nil, nil)
+		if err != nil {
+			slog.Warn("image prefetch failed", "image", ref, "error", err)
+			res.Error = err.Error()
+			res.Code = PrefetchCodePullFailed
+			var policyErr *ImagePolicyError
+			if errors.As(err, &policyErr) {
+				res.Code = policyErr.Code
+			}
+		} else {
+			res.Ref = pulled
+			c.Touch(pulled)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// PrefetchWarm pulls the configured warm images
+func (c *ImageCache) PrefetchWarm(ctx context.Context) []PrefetchResult {
+	return c.Prefetch(ctx, c.warm)
+}
+
+// Cached lists images on the node with their cache metadata
+func (c *ImageCache) Cached(ctx context.Context) ([]CachedImage, error) {
+	images, err := c.docker.cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	inUse, err := c.imagesInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := make([]CachedImage, 0, len(images))
+	for _, img := range images {
+		entry := CachedImage{
+			ID:        img.ID,
+			Tags:      img.RepoTags,
+			Digests:   img.RepoDigests,
+			SizeBytes: img.Size,
+			LastUsed:  time.Unix(img.Created, 0),
+			InUse:     inUse[img.ID],
+		}
+		for _, ref := range imageRefs(img) {
+			if t, ok := c.lastUsed[ref]; ok && t.After(entry.LastUsed) {
+				entry.LastUsed = t
+			}
+			if c.isWarm(ref) {
+				entry.Warm = true
+			}
+		}
+		cached = append(cached, entry)
+	}
+	return cached, nil
+}
+
+// Evict removes least recently used images until the cache fits its size
+// limit. Returns the IDs of removed images.
+func (c *ImageCache) Evict(ctx context.Context) ([]string, error) {
+	if c.maxBytes <= 0 {
+		return nil, nil
+	}
+
+	cached, err := c.Cached(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	var candidates []CachedImage
+	for _, img := range cached {
+		total += img.SizeBytes
+		if !img.Warm && !img.InUse {
+			candidates = append(candidates, img)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed.Before(candidates[j].LastUsed)
+	})
+
+	var removed []string
+	for _, img := range candidates {
+		if total <= c.maxBytes {
+			break
+		}
+		// Not forced: Docker refuses if a container started using it meanwhile
+		if _, err := c.docker.cli.ImageRemove(ctx, img.ID, image.RemoveOptions{PruneChildren: true}); err != nil {
+			slog.Warn("failed to evict image", "image", img.ID, "error", err)
+			continue
+		}
+		slog.Info("evicted cached image", "image", img.ID, "tags", img.Tags, "size_bytes", img.SizeBytes)
+		total -= img.SizeBytes
+		removed = append(removed, img.ID)
+	}
+	if len(removed) > 0 {
+		c.changed()
+	}
+
+	if total > c.maxBytes {
+		slog.Warn("image cache over limit after eviction", "total_bytes", total, "limit_bytes", c.maxBytes)
+	}
+	return removed, nil
+}
+
+// Run evicts on the given interval until the context is cancelled
+func (c *ImageCache) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Evict(ctx); err != nil {
+				slog.Warn("image cache eviction failed", "error", err)
+			}
+		}
+	}
+}
+
+// imagesInUse returns IDs of images used by any container, running or not
+func (c *ImageCache) imagesInUse(ctx context.Context) (map[string]bool, error) {
+	containers, err := c.docker.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	inUse := make(map[string]bool, len(containers))
+	for _, ctr := range containers {
+		inUse[ctr.ImageID] = true
+	}
+	return inUse, nil
+}
+
+// isWarm reports whether ref is a warm image (c.warm is fixed at construction)
+func (c *ImageCache) isWarm(ref string) bool {
+	for _, w := range c.warm {
+		if w == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// imageRefs returns every name an image is known by
+func imageRefs(img image.Summary) []string {
+	refs := []string{img.ID}
+	for _, ref := range append(append([]string{}, img.RepoTags...), img.RepoDigests...) {
+		refs = append(refs, normalizeRef(ref))
+	}
+	return refs
+}
+
+// normalizeRef expands references to their fully qualified, tagged form
+// ("ubuntu" and "ubuntu:latest" both become "docker.io/library/ubuntu:latest")
+// so the names a rental asks for and the short names Docker reports in
+// RepoTags compare equal once both are normalized. Image IDs are unchanged.
+func normalizeRef(ref string) string {
+	if strings.HasPrefix(ref, "sha256:") {
+		return ref
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	return reference.TagNameOnly(named).String()
+}
@@ -0,0 +1,185 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gb = int64(1 << 30)
+
+func cacheFixture() *MockDockerClient {
+	now := time.Now()
+	return &MockDockerClient{
+		Images: []image.Summary{
+			{ID: "sha256:old", RepoTags: []string{"old/model:1"}, Size: 10 * gb, Created: now.Add(-72 * time.Hour).Unix()},
+			{ID: "sha256:mid", RepoTags: []string{"mid/model:1"}, Size: 10 * gb, Created: now.Add(-48 * time.Hour).Unix()},
+			{ID: "sha256:new", RepoTags: []string{"new/model:1"}, Size: 10 * gb, Created: now.Add(-24 * time.Hour).Unix()},
+			{ID: "sha256:rent", RepoTags: []string{"pytorch/pytorch:latest"}, Size: 20 * gb, Created: now.Add(-96 * time.Hour).Unix()},
+			{ID: "sha256:mine", RepoTags: []string{"mingeyom/worldland-mio:latest"}, Size: 5 * gb, Created: now.Add(-96 * time.Hour).Unix()},
+		},
+		Containers: []container.Summary{
+			{ID: "rental-1", ImageID: "sha256:rent"},
+		},
+	}
+}
+
+func TestImageCache_EvictsLeastRecentlyUsedFirst(t *testing.T) {
+	mock := cacheFixture()
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 40*gb, []string{"mingeyom/worldland-mio:latest"})
+
+	removed, err := cache.Evict(context.Background())
+
+	require.NoError(t, err)
+	// 55GB total, limit 40GB: in-use and warm images are skipped, oldest two go
+	assert.Equal(t, []string{"sha256:old", "sha256:mid"}, removed)
+	assert.Equal(t, []string{"sha256:old", "sha256:mid"}, mock.ImageRemoves)
+}
+
+func TestImageCache_TouchProtectsRecentlyUsedImages(t *testing.T) {
+	mock := cacheFixture()
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 45*gb, []string{"mingeyom/worldland-mio:latest"})
+
+	cache.Touch("old/model:1")
+	removed, err := cache.Evict(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sha256:mid"}, removed)
+}
+
+func TestImageCache_NeverEvictsInUseOrWarmImages(t *testing.T) {
+	mock := cacheFixture()
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 1*gb, []string{"mingeyom/worldland-mio"})
+
+	removed, err := cache.Evict(context.Background())
+
+	require.NoError(t, err)
+	assert.NotContains(t, removed, "sha256:rent")
+	assert.NotContains(t, removed, "sha256:mine")
+	assert.Len(t, removed, 3)
+}
+
+func TestImageCache_NoLimitNeverEvicts(t *testing.T) {
+	mock := cacheFixture()
+	cache := NewImageCache(NewDockerServiceWithClient(mock), 0, nil)
+
+	removed, err := cache.Evict(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	assert.Empty(t, mock.ImageRemoves)
+}
+
+func TestImageCache_CachedReportsUsageAndWarmth(t *testing.T) {
+	mock := cacheFixture()
+	cache := NewImageCache(NewDockerServiceWithClient(mock), 0, []string{"mingeyom/worldland-mio:latest"})
+
+	cached, err := cache.Cached(context.Background())
+
+	require.NoError(t, err)
+	byID := make(map[string]CachedImage)
+	for _, img := range cached {
+		byID[img.ID] = img
+	}
+	assert.True(t, byID["sha256:rent"].InUse)
+	assert.True(t, byID["sha256:mine"].Warm)
+	assert.False(t, byID["sha256:old"].InUse)
+	assert.Equal(t, 10*gb, byID["sha256:old"].SizeBytes)
+}
+
+func TestImageCache_PrefetchReportsPerImageResults(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true}
+	svc := NewDockerServiceWithClient(mock).WithImagePolicy(ImagePolicy{Deny: []string{"docker.io/evil/*"}})
+	cache := NewImageCache(svc, 0, nil)
+
+	results := cache.Prefetch(context.Background(), []string{"pytorch/pytorch:2.1", "evil/miner"})
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "pytorch/pytorch:2.1", results[0].Ref)
+	assert.Empty(t, results[0].Error)
+	assert.Contains(t, results[1].Error, "rejected")
+	assert.Equal(t, ImageCodeDenied, results[1].Code)
+	assert.Equal(t, []string{"pytorch/pytorch:2.1"}, mock.PullCalls)
+}
+
+func TestImageCache_PrefetchReportsPullFailureCode(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, PullError: errors.New("pull access denied")}
+	cache := NewImageCache(NewDockerServiceWithClient(mock), 0, nil)
+
+	results := cache.Prefetch(context.Background(), []string{"corp/private:1"})
+
+	require.Len(t, results, 1)
+	assert.Equal(t, PrefetchCodePullFailed, results[0].Code)
+}
+
+func TestImageCache_RefsListsImagesOnlyAfterChanges(t *testing.T) {
+	mock := cacheFixture()
+	mock.ImageMissing = true
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 0, nil)
+	svc.WithImageCache(cache)
+	ctx := context.Background()
+
+	refs, err := cache.Refs(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, refs, "old/model:1")
+	_, err = cache.Refs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, mock.ImageLists)
+
+	mock.Images = append(mock.Images, image.Summary{ID: "sha256:pulled", RepoTags: []string{"pulled/model:1"}})
+	_, err = svc.PrepareImage(ctx, "pulled/model:1", nil, nil)
+	require.NoError(t, err)
+
+	refs, err = cache.Refs(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, refs, "pulled/model:1")
+	assert.Equal(t, 2, mock.ImageLists)
+}
+
+func TestCreateContainer_TouchesImageCache(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 0, nil)
+	svc.WithImageCache(cache)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "a", Image: "ubuntu:22.04"})
+
+	require.NoError(t, err)
+	assert.Contains(t, cache.lastUsed, "docker.io/library/ubuntu:22.04")
+}
+
+func TestPrepareImage_TouchesImageCache(t *testing.T) {
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 0, nil)
+	svc.WithImageCache(cache)
+
+	_, err := svc.PrepareImage(context.Background(), "pytorch/pytorch:2.1", nil, nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, cache.lastUsed, "docker.io/pytorch/pytorch:2.1")
+}
+
+func TestStartContainer_TouchesImageCacheByID(t *testing.T) {
+	mock := &MockDockerClient{InspectResponse: types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "container-123", Image: "sha256:old"},
+	}}
+	svc := NewDockerServiceWithClient(mock)
+	cache := NewImageCache(svc, 0, nil)
+	svc.WithImageCache(cache)
+
+	require.NoError(t, svc.StartContainer(context.Background(), "container-123"))
+
+	assert.Contains(t, cache.lastUsed, "sha256:old")
+}
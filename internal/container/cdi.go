@@ -0,0 +1,203 @@
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidCDISpec    = errors.New("invalid cdi spec")
+	ErrCDIDeviceNotFound = errors.New("gpu has no cdi device")
+)
+
+// DefaultCDISpecDirs are where CDI spec files live, lowest priority first.
+// `nvidia-ctk cdi generate` writes to either.
+var DefaultCDISpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+
+// CDIKindNVIDIA is the device kind of NVIDIA GPUs and MIG slices
+const CDIKindNVIDIA = "nvidia.com/gpu"
+
+var (
+	cdiKindPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?/[a-zA-Z0-9]([a-zA-Z0-9_.-]*[a-zA-Z0-9])?$`)
+	cdiDevicePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.:-]*[a-zA-Z0-9])?$`)
+)
+
+// CDISpec is the part of a CDI spec file the node needs: which devices of
+// which kind exist. Container edits are applied by dockerd.
+type CDISpec struct {
+	Version string      `json:"cdiVersion" yaml:"cdiVersion"`
+	Kind    string      `json:"kind" yaml:"kind"`
+	Devices []CDIDevice `json:"devices" yaml:"devices"`
+}
+
+// CDIDevice is a device in a CDI spec
+type CDIDevice struct {
+	Name string `json:"name" yaml:"name"` // e.g. "0", "0:1", "all" or a GPU UUID
+}
+
+// ParseCDISpec parses a CDI spec in JSON or YAML, chosen by the file
+// extension of name
+func ParseCDISpec(name string, data []byte) (CDISpec, error) {
+	var spec CDISpec
+	var err error
+	switch filepath.Ext(name) {
+	case ".json":
+		err = json.Unmarshal(data, &spec)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &spec)
+	default:
+		return CDISpec{}, fmt.Errorf("%w: %s is not .json or .yaml", ErrInvalidCDISpec, name)
+	}
+	if err != nil {
+		return CDISpec{}, fmt.Errorf("%w: %s: %v", ErrInvalidCDISpec, name, err)
+	}
+	if err := spec.Validate(); err != nil {
+		return CDISpec{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return spec, nil
+}
+
+// Validate checks the version, kind and device names
+func (s CDISpec) Validate() error {
+	if s.Version == "" {
+		return fmt.Errorf("%w: missing cdiVersion", ErrInvalidCDISpec)
+	}
+	if !cdiKindPattern.MatchString(s.Kind) {
+		return fmt.Errorf("%w: kind %q is not vendor/class", ErrInvalidCDISpec, s.Kind)
+	}
+	if len(s.Devices) == 0 {
+		return fmt.Errorf("%w: no devices", ErrInvalidCDISpec)
+	}
+	for _, d := range s.Devices {
+		if !cdiDevicePattern.MatchString(d.Name) {
+			return fmt.Errorf("%w: device name %q", ErrInvalidCDISpec, d.Name)
+		}
+	}
+	return nil
+}
+
+// CDISpecs are the CDI devices available on the host, by qualified name
+// ("nvidia.com/gpu=0")
+type CDISpecs struct {
+	devices map[string]string // qualified name -> spec file
+}
+
+// LoadCDISpecs reads the spec files in dirs. Missing directories are
+// skipped, and invalid files are logged and skipped. When two files define
+// the same device, the one in the later directory wins.
+func LoadCDISpecs(dirs ...string) (*CDISpecs, error) {
+	c := &CDISpecs{devices: make(map[string]string)}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cdi spec dir: %w", err)
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read cdi spec: %w", err)
+			}
+			spec, err := ParseCDISpec(path, data)
+			if err != nil {
+				slog.Warn("skipping cdi spec", "error", err)
+				continue
+			}
+			for _, d := range spec.Devices {
+				c.devices[spec.Kind+"="+d.Name] = path
+			}
+		}
+	}
+	return c, nil
+}
+
+// Devices returns the qualified device names, sorted
+func (c *CDISpecs) Devices() []string {
+	names := make([]string, 0, len(c.devices))
+	for name := range c.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookup returns the first of the candidate device names of kind that exists
+func (c *CDISpecs) lookup(kind string, candidates ...string) (string, bool) {
+	for _, name := range candidates {
+		if _, ok := c.devices[kind+"="+name]; ok {
+			return kind + "=" + name, true
+		}
+	}
+	return "", false
+}
+
+// WithCDI sets the host's CDI devices for runtimes attaching GPUs in CDI mode
+func (s *DockerService) WithCDI(specs *CDISpecs) *DockerService {
+	s.cdi = specs
+	return s
+}
+
+// cdiDevices maps a container's GPUs (see gpuDevices) to CDI device names.
+// nvidia-ctk names devices by UUID, index ("0", "0:1" for a MIG slice) or
+// type and index ("gpu0", "mig0:1") depending on its naming strategy, so
+// each is tried.
+func (s *DockerService) cdiDevices(devices []string) ([]string, error) {
+	if len(devices) == 0 {
+		return nil, nil
+	}
+	if s.cdi == nil {
+		return nil, fmt.Errorf("%w: no cdi specs loaded", ErrCDIDeviceNotFound)
+	}
+
+	aliases := make(map[string][]string) // UUID or index -> the device's possible CDI names
+	if s.gpus != nil {
+		specs, err := s.gpus.GetSpecs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gpus: %w", err)
+		}
+		for _, spec := range specs {
+			index := strconv.Itoa(spec.Index)
+			typed := "gpu" + index
+			if spec.IsMIGSlice() {
+				index += ":" + strconv.Itoa(spec.MIGIndex)
+				typed = "mig" + index
+			}
+			names := []string{spec.UUID, index, typed}
+			aliases[spec.UUID] = names
+			aliases[index] = names
+		}
+	}
+
+	names := make([]string, 0, len(devices))
+	for _, id := range devices {
+		candidates, ok := aliases[id]
+		if !ok {
+			candidates = []string{id}
+			if _, err := strconv.Atoi(id); err == nil {
+				candidates = append(candidates, "gpu"+id)
+			}
+		}
+		name, ok := s.cdi.lookup(CDIKindNVIDIA, candidates...)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrCDIDeviceNotFound, id)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,135 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata/cdi", path))
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseCDISpec_YAML(t *testing.T) {
+	spec, err := ParseCDISpec("nvidia.yaml", readFixture(t, "etc/nvidia.yaml"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.5.0", spec.Version)
+	assert.Equal(t, CDIKindNVIDIA, spec.Kind)
+	assert.Equal(t, []CDIDevice{{Name: "0"}, {Name: "1:0"}, {Name: "1:1"}, {Name: "all"}}, spec.Devices)
+}
+
+func TestParseCDISpec_JSON(t *testing.T) {
+	spec, err := ParseCDISpec("nvidia-uuid.json", readFixture(t, "run/nvidia-uuid.json"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "0.6.0", spec.Version)
+	assert.Equal(t, []CDIDevice{{Name: "GPU-aaa"}}, spec.Devices)
+}
+
+func TestParseCDISpec_Invalid(t *testing.T) {
+	for _, path := range []string{"invalid/no-kind.yaml", "invalid/bad-name.json"} {
+		_, err := ParseCDISpec(path, readFixture(t, path))
+		assert.ErrorIs(t, err, ErrInvalidCDISpec, path)
+	}
+
+	_, err := ParseCDISpec("nvidia.yaml", []byte("cdiVersion: [unterminated"))
+	assert.ErrorIs(t, err, ErrInvalidCDISpec)
+	_, err = ParseCDISpec("nvidia.toml", []byte{})
+	assert.ErrorIs(t, err, ErrInvalidCDISpec)
+}
+
+func TestLoadCDISpecs(t *testing.T) {
+	specs, err := LoadCDISpecs("testdata/cdi/etc", "testdata/cdi/run", "testdata/cdi/missing")
+
+	require.NoError(t, err)
+	// broken.yaml and notes.txt are skipped
+	assert.Equal(t, []string{
+		"nvidia.com/gpu=0",
+		"nvidia.com/gpu=1:0",
+		"nvidia.com/gpu=1:1",
+		"nvidia.com/gpu=GPU-aaa",
+		"nvidia.com/gpu=all",
+	}, specs.Devices())
+}
+
+// cdiGPUs has a whole GPU and a second one in MIG mode with two slices
+var cdiGPUs = fakeGPUs{
+	{UUID: "GPU-aaa", Index: 0},
+	{UUID: "GPU-bbb", Index: 1, MIGMode: true},
+	{UUID: "MIG-bbb-0", Index: 1, ParentUUID: "GPU-bbb", MIGIndex: 0},
+	{UUID: "MIG-bbb-1", Index: 1, ParentUUID: "GPU-bbb", MIGIndex: 1},
+}
+
+func TestCreateContainer_CDIDevices(t *testing.T) {
+	specs, err := LoadCDISpecs("testdata/cdi/etc", "testdata/cdi/run")
+	require.NoError(t, err)
+
+	tests := map[string][]string{
+		"GPU-aaa":   {"nvidia.com/gpu=GPU-aaa"}, // Named by UUID
+		"MIG-bbb-1": {"nvidia.com/gpu=1:1"},     // Named by index
+		"0":         {"nvidia.com/gpu=GPU-aaa"},
+		"all":       {"nvidia.com/gpu=all"}, // Mining only
+		"":          nil,
+	}
+	for gpu, want := range tests {
+		mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+		svc := NewDockerServiceWithClient(mock).WithGPUs(cdiGPUs).WithCDI(specs)
+
+		_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+			SessionID:          "session-abc",
+			Image:              "img",
+			SSHPort:            30001,
+			GPUDeviceID:        gpu,
+			UseImageEntrypoint: gpu == "all",
+			Runtime:            Runtime{Name: "runc", GPUs: GPUAttachCDI},
+		})
+		require.NoError(t, err, gpu)
+
+		if want == nil {
+			assert.Empty(t, mock.LastHostConfig.DeviceRequests, gpu)
+		} else {
+			require.Len(t, mock.LastHostConfig.DeviceRequests, 1, gpu)
+			req := mock.LastHostConfig.DeviceRequests[0]
+			assert.Equal(t, "cdi", req.Driver, gpu)
+			assert.Equal(t, want, req.DeviceIDs, gpu)
+		}
+		for _, env := range mock.LastCreateConfig.Env {
+			assert.False(t, strings.HasPrefix(env, "NVIDIA_VISIBLE_DEVICES="), gpu)
+		}
+	}
+}
+
+func TestCreateContainer_CDIDeviceNotFound(t *testing.T) {
+	specs, err := LoadCDISpecs("testdata/cdi/etc")
+	require.NoError(t, err)
+	runtime := Runtime{Name: "runc", GPUs: GPUAttachCDI}
+
+	// No spec lists GPU 2, and without specs nothing can be requested
+	tests := []struct {
+		svc *DockerService
+		gpu string
+	}{
+		{NewDockerServiceWithClient(&MockDockerClient{}).WithGPUs(cdiGPUs).WithCDI(&CDISpecs{}), "0"},
+		{NewDockerServiceWithClient(&MockDockerClient{}).WithCDI(specs), "2"},
+		{NewDockerServiceWithClient(&MockDockerClient{}), "2"},
+	}
+	for _, tt := range tests {
+		_, err := tt.svc.CreateContainer(context.Background(), ContainerConfig{
+			SessionID:   "session-abc",
+			Image:       "img",
+			GPUDeviceID: tt.gpu,
+			Runtime:     runtime,
+		})
+		assert.ErrorIs(t, err, ErrCDIDeviceNotFound)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -14,6 +15,9 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
@@ -21,14 +25,56 @@ import (
 
 // ContainerConfig holds configuration for creating a GPU container
 type ContainerConfig struct {
-	SessionID   string // Used as container name
-	Image       string // e.g., "nvidia/cuda:12.1.1-runtime-ubuntu22.04"
-	GPUDeviceID string // NVIDIA UUID (not index)
-	SSHPassword string // SSH password for the user
-	SSHPort     int    // Host port to bind for SSH (container:22 -> host:SSHPort)
-	MemoryBytes int64  // Memory limit in bytes
-	CPUCount    int64  // CPU count (in NanoCPUs / 1e9)
-	UseImageEntrypoint bool // If true, use the image's default entrypoint (no SSH setup)
+	SessionID          string            // Used as container name
+	Image              string            // e.g., "nvidia/cuda:12.1.1-runtime-ubuntu22.04"
+	GPUDeviceID        string            // NVIDIA UUID (not index)
+	SSHPassword        string            // SSH password for the user
+	SSHPort            int               // Host port to bind for SSH (container:22 -> host:SSHPort)
+	MemoryBytes        int64             // Memory limit in bytes
+	CPUCount           int64             // CPU count (in NanoCPUs / 1e9)
+	CPUSet             string            // Dedicated CPUs, e.g. "8-11" (empty = any)
+	MemNodes           string            // NUMA memory nodes, e.g. "1" (empty = any)
+	UseImageEntrypoint bool              // If true, use the image's default entrypoint (no SSH setup)
+	DiskQuotaBytes     int64             // Writable layer size limit (0 = unlimited)
+	ScratchMounts      []ScratchMount    // tmpfs scratch space mounted into the container
+	ShmSizeBytes       int64             // /dev/shm size (0 = Docker's 64 MB default)
+	Ulimits            []Ulimit          // Resource limits (empty = daemon defaults)
+	IPCMode            string            // IPC namespace mode (empty = daemon default, see ParseIPCMode)
+	NetworkName        string            // Docker network to attach to (empty = default bridge)
+	ExtraPorts         []PortMapping     // Additional container ports published alongside SSH
+	AccessMode         AccessMode        // Service started for the renter (empty = ssh)
+	AccessToken        string            // Token/password for jupyter and code-server
+	Env                Env               // Renter environment variables (rental mode only)
+	InitCommand        string            // Renter startup command, run in the background as the rental user
+	WorkDir            string            // Working directory for the init command and SSH logins
+	RegistryAuth       *RegistryAuth     // Credentials for a private image (nil = anonymous or credential helper)
+	Labels             map[string]string // Extra container labels (rental mode also gets LabelSessionID)
+	WorkspaceVolume    string            // Volume mounted at WorkspacePath (rental mode only, empty = none)
+	Security           SecurityProfile   // Container hardening (rental mode only)
+	Runtime            Runtime           // OCI runtime and GPU attach mode (zero = DefaultRuntime)
+}
+
+// PortMapping publishes a container port on a host port
+type PortMapping struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort"`
+	Protocol      string `json:"protocol"` // "tcp" (default) or "udp"
+}
+
+// natPort returns the Docker port key, e.g. "8888/tcp"
+func (p PortMapping) natPort() nat.Port {
+	proto := p.Protocol
+	if proto == "" {
+		proto = "tcp"
+	}
+	return nat.Port(fmt.Sprintf("%d/%s", p.ContainerPort, proto))
+}
+
+// ScratchMount describes a tmpfs mount used as fast scratch space.
+// tmpfs pages are charged against the container's memory limit.
+type ScratchMount struct {
+	Path      string `json:"path"`      // Mount point inside the container, e.g. "/scratch"
+	SizeBytes int64  `json:"sizeBytes"` // tmpfs size limit (0 = kernel default of half the RAM)
 }
 
 // ContainerInfo contains information about a running container
@@ -37,11 +83,43 @@ type ContainerInfo struct {
 	SSHPort     int    // Dynamically allocated host port for SSH
 	State       string // "running", "exited", etc.
 	Health      string // "healthy", "unhealthy", "starting", ""
+	ExitCode    int    // Last exit code (meaningful once the container has exited)
+	OOMKilled   bool   // Set when the kernel OOM killer ended the container
 }
 
 // DockerService wraps Docker SDK for GPU container management
 type DockerService struct {
 	cli DockerClient // Interface for testability
+
+	// Cached result of storage driver quota detection (see quota.go)
+	quotaMu        sync.Mutex
+	quotaChecked   bool
+	quotaSupported bool
+
+	// Egress policy enforcement for rental networks (see network.go)
+	firewall *EgressFirewall
+
+	// Operator credential helpers for private registries (see registry.go)
+	credHelpers map[string]string
+	credHelper  CredentialHelperFunc
+	pullsMu     sync.Mutex
+	privatePull map[string]bool // Image ID -> pulled with credentials
+
+	// Admission policy for rental images (see policy.go, manifest.go)
+	imagePolicy *ImagePolicy
+	manifests   ManifestFetcher
+
+	// LRU tracking for the image cache (see cache.go)
+	imageCache *ImageCache
+
+	// Host GPUs for resolving assigned UUIDs (see gpu.go)
+	gpus GPUSpecSource
+
+	// CDI devices GPUs are requested by in CDI mode (see cdi.go)
+	cdi *CDISpecs
+
+	// Image ID data exports run (see PinExportImage)
+	exportImage string
 }
 
 // DockerClient interface for Docker operations (mockable)
@@ -51,9 +129,26 @@ type DockerClient interface {
 	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
 	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
 	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (types.ContainerJSON, []byte, error)
 	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+	ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error)
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
+	ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
 	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
 	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+	ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error)
+	ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error)
+	DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (registry.DistributionInspect, error)
+	Info(ctx context.Context) (system.Info, error)
+	NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+	VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error)
 	Close() error
 }
 
@@ -75,66 +170,72 @@ func NewDockerServiceWithClient(cli DockerClient) *DockerService {
 }
 
 // ensureImage pulls a Docker image if it's not available locally.
-func (s *DockerService) ensureImage(ctx context.Context, imageName string) error {
+// auth may be nil for public images or registries with a credential helper.
+// onProgress, if set, receives throttled pull progress. With checkAccess a
+// local copy is only used once the caller may pull it (see checkImageAccess).
+func (s *DockerService) ensureImage(ctx context.Context, imageName string, auth *RegistryAuth, onProgress PullProgressFunc, checkAccess bool) error {
 	// Try to inspect the image first — if it exists locally, no pull needed
-	_, err := s.cli.ImageInspect(ctx, imageName)
+	info, err := s.cli.ImageInspect(ctx, imageName)
 	if err == nil {
+		if checkAccess {
+			return s.checkImageAccess(ctx, imageName, info.ID, auth)
+		}
 		return nil
 	}
 
 	slog.Info("image not found locally, pulling from registry", "image", imageName)
 
-	reader, err := s.cli.ImagePull(ctx, imageName, image.PullOptions{})
+	// Credentials live only in this encoded header for the pull's duration
+	encodedAuth, err := s.registryAuth(ctx, imageName, auth)
+	if err != nil {
+		return fmt.Errorf("failed to resolve registry credentials for %s: %w", imageName, err)
+	}
+
+	reader, err := s.cli.ImagePull(ctx, imageName, image.PullOptions{RegistryAuth: encodedAuth})
 	if err != nil {
 		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
 	}
 	defer reader.Close()
 
-	// Consume the reader to complete the pull (progress output is discarded)
-	if _, err := io.Copy(io.Discard, reader); err != nil {
+	// Consume the stream to complete the pull, tracking per-layer progress
+	if err := readPullProgress(reader, imageName, pullProgressInterval, onProgress); err != nil {
 		return fmt.Errorf("error during image pull %s: %w", imageName, err)
 	}
 
+	if info, err := s.cli.ImageInspect(ctx, imageName); err == nil {
+		s.recordPull(info.ID, encodedAuth != "")
+	}
+	if s.imageCache != nil {
+		s.imageCache.changed()
+	}
 	slog.Info("image pulled successfully", "image", imageName)
 	return nil
 }
 
-// sshSetupScript is the entrypoint script that installs and starts SSH server
-// inside any base image (CUDA, PyTorch, TensorFlow, etc.)
-const sshSetupScript = `set -e
-export DEBIAN_FRONTEND=noninteractive
-apt-get update -qq
-apt-get install -y -qq openssh-server sudo > /dev/null 2>&1
-
-# Create user with password
-useradd -m -s /bin/bash "$USER_NAME" 2>/dev/null || true
-echo "$USER_NAME:$SSH_PASSWORD" | chpasswd
-echo "$USER_NAME ALL=(ALL) NOPASSWD:ALL" >> /etc/sudoers
-
-# Configure sshd
-mkdir -p /run/sshd
-sed -i 's/#PasswordAuthentication yes/PasswordAuthentication yes/' /etc/ssh/sshd_config
-sed -i 's/PasswordAuthentication no/PasswordAuthentication yes/' /etc/ssh/sshd_config
-sed -i 's/#PermitRootLogin.*/PermitRootLogin no/' /etc/ssh/sshd_config
-
-echo "SSH server ready on port 22"
-exec /usr/sbin/sshd -D
-`
-
 // CreateContainer creates a GPU container with NVIDIA runtime and SSH access
 func (s *DockerService) CreateContainer(ctx context.Context, cfg ContainerConfig) (string, error) {
 	// Auto-pull image if not available locally
-	if err := s.ensureImage(ctx, cfg.Image); err != nil {
+	// Mining images come from the operator's own config
+	if err := s.ensureImage(ctx, cfg.Image, cfg.RegistryAuth, nil, !cfg.UseImageEntrypoint); err != nil {
 		return "", fmt.Errorf("failed to ensure image: %w", err)
 	}
+	if s.imageCache != nil {
+		s.imageCache.Touch(cfg.Image)
+	}
 
-	// GPU device selection via NVIDIA_VISIBLE_DEVICES
-	// Use "all" by default; for multi-GPU hosts, use device index (e.g., "0", "1")
-	// Note: GPU UUIDs don't work with nvidia runtime auto/CDI mode
-	gpuDevice := "all"
-	if cfg.GPUDeviceID != "" && cfg.GPUDeviceID != "all" && !isGPUUUID(cfg.GPUDeviceID) {
-		gpuDevice = cfg.GPUDeviceID // device index like "0", "1"
+	// Only the assigned GPUs, via NVIDIA_VISIBLE_DEVICES or device requests.
+	// The nvidia runtime's CDI mode names devices by index, not UUID.
+	runtime := cfg.Runtime.withDefaults()
+	devices, err := s.gpuDevices(cfg.GPUDeviceID, runtime.GPUs == GPUAttachEnv, cfg.UseImageEntrypoint)
+	if err != nil {
+		return "", err
 	}
+	if runtime.GPUs == GPUAttachCDI {
+		if devices, err = s.cdiDevices(devices); err != nil {
+			return "", err
+		}
+	}
+	gpuEnv := runtime.gpuEnv(devices)
 
 	var containerConfig *container.Config
 	var portBindings nat.PortMap
@@ -143,44 +244,100 @@ func (s *DockerService) CreateContainer(ctx context.Context, cfg ContainerConfig
 		// Mining mode: use image's default entrypoint, no SSH
 		containerConfig = &container.Config{
 			Image: cfg.Image,
-			Env: []string{
-				fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", gpuDevice),
-				"NVIDIA_DRIVER_CAPABILITIES=all",
-			},
+			Env:   gpuEnv,
 		}
 	} else {
-		// Rental mode: inject SSH setup as entrypoint
+		// Rental mode: inject SSH (plus the access mode's service) as entrypoint
+		env := append([]string{
+			fmt.Sprintf("SSH_PASSWORD=%s", cfg.SSHPassword),
+			"USER_NAME=ubuntu",
+		}, gpuEnv...)
+		if !cfg.Security.AllowsSudo() {
+			env = append(env, "RENTAL_SUDO=0")
+		}
+		if cfg.AccessToken != "" {
+			env = append(env, fmt.Sprintf("ACCESS_TOKEN=%s", cfg.AccessToken))
+		}
+		if cfg.InitCommand != "" {
+			env = append(env, fmt.Sprintf("INIT_COMMAND=%s", cfg.InitCommand))
+		}
+		if cfg.WorkDir != "" {
+			env = append(env, fmt.Sprintf("WORK_DIR=%s", cfg.WorkDir))
+		}
+		if len(cfg.Env) > 0 {
+			// Setup script re-exports these for SSH login shells
+			env = append(env, fmt.Sprintf("RENTAL_ENV_KEYS=%s", strings.Join(cfg.Env.Keys(), " ")))
+			env = append(env, cfg.Env.list()...)
+		}
+		labels := map[string]string{LabelSessionID: cfg.SessionID}
+		for k, v := range cfg.Labels {
+			labels[k] = v
+		}
 		containerConfig = &container.Config{
-			Image: cfg.Image,
-			Env: []string{
-				fmt.Sprintf("SSH_PASSWORD=%s", cfg.SSHPassword),
-				"USER_NAME=ubuntu",
-				fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", gpuDevice),
-				"NVIDIA_DRIVER_CAPABILITIES=all",
-			},
+			Image:        cfg.Image,
+			Labels:       labels,
+			Env:          env,
+			WorkingDir:   cfg.WorkDir,
 			ExposedPorts: nat.PortSet{"22/tcp": struct{}{}},
 			Entrypoint:   []string{"/bin/bash", "-c"},
-			Cmd:          []string{sshSetupScript},
+			Cmd:          []string{cfg.AccessMode.setupScript()},
 		}
 		portBindings = nat.PortMap{
 			"22/tcp": []nat.PortBinding{
 				{HostIP: "0.0.0.0", HostPort: strconv.Itoa(cfg.SSHPort)},
 			},
 		}
+		for _, p := range cfg.ExtraPorts {
+			containerConfig.ExposedPorts[p.natPort()] = struct{}{}
+			portBindings[p.natPort()] = []nat.PortBinding{
+				{HostIP: "0.0.0.0", HostPort: strconv.Itoa(p.HostPort)},
+			}
+		}
 	}
 
-	// Host configuration with nvidia runtime
+	// Host configuration with the selected runtime
 	hostConfig := &container.HostConfig{
-		Runtime: "nvidia",
+		Runtime: runtime.Name,
 		Resources: container.Resources{
-			Memory:   cfg.MemoryBytes,
-			NanoCPUs: cfg.CPUCount * 1e9, // Convert to NanoCPUs
+			DeviceRequests: runtime.deviceRequests(devices),
+			Memory:         cfg.MemoryBytes,
+			NanoCPUs:       cfg.CPUCount * 1e9, // Convert to NanoCPUs
+			CpusetCpus:     cfg.CPUSet,
+			CpusetMems:     cfg.MemNodes,
+			Ulimits:        dockerUlimits(cfg.Ulimits),
 		},
 		PortBindings: portBindings,
+		Tmpfs:        scratchTmpfs(cfg.ScratchMounts),
+		ShmSize:      cfg.ShmSizeBytes,
+		IpcMode:      container.IpcMode(cfg.IPCMode),
+	}
+	if !cfg.UseImageEntrypoint {
+		if err := s.checkSecurity(ctx, cfg.Security); err != nil {
+			return "", err
+		}
+		hostConfig.Mounts = workspaceMounts(cfg.WorkspaceVolume, false)
+		cfg.Security.apply(hostConfig)
+	}
+	if cfg.NetworkName != "" {
+		hostConfig.NetworkMode = container.NetworkMode(cfg.NetworkName)
+	}
+
+	// Writable layer quota via storage-opt when the storage driver supports it.
+	// Otherwise the rental executor's disk quota monitor enforces the limit.
+	if cfg.DiskQuotaBytes > 0 && s.SupportsDiskQuota(ctx) {
+		hostConfig.StorageOpt = map[string]string{"size": strconv.FormatInt(cfg.DiskQuotaBytes, 10)}
 	}
 
 	// Create container
+	slog.Info("creating container", "config", cfg)
 	resp, err := s.cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, cfg.SessionID)
+	if err != nil && hostConfig.StorageOpt != nil && isStorageOptError(err) {
+		// e.g. overlay2 on xfs without pquota: fall back to monitor-based enforcement
+		slog.Warn("storage driver rejected disk quota, falling back to monitor", "session", cfg.SessionID, "error", err)
+		s.disableDiskQuota()
+		hostConfig.StorageOpt = nil
+		resp, err = s.cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, cfg.SessionID)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to create container: %w", err)
 	}
@@ -213,6 +370,12 @@ func (s *DockerService) StartContainer(ctx context.Context, containerID string)
 		return fmt.Errorf("failed to start container after retries: %w", err)
 	}
 
+	// Restarts and rebuilds count as use of the image too
+	if s.imageCache != nil {
+		if info, err := s.cli.ContainerInspect(ctx, containerID); err == nil && info.ContainerJSONBase != nil {
+			s.imageCache.Touch(info.Image)
+		}
+	}
 	return nil
 }
 
@@ -278,22 +441,18 @@ func (s *DockerService) InspectContainer(ctx context.Context, containerID string
 	}
 
 	// Get state
-	state := ""
-	if inspect.State != nil {
-		state = inspect.State.Status
-	}
-
-	return &ContainerInfo{
+	info := &ContainerInfo{
 		ContainerID: inspect.ID,
 		SSHPort:     sshPort,
-		State:       state,
 		Health:      health,
-	}, nil
-}
+	}
+	if inspect.State != nil {
+		info.State = inspect.State.Status
+		info.ExitCode = inspect.State.ExitCode
+		info.OOMKilled = inspect.State.OOMKilled
+	}
 
-// isGPUUUID returns true if the string looks like a GPU UUID (e.g., "GPU-751b4c38-...")
-func isGPUUUID(s string) bool {
-	return strings.HasPrefix(s, "GPU-") || strings.HasPrefix(s, "MIG-")
+	return info, nil
 }
 
 // Close closes the Docker client connection
@@ -1,9 +1,12 @@
 package container
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"strings"
 	"testing"
 
@@ -11,8 +14,12 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
+	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,6 +40,54 @@ type MockDockerClient struct {
 	CreateResponse container.CreateResponse
 	CreateError    error
 
+	StorageOptError error // Returned by ContainerCreate when StorageOpt is set
+
+	InfoResponse system.Info
+	InfoCalled   int
+
+	SizeRw *int64
+
+	ImageMissing    bool // ImageInspect reports the image as not found locally until pulled
+	PullCalls       []string
+	LastPullOptions image.PullOptions
+	ImageSize       int64
+	ImageID         string // Returned by ImageInspect
+	PullStream      string // JSON progress stream returned by ImagePull (default "{}")
+	PullError       error
+	ImageRemoves    []string
+
+	Images     []image.Summary     // Returned by ImageList
+	Containers []container.Summary // Returned by ContainerList
+	ImageLists int
+
+	Logs            []byte // Multiplexed stream returned by ContainerLogs
+	LastLogsOptions container.LogsOptions
+
+	ExecCmds     [][]string // Commands passed to ContainerExecCreate
+	ExecOutput   []byte     // Multiplexed stream returned by ContainerExecAttach
+	ExecExitCode int
+	ExecHang     bool // Keep the exec stream open until it is closed
+
+	Stats container.StatsResponse // Returned by ContainerStatsOneShot
+
+	Renames []string // "id -> name" per ContainerRename
+
+	VolumeCreates     []volume.CreateOptions
+	VolumeRemoves     []string
+	VolumeRemoveError error
+	Volumes           []*volume.Volume // Returned by DiskUsage
+
+	DistributionDigest string // Digest returned by DistributionInspect
+	DistributionError  error
+	DistributionCalls  []string
+	DistributionAuth   []string // Encoded registry auth of each DistributionInspect
+	PrivateRegistry    bool     // DistributionInspect fails without registry auth
+
+	NetworkCreateError error
+	NetworkCreates     []string
+	NetworkRemoves     []string
+	LastNetworkOptions network.CreateOptions
+
 	StartErrors []error // For testing retry logic
 	startCallIdx int
 
@@ -57,6 +112,9 @@ func (m *MockDockerClient) ContainerCreate(ctx context.Context, config *containe
 	m.LastCreateConfig = config
 	m.LastHostConfig = hostConfig
 	m.LastContainerName = containerName
+	if m.StorageOptError != nil && hostConfig.StorageOpt != nil {
+		return container.CreateResponse{}, m.StorageOptError
+	}
 	return m.CreateResponse, m.CreateError
 }
 
@@ -89,6 +147,17 @@ func (m *MockDockerClient) ContainerInspect(ctx context.Context, containerID str
 	return m.InspectResponse, m.InspectError
 }
 
+func (m *MockDockerClient) ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (types.ContainerJSON, []byte, error) {
+	m.InspectCalled++
+	resp := m.InspectResponse
+	if getSize && resp.ContainerJSONBase != nil {
+		base := *resp.ContainerJSONBase
+		base.SizeRw = m.SizeRw
+		resp.ContainerJSONBase = &base
+	}
+	return resp, nil, m.InspectError
+}
+
 func (m *MockDockerClient) ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
 	m.WaitCalled++
 	waitCh := make(chan container.WaitResponse, 1)
@@ -104,12 +173,126 @@ func (m *MockDockerClient) ContainerWait(ctx context.Context, containerID string
 }
 
 func (m *MockDockerClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	if m.PullError != nil {
+		return nil, m.PullError
+	}
+	m.PullCalls = append(m.PullCalls, refStr)
+	m.LastPullOptions = options
+	if m.PullStream != "" {
+		return io.NopCloser(strings.NewReader(m.PullStream)), nil
+	}
 	return io.NopCloser(strings.NewReader("{}")), nil
 }
 
 func (m *MockDockerClient) ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error) {
 	// Return success (image found locally) by default
-	return image.InspectResponse{}, nil
+	if m.ImageMissing {
+		pulled := false
+		for _, ref := range m.PullCalls {
+			pulled = pulled || ref == imageID
+		}
+		if !pulled {
+			return image.InspectResponse{}, errors.New("No such image: " + imageID)
+		}
+	}
+	return image.InspectResponse{ID: m.ImageID, Size: m.ImageSize}, nil
+}
+
+func (m *MockDockerClient) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
+	m.ImageLists++
+	return m.Images, nil
+}
+
+func (m *MockDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	return m.Containers, nil
+}
+
+func (m *MockDockerClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	m.LastLogsOptions = options
+	return io.NopCloser(bytes.NewReader(m.Logs)), nil
+}
+
+func (m *MockDockerClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error) {
+	m.ExecCmds = append(m.ExecCmds, options.Cmd)
+	return container.ExecCreateResponse{ID: "exec-123"}, nil
+}
+
+func (m *MockDockerClient) ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error) {
+	client, server := net.Pipe()
+	go func() {
+		server.Write(m.ExecOutput)
+		if !m.ExecHang {
+			server.Close()
+		}
+	}()
+	return types.NewHijackedResponse(client, ""), nil
+}
+
+func (m *MockDockerClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	return container.ExecInspect{ExecID: execID, ExitCode: m.ExecExitCode}, nil
+}
+
+func (m *MockDockerClient) ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error) {
+	data, err := json.Marshal(m.Stats)
+	if err != nil {
+		return container.StatsResponseReader{}, err
+	}
+	return container.StatsResponseReader{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (m *MockDockerClient) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	m.Renames = append(m.Renames, containerID+" -> "+newContainerName)
+	return nil
+}
+
+func (m *MockDockerClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	m.ImageRemoves = append(m.ImageRemoves, imageID)
+	return nil, nil
+}
+
+func (m *MockDockerClient) DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (registry.DistributionInspect, error) {
+	m.DistributionCalls = append(m.DistributionCalls, imageRef)
+	m.DistributionAuth = append(m.DistributionAuth, encodedRegistryAuth)
+	if m.DistributionError != nil {
+		return registry.DistributionInspect{}, m.DistributionError
+	}
+	if m.PrivateRegistry && encodedRegistryAuth == "" {
+		return registry.DistributionInspect{}, errors.New("unauthorized: authentication required")
+	}
+	return registry.DistributionInspect{Descriptor: specs.Descriptor{Digest: digest.Digest(m.DistributionDigest)}}, nil
+}
+
+func (m *MockDockerClient) Info(ctx context.Context) (system.Info, error) {
+	m.InfoCalled++
+	return m.InfoResponse, nil
+}
+
+func (m *MockDockerClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	m.NetworkCreates = append(m.NetworkCreates, name)
+	m.LastNetworkOptions = options
+	if m.NetworkCreateError != nil {
+		return network.CreateResponse{}, m.NetworkCreateError
+	}
+	return network.CreateResponse{ID: "net-" + name}, nil
+}
+
+func (m *MockDockerClient) NetworkRemove(ctx context.Context, networkID string) error {
+	m.NetworkRemoves = append(m.NetworkRemoves, networkID)
+	return nil
+}
+
+func (m *MockDockerClient) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	m.VolumeCreates = append(m.VolumeCreates, options)
+	return volume.Volume{Name: options.Name}, nil
+}
+
+func (m *MockDockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	m.VolumeRemoves = append(m.VolumeRemoves, volumeID)
+	return m.VolumeRemoveError
+}
+
+func (m *MockDockerClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	return types.DiskUsage{Volumes: m.Volumes}, nil
 }
 
 func (m *MockDockerClient) Close() error {
@@ -312,3 +495,162 @@ func TestInspectContainer_ReturnsHealthStatus(t *testing.T) {
 	assert.Equal(t, "healthy", info.Health)
 	assert.Equal(t, "running", info.State)
 }
+
+func TestInspectContainer_ReturnsExitCodeAndOOMKilled(t *testing.T) {
+	mock := &MockDockerClient{
+		InspectResponse: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID: "container-123",
+				State: &types.ContainerState{
+					Status:    "exited",
+					ExitCode:  137,
+					OOMKilled: true,
+				},
+			},
+		},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	info, err := svc.InspectContainer(context.Background(), "container-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, "exited", info.State)
+	assert.Equal(t, 137, info.ExitCode)
+	assert.True(t, info.OOMKilled)
+}
+
+func TestCreateContainer_DiskQuotaUsesStorageOpt(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "container-123"},
+		InfoResponse:   system.Info{Driver: "overlay2", DriverStatus: [][2]string{{"Backing Filesystem", "xfs"}}},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	cfg := ContainerConfig{
+		SessionID:      "session-abc",
+		Image:          "nvidia/cuda:12.1-runtime-ubuntu22.04",
+		DiskQuotaBytes: 50 * 1024 * 1024 * 1024,
+		ScratchMounts:  []ScratchMount{{Path: "/scratch", SizeBytes: 1024 * 1024 * 1024}},
+	}
+
+	_, err := svc.CreateContainer(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "53687091200", mock.LastHostConfig.StorageOpt["size"])
+	assert.Equal(t, "rw,nosuid,nodev,size=1073741824", mock.LastHostConfig.Tmpfs["/scratch"])
+}
+
+func TestCreateContainer_DiskQuotaFallsBackWhenDriverRejects(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse:  container.CreateResponse{ID: "container-123"},
+		InfoResponse:    system.Info{Driver: "overlay2", DriverStatus: [][2]string{{"Backing Filesystem", "xfs"}}},
+		StorageOptError: errors.New("--storage-opt is supported only for overlay over xfs with 'pquota' mount option"),
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	cfg := ContainerConfig{SessionID: "session-abc", Image: "img", DiskQuotaBytes: 1024}
+
+	containerID, err := svc.CreateContainer(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "container-123", containerID)
+	assert.Equal(t, 2, mock.CreateCalled)
+	assert.Nil(t, mock.LastHostConfig.StorageOpt)
+	assert.False(t, svc.SupportsDiskQuota(context.Background()))
+	assert.Equal(t, 1, mock.InfoCalled)
+}
+
+func TestCreateContainer_DiskQuotaSkippedOnUnsupportedDriver(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "container-123"},
+		InfoResponse:   system.Info{Driver: "overlay2", DriverStatus: [][2]string{{"Backing Filesystem", "extfs"}}},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "s", Image: "img", DiskQuotaBytes: 1024})
+
+	require.NoError(t, err)
+	assert.Nil(t, mock.LastHostConfig.StorageOpt)
+	assert.Equal(t, 1, mock.CreateCalled)
+}
+
+func TestDiskUsage_ReturnsWritableLayerSize(t *testing.T) {
+	size := int64(4096)
+	mock := &MockDockerClient{
+		InspectResponse: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "container-123"},
+		},
+		SizeRw: &size,
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	usage, err := svc.DiskUsage(context.Background(), "container-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4096), usage)
+}
+
+func TestCreateContainer_PinsCPUSet(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID: "session-abc",
+		Image:     "img",
+		SSHPort:   30001,
+		CPUCount:  4,
+		CPUSet:    "8-11",
+		MemNodes:  "1",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "8-11", mock.LastHostConfig.CpusetCpus)
+	assert.Equal(t, "1", mock.LastHostConfig.CpusetMems)
+	assert.Equal(t, int64(4e9), mock.LastHostConfig.NanoCPUs)
+}
+
+func TestCreateContainer_SetsShmUlimitsAndIPC(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:    "session-abc",
+		Image:        "img",
+		SSHPort:      30001,
+		ShmSizeBytes: 8 << 30,
+		Ulimits:      []Ulimit{{Name: "memlock", Soft: -1, Hard: -1}, {Name: "nofile", Soft: 65536, Hard: 65536}},
+		IPCMode:      IPCModePrivate,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(8<<30), mock.LastHostConfig.ShmSize)
+	assert.Equal(t, container.IpcMode("private"), mock.LastHostConfig.IpcMode)
+	assert.Equal(t, []*container.Ulimit{
+		{Name: "memlock", Soft: -1, Hard: -1},
+		{Name: "nofile", Soft: 65536, Hard: 65536},
+	}, mock.LastHostConfig.Ulimits)
+}
+
+func TestCreateContainer_PublishesExtraPorts(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	cfg := ContainerConfig{
+		SessionID: "session-abc",
+		Image:     "img",
+		SSHPort:   30001,
+		ExtraPorts: []PortMapping{
+			{ContainerPort: 8888, HostPort: 30002},
+			{ContainerPort: 6006, HostPort: 30003, Protocol: "tcp"},
+		},
+	}
+
+	_, err := svc.CreateContainer(context.Background(), cfg)
+
+	require.NoError(t, err)
+	assert.Contains(t, mock.LastCreateConfig.ExposedPorts, nat.Port("8888/tcp"))
+	assert.Contains(t, mock.LastCreateConfig.ExposedPorts, nat.Port("6006/tcp"))
+	assert.Equal(t, "30002", mock.LastHostConfig.PortBindings["8888/tcp"][0].HostPort)
+	assert.Equal(t, "30003", mock.LastHostConfig.PortBindings["6006/tcp"][0].HostPort)
+	assert.Equal(t, "30001", mock.LastHostConfig.PortBindings["22/tcp"][0].HostPort)
+}
@@ -0,0 +1,104 @@
+package container
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// redacted replaces secret values in logs
+const redacted = "[REDACTED]"
+
+// reservedEnv are variables the node sets itself. Renters can never override them.
+var reservedEnv = map[string]bool{
+	"SSH_PASSWORD":               true,
+	"USER_NAME":                  true,
+	"ACCESS_TOKEN":               true,
+	"INIT_COMMAND":               true,
+	"WORK_DIR":                   true,
+	"RENTAL_ENV_KEYS":            true,
+	"RENTAL_SUDO":                true,
+	"NVIDIA_VISIBLE_DEVICES":     true,
+	"NVIDIA_DRIVER_CAPABILITIES": true,
+}
+
+// IsReservedEnv reports whether key is set by the node and can't be overridden
+func IsReservedEnv(key string) bool {
+	return reservedEnv[key]
+}
+
+// Env holds renter-supplied environment variables. Values may contain secrets
+// (API keys, tokens) so they are redacted whenever Env is printed or logged.
+type Env map[string]string
+
+// Keys returns the variable names in sorted order
+func (e Env) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// list returns KEY=VALUE entries for the Docker API, sorted by key
+func (e Env) list() []string {
+	out := make([]string, 0, len(e))
+	for _, k := range e.Keys() {
+		out = append(out, fmt.Sprintf("%s=%s", k, e[k]))
+	}
+	return out
+}
+
+// String implements fmt.Stringer with values redacted
+func (e Env) String() string {
+	parts := make([]string, 0, len(e))
+	for _, k := range e.Keys() {
+		parts = append(parts, k+"="+redacted)
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// LogValue implements slog.LogValuer with values redacted
+func (e Env) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(e))
+	for _, k := range e.Keys() {
+		attrs = append(attrs, slog.String(k, redacted))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer so configs can be logged without leaking
+// the SSH password, access token, renter env values or init command
+func (c ContainerConfig) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("session", c.SessionID),
+		slog.String("image", c.Image),
+		slog.String("gpu", c.GPUDeviceID),
+		slog.Int("ssh_port", c.SSHPort),
+		slog.Int64("memory_bytes", c.MemoryBytes),
+		slog.Int64("cpu_count", c.CPUCount),
+		slog.String("access_mode", string(c.AccessMode)),
+		slog.Any("env", c.Env),
+	}
+	if c.SSHPassword != "" {
+		attrs = append(attrs, slog.String("ssh_password", redacted))
+	}
+	if c.AccessToken != "" {
+		attrs = append(attrs, slog.String("access_token", redacted))
+	}
+	if c.InitCommand != "" {
+		attrs = append(attrs, slog.String("init_command", redacted))
+	}
+	if c.WorkDir != "" {
+		attrs = append(attrs, slog.String("workdir", c.WorkDir))
+	}
+	if c.Runtime.Name != "" {
+		attrs = append(attrs, slog.String("runtime", c.Runtime.String()))
+	}
+	if c.Security.Name != "" {
+		attrs = append(attrs, slog.String("security_profile", c.Security.Name))
+	}
+	return slog.GroupValue(attrs...)
+}
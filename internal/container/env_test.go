@@ -0,0 +1,63 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv_RedactsValues(t *testing.T) {
+	env := Env{"HF_TOKEN": "hf_secret", "HF_HOME": "/data/hf"}
+
+	assert.Equal(t, "{HF_HOME=[REDACTED] HF_TOKEN=[REDACTED]}", env.String())
+	assert.NotContains(t, fmt.Sprintf("%v", env), "hf_secret")
+}
+
+func TestContainerConfig_LogValueRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	logger.Info("creating container", "config", ContainerConfig{
+		SessionID:   "session-abc",
+		SSHPassword: "hunter2",
+		AccessToken: "tok123",
+		Env:         Env{"OPENAI_API_KEY": "sk-secret"},
+		InitCommand: "curl -H 'Authorization: Bearer sk-secret' example.com",
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, "session-abc")
+	assert.Contains(t, out, "OPENAI_API_KEY")
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "tok123")
+	assert.NotContains(t, out, "sk-secret")
+}
+
+func TestCreateContainer_PassesEnvInitCommandAndWorkDir(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:   "session-abc",
+		Image:       "img",
+		SSHPort:     30001,
+		Env:         Env{"HF_HOME": "/data/hf", "API_KEY": "secret"},
+		InitCommand: "python serve.py",
+		WorkDir:     "/workspace",
+	})
+
+	require.NoError(t, err)
+	cfg := mock.LastCreateConfig
+	assert.Contains(t, cfg.Env, "HF_HOME=/data/hf")
+	assert.Contains(t, cfg.Env, "API_KEY=secret")
+	assert.Contains(t, cfg.Env, "RENTAL_ENV_KEYS=API_KEY HF_HOME")
+	assert.Contains(t, cfg.Env, "INIT_COMMAND=python serve.py")
+	assert.Contains(t, cfg.Env, "WORK_DIR=/workspace")
+	assert.Equal(t, "/workspace", cfg.WorkingDir)
+}
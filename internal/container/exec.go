@@ -0,0 +1,81 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// ExecResult is the outcome of a command run inside a container
+type ExecResult struct {
+	ExitCode  int    `json:"exitCode"` // -1 when the command did not finish
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	Truncated bool   `json:"truncated"` // Output exceeded the size limit
+	TimedOut  bool   `json:"timedOut"`  // ctx ended before the command finished
+}
+
+// Exec runs cmd in a running container without a TTY and captures up to
+// maxBytes of stdout and of stderr. When ctx ends first the output read so
+// far is returned with TimedOut set; Docker can't kill an exec, so the
+// process keeps running until it exits on its own.
+func (s *DockerService) Exec(ctx context.Context, containerID string, cmd []string, maxBytes int64) (*ExecResult, error) {
+	created, err := s.cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	resp, err := s.cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach exec: %w", err)
+	}
+	defer resp.Close()
+
+	if maxBytes <= 0 {
+		maxBytes = DefaultLogMaxBytes
+	}
+	var stdout, stderr bytes.Buffer
+	outW := &limitWriter{w: &stdout, remaining: maxBytes}
+	errW := &limitWriter{w: &stderr, remaining: maxBytes}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(outW, errW, resp.Reader)
+		done <- err
+	}()
+
+	result := &ExecResult{ExitCode: -1}
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrLogLimitReached) {
+			result.Truncated = true
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read exec output: %w", err)
+		}
+	case <-ctx.Done():
+		result.TimedOut = true
+		resp.Close()
+		<-done
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	if !result.TimedOut && !result.Truncated {
+		inspect, err := s.cli.ContainerExecInspect(context.Background(), created.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect exec: %w", err)
+		}
+		if !inspect.Running {
+			result.ExitCode = inspect.ExitCode
+		}
+	}
+	return result, nil
+}
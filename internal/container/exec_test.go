@@ -0,0 +1,54 @@
+package container
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec_CapturesOutputAndExitCode(t *testing.T) {
+	mock := &MockDockerClient{
+		ExecOutput:   muxLogs(t, "GPU 0: NVIDIA A100\n", "warning: persistence mode off\n"),
+		ExecExitCode: 3,
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	result, err := svc.Exec(context.Background(), "container-123", []string{"nvidia-smi", "-L"}, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"nvidia-smi", "-L"}}, mock.ExecCmds)
+	assert.Equal(t, "GPU 0: NVIDIA A100\n", result.Stdout)
+	assert.Equal(t, "warning: persistence mode off\n", result.Stderr)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.False(t, result.Truncated)
+	assert.False(t, result.TimedOut)
+}
+
+func TestExec_TruncatesOutput(t *testing.T) {
+	mock := &MockDockerClient{ExecOutput: muxLogs(t, "0123456789", "")}
+	svc := NewDockerServiceWithClient(mock)
+
+	result, err := svc.Exec(context.Background(), "container-123", []string{"ps", "aux"}, 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0123", result.Stdout)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, -1, result.ExitCode)
+}
+
+func TestExec_TimesOut(t *testing.T) {
+	mock := &MockDockerClient{ExecOutput: muxLogs(t, "partial\n", ""), ExecHang: true}
+	svc := NewDockerServiceWithClient(mock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := svc.Exec(ctx, "container-123", []string{"sleep", "60"}, 0)
+
+	require.NoError(t, err)
+	assert.True(t, result.TimedOut)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Equal(t, "partial\n", result.Stdout)
+}
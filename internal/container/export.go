@@ -0,0 +1,179 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+)
+
+// LabelExportOf marks a data export container with the session it serves
+const LabelExportOf = "worldland.export_of"
+
+// DefaultExportImage serves exports. It only needs a POSIX shell, busybox
+// user tools and OpenSSH; the node pins it by image ID at startup (see
+// PinExportImage), so operators who pin it by digest get exactly that image.
+const DefaultExportImage = "atmoz/sftp:alpine"
+
+// exportPidsLimit is plenty for sshd and a few SFTP sessions
+const exportPidsLimit = 256
+
+var ErrExportImageNotPinned = errors.New("data export image not pinned")
+
+// exportScript serves the rental's workspace read-only over SFTP as a user
+// owning the same files, with the rental's password. Only /etc (a volume
+// removed with the container), /run and /tmp are writable.
+const exportScript = `set -e
+uid=$(stat -c %u /home/ubuntu)
+gid=$(stat -c %g /home/ubuntu)
+group=$(awk -F: -v g="$gid" '$3 == g { print $1 }' /etc/group)
+if [ -z "$group" ]; then addgroup -g "$gid" ubuntu; group=ubuntu; fi
+adduser -D -H -h /home/ubuntu -s /sbin/nologin -u "$uid" -G "$group" ubuntu
+echo "ubuntu:$SSH_PASSWORD" | chpasswd
+ssh-keygen -A
+mkdir -p /run/sshd
+exec /usr/sbin/sshd -D -e \
+  -o "ForceCommand=internal-sftp -R" \
+  -o PasswordAuthentication=yes \
+  -o AllowTcpForwarding=no \
+  -o AllowAgentForwarding=no \
+  -o X11Forwarding=no \
+  -o PermitTunnel=no \
+  -o PermitTTY=no`
+
+// ExportConfig describes a data export for a stopped rental container
+type ExportConfig struct {
+	SessionID   string
+	ContainerID string // Stopped rental container, read for its SSH password
+	SSHPort     int    // Host port to serve SFTP on (the rental's SSH port)
+	NetworkName string // Rental network (empty = default bridge)
+
+	WorkspaceVolume string // Rental workspace, the only data exported (mounted read-only)
+
+	Security SecurityProfile // Rentals' profile; the export is never less strict than the strict preset
+}
+
+// exportContainerName returns the export container name for a session
+func exportContainerName(sessionID string) string {
+	return sessionID + "-export"
+}
+
+// PinExportImage pulls the export image if needed and pins exports to its
+// current image ID, so a retagged image isn't picked up until the node restarts
+func (s *DockerService) PinExportImage(ctx context.Context, ref string) error {
+	if err := s.ensureImage(ctx, ref, nil, nil, false); err != nil {
+		return err
+	}
+	inspect, err := s.cli.ImageInspect(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to inspect export image: %w", err)
+	}
+	if inspect.ID == "" {
+		return fmt.Errorf("%w: %s has no image id", ErrExportImageNotPinned, ref)
+	}
+	s.exportImage = inspect.ID
+	slog.Info("data export image pinned", "image", ref, "id", inspect.ID)
+	return nil
+}
+
+// exportProfile hardens the export container: the rentals' profile with at
+// least the strict preset's capabilities, no-new-privileges and pids limit
+func exportProfile(rental SecurityProfile) SecurityProfile {
+	p, _ := SecurityPreset(SecurityProfileStrict)
+	p.Name = rental.Name
+	p.Seccomp = rental.Seccomp
+	p.RequireUserNS = rental.RequireUserNS
+	p.PidsLimit = exportPidsLimit
+	if rental.PidsLimit > 0 && rental.PidsLimit < p.PidsLimit {
+		p.PidsLimit = rental.PidsLimit
+	}
+	p.ReadOnlyRootfs = true
+	p.WritablePaths = []string{"/etc"} // For the export user; seeded from the image
+	return p
+}
+
+// StartExport serves a stopped rental's workspace read-only over SFTP on the
+// rental's SSH port, so the renter can copy files off with the same password
+// until the export is removed. The export runs the pinned export image, never
+// the rental's. Returns the export container ID.
+func (s *DockerService) StartExport(ctx context.Context, cfg ExportConfig) (string, error) {
+	if s.exportImage == "" {
+		return "", ErrExportImageNotPinned
+	}
+	profile := exportProfile(cfg.Security)
+	if err := s.checkSecurity(ctx, profile); err != nil {
+		return "", err
+	}
+
+	rental, err := s.cli.ContainerInspect(ctx, cfg.ContainerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect rental container: %w", err)
+	}
+	var password string
+	if rental.Config != nil {
+		for _, env := range rental.Config.Env {
+			if v, ok := strings.CutPrefix(env, "SSH_PASSWORD="); ok {
+				password = v
+			}
+		}
+	}
+	if password == "" {
+		return "", fmt.Errorf("rental container %s has no ssh password", cfg.ContainerID)
+	}
+
+	containerConfig := &container.Config{
+		Image: s.exportImage,
+		Labels: map[string]string{
+			LabelSessionID: "", // Keeps the export out of ListRentalContainers' rentals
+			LabelExportOf:  cfg.SessionID,
+		},
+		Env:          []string{"SSH_PASSWORD=" + password},
+		ExposedPorts: nat.PortSet{"22/tcp": struct{}{}},
+		Entrypoint:   []string{"/bin/sh", "-c"},
+		Cmd:          []string{exportScript},
+	}
+	hostConfig := &container.HostConfig{
+		Mounts: workspaceMounts(cfg.WorkspaceVolume, true),
+		Resources: container.Resources{
+			Memory:   256 * 1024 * 1024,
+			NanoCPUs: 5e8,
+		},
+		PortBindings: nat.PortMap{
+			"22/tcp": []nat.PortBinding{
+				{HostIP: "0.0.0.0", HostPort: strconv.Itoa(cfg.SSHPort)},
+			},
+		},
+	}
+	profile.apply(hostConfig)
+	if cfg.NetworkName != "" {
+		hostConfig.NetworkMode = container.NetworkMode(cfg.NetworkName)
+	}
+
+	resp, err := s.cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, exportContainerName(cfg.SessionID))
+	if err != nil {
+		return "", fmt.Errorf("failed to create export container: %w", err)
+	}
+	if err := s.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		_ = s.cli.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+		return "", fmt.Errorf("failed to start export container: %w", err)
+	}
+
+	slog.Info("rental data export started", "session", cfg.SessionID, "port", cfg.SSHPort)
+	return resp.ID, nil
+}
+
+// RemoveExport removes a session's export container with its /etc volume.
+// A missing container is not an error.
+func (s *DockerService) RemoveExport(ctx context.Context, sessionID string) error {
+	err := s.cli.ContainerRemove(ctx, exportContainerName(sessionID), container.RemoveOptions{Force: true, RemoveVolumes: true})
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove export container: %w", err)
+	}
+	return nil
+}
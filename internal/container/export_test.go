@@ -0,0 +1,130 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportService returns a service with a pinned export image, exporting a
+// stopped rental whose password is "secret"
+func exportService(t *testing.T, mock *MockDockerClient) *DockerService {
+	t.Helper()
+	mock.ImageID = "sha256:export"
+	mock.InspectResponse = types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "container-123"},
+		Config:            &container.Config{Env: []string{"USER_NAME=ubuntu", "SSH_PASSWORD=secret"}},
+	}
+	svc := NewDockerServiceWithClient(mock)
+	require.NoError(t, svc.PinExportImage(context.Background(), DefaultExportImage))
+	return svc
+}
+
+func TestStartExport_ServesWorkspaceFromPinnedImage(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "export-123"}}
+	svc := exportService(t, mock)
+
+	id, err := svc.StartExport(context.Background(), ExportConfig{
+		SessionID:       "session-123",
+		ContainerID:     "container-123",
+		SSHPort:         30001,
+		NetworkName:     "wl-rental-session-123",
+		WorkspaceVolume: "wl-rental-session-123-workspace",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "export-123", id)
+	assert.Equal(t, "session-123-export", mock.LastContainerName)
+
+	cfg := mock.LastCreateConfig
+	assert.Equal(t, "sha256:export", cfg.Image, "never the rental's image or a snapshot of it")
+	assert.Equal(t, "", cfg.Labels[LabelSessionID])
+	assert.Equal(t, "session-123", cfg.Labels[LabelExportOf])
+	assert.Equal(t, []string{"SSH_PASSWORD=secret"}, cfg.Env)
+	assert.Contains(t, cfg.Cmd[0], "ForceCommand=internal-sftp -R")
+
+	host := mock.LastHostConfig
+	assert.True(t, host.ReadonlyRootfs)
+	assert.Empty(t, host.Runtime, "export doesn't need a GPU")
+	assert.Equal(t, "30001", host.PortBindings["22/tcp"][0].HostPort)
+	assert.Equal(t, container.NetworkMode("wl-rental-session-123"), host.NetworkMode)
+	assert.Equal(t, 1, mock.StartCalled)
+}
+
+func TestStartExport_Hardened(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "export-123"}}
+	svc := exportService(t, mock)
+
+	// The default rental profile keeps Docker's defaults; the export doesn't
+	_, err := svc.StartExport(context.Background(), ExportConfig{
+		SessionID:       "session-123",
+		ContainerID:     "container-123",
+		SSHPort:         30001,
+		WorkspaceVolume: "wl-rental-session-123-workspace",
+		Security:        SecurityProfile{Name: SecurityProfileDefault, Seccomp: `{"defaultAction":"SCMP_ACT_ALLOW"}`},
+	})
+
+	require.NoError(t, err)
+	host := mock.LastHostConfig
+	assert.Equal(t, []string{"ALL"}, []string(host.CapDrop))
+	assert.Contains(t, host.SecurityOpt, "no-new-privileges:true")
+	assert.Contains(t, host.SecurityOpt, `seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`)
+	require.NotNil(t, host.PidsLimit)
+	assert.Equal(t, int64(exportPidsLimit), *host.PidsLimit)
+	assert.Contains(t, host.Mounts, mount.Mount{Type: mount.TypeVolume, Target: "/etc"})
+}
+
+func TestStartExport_RequiresPinnedImage(t *testing.T) {
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.StartExport(context.Background(), ExportConfig{SessionID: "session-123", ContainerID: "container-123", SSHPort: 30001})
+
+	assert.ErrorIs(t, err, ErrExportImageNotPinned)
+	assert.Equal(t, 0, mock.CreateCalled)
+}
+
+func TestPinExportImage_PullsMissingImage(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, ImageID: "sha256:export"}
+	svc := NewDockerServiceWithClient(mock)
+
+	require.NoError(t, svc.PinExportImage(context.Background(), DefaultExportImage))
+
+	assert.Equal(t, []string{DefaultExportImage}, mock.PullCalls)
+	assert.Equal(t, "sha256:export", svc.exportImage)
+}
+
+func TestStartExport_RemovesContainerWhenStartFails(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "export-123"},
+		StartErrors:    []error{errors.New("port is already allocated")},
+	}
+	svc := exportService(t, mock)
+
+	_, err := svc.StartExport(context.Background(), ExportConfig{SessionID: "session-123", ContainerID: "container-123", SSHPort: 30001})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, mock.RemoveCalled)
+}
+
+func TestListRentalContainers_MarksExports(t *testing.T) {
+	mock := &MockDockerClient{
+		Containers: []container.Summary{
+			{ID: "export-123", State: "running", Labels: map[string]string{LabelSessionID: "", LabelExportOf: "session-123"}},
+		},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	rentals, err := svc.ListRentalContainers(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, rentals, 1)
+	assert.Equal(t, "session-123", rentals[0].SessionID)
+	assert.True(t, rentals[0].Export)
+}
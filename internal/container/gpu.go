@@ -0,0 +1,90 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/worldland/worldland-node/internal/domain"
+)
+
+var (
+	ErrUnknownGPU = errors.New("gpu not found on this host")
+	ErrAllGPUs    = errors.New("rental containers must name their gpus")
+)
+
+// GPUSpecSource lists the host's GPUs (domain.GPUProvider satisfies it)
+type GPUSpecSource interface {
+	GetSpecs() ([]domain.GPUSpec, error)
+}
+
+// WithGPUs lets CreateContainer check assigned GPUs and MIG slices against
+// the host's and translate them to the device indexes
+// NVIDIA_VISIBLE_DEVICES needs in the nvidia runtime's CDI mode ("0", or
+// "0:1" for slice 1 of GPU 0). Without it assignments are passed through.
+func (s *DockerService) WithGPUs(gpus GPUSpecSource) *DockerService {
+	s.gpus = gpus
+	return s
+}
+
+// gpuDevices resolves a comma-separated GPU assignment to the devices a
+// container may see: nil for none, ["all"] for every GPU. Only the mining
+// container may ask for "all"; rentals get their GPUs from the inventory
+// allocator by UUID. GPU and MIG slice UUIDs, GPU indexes and "<gpu>:<slice>"
+// indexes are checked against the host and given as UUIDs, or with byIndex
+// as indexes.
+func (s *DockerService) gpuDevices(gpuDeviceID string, byIndex, allowAll bool) ([]string, error) {
+	if gpuDeviceID == "" {
+		return nil, nil
+	}
+	if gpuDeviceID == "all" {
+		if !allowAll {
+			return nil, ErrAllGPUs
+		}
+		return []string{"all"}, nil
+	}
+
+	var specs map[string]domain.GPUSpec // UUID or index -> GPU or MIG slice
+	var devices []string
+	for _, id := range strings.Split(gpuDeviceID, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if s.gpus == nil {
+			devices = append(devices, id)
+			continue
+		}
+		if specs == nil {
+			list, err := s.gpus.GetSpecs()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list gpus: %w", err)
+			}
+			specs = make(map[string]domain.GPUSpec, 2*len(list))
+			for _, spec := range list {
+				specs[spec.UUID] = spec
+				specs[deviceIndex(spec)] = spec
+			}
+		}
+		spec, ok := specs[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownGPU, id)
+		}
+		if byIndex {
+			devices = append(devices, deviceIndex(spec))
+		} else {
+			devices = append(devices, spec.UUID)
+		}
+	}
+	return devices, nil
+}
+
+// deviceIndex names a GPU by NVML index, or a MIG slice as "<gpu>:<slice>"
+func deviceIndex(spec domain.GPUSpec) string {
+	index := strconv.Itoa(spec.Index)
+	if spec.IsMIGSlice() {
+		index += ":" + strconv.Itoa(spec.MIGIndex)
+	}
+	return index
+}
@@ -0,0 +1,145 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/domain"
+)
+
+// fakeGPUs implements GPUSpecSource for testing
+type fakeGPUs []domain.GPUSpec
+
+func (f fakeGPUs) GetSpecs() ([]domain.GPUSpec, error) {
+	return f, nil
+}
+
+// fourGPUs has four whole GPUs and a fifth in MIG mode with two slices
+var fourGPUs = fakeGPUs{
+	{UUID: "GPU-aaa", Index: 0},
+	{UUID: "GPU-bbb", Index: 1},
+	{UUID: "GPU-ccc", Index: 2},
+	{UUID: "GPU-ddd", Index: 3},
+	{UUID: "GPU-eee", Index: 4, MIGMode: true},
+	{UUID: "MIG-eee-0", Index: 4, ParentUUID: "GPU-eee", MIGProfile: "3g.40gb", MIGIndex: 0},
+	{UUID: "MIG-eee-1", Index: 4, ParentUUID: "GPU-eee", MIGProfile: "3g.40gb", MIGIndex: 1},
+}
+
+func TestCreateContainer_ExposesOnlyAssignedGPUs(t *testing.T) {
+	tests := []struct {
+		name        string
+		gpuDeviceID string
+		runtime     Runtime
+		wantEnv     string
+		wantIDs     []string
+	}{
+		{name: "single uuid", gpuDeviceID: "GPU-ccc", wantEnv: "NVIDIA_VISIBLE_DEVICES=2"},
+		{name: "multiple uuids", gpuDeviceID: "GPU-bbb,GPU-ddd", wantEnv: "NVIDIA_VISIBLE_DEVICES=1,3"},
+		{name: "index", gpuDeviceID: "1", wantEnv: "NVIDIA_VISIBLE_DEVICES=1"},
+		{name: "mig slice index", gpuDeviceID: "4:0", wantEnv: "NVIDIA_VISIBLE_DEVICES=4:0"},
+		{name: "index device request", gpuDeviceID: "2", runtime: Runtime{Name: "runc"}, wantIDs: []string{"GPU-ccc"}},
+		{name: "mig slice", gpuDeviceID: "MIG-eee-1", wantEnv: "NVIDIA_VISIBLE_DEVICES=4:1"},
+		{name: "mig slice device request", gpuDeviceID: "MIG-eee-0", runtime: Runtime{Name: "runc"}, wantIDs: []string{"MIG-eee-0"}},
+		{name: "no gpu", gpuDeviceID: "", wantEnv: "NVIDIA_VISIBLE_DEVICES=none"},
+		{name: "device request", gpuDeviceID: "GPU-bbb,GPU-ddd", runtime: Runtime{Name: "runc"}, wantIDs: []string{"GPU-bbb", "GPU-ddd"}},
+		{name: "device request no gpu", gpuDeviceID: "", runtime: Runtime{Name: "runc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+			svc := NewDockerServiceWithClient(mock).WithGPUs(fourGPUs)
+
+			_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+				SessionID:   "session-abc",
+				Image:       "img",
+				SSHPort:     30001,
+				GPUDeviceID: tt.gpuDeviceID,
+				Runtime:     tt.runtime,
+			})
+			require.NoError(t, err)
+
+			var visible []string
+			for _, env := range mock.LastCreateConfig.Env {
+				if len(env) > len("NVIDIA_VISIBLE_DEVICES=") && env[:len("NVIDIA_VISIBLE_DEVICES=")] == "NVIDIA_VISIBLE_DEVICES=" {
+					visible = append(visible, env)
+				}
+			}
+			if tt.wantEnv != "" {
+				assert.Equal(t, []string{tt.wantEnv}, visible)
+			} else {
+				assert.Empty(t, visible)
+			}
+
+			if tt.wantIDs != nil {
+				require.Len(t, mock.LastHostConfig.DeviceRequests, 1)
+				assert.Equal(t, tt.wantIDs, mock.LastHostConfig.DeviceRequests[0].DeviceIDs)
+				assert.Zero(t, mock.LastHostConfig.DeviceRequests[0].Count)
+			} else {
+				assert.Empty(t, mock.LastHostConfig.DeviceRequests)
+			}
+		})
+	}
+}
+
+func TestCreateContainer_RejectsUnknownGPU(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock).WithGPUs(fourGPUs)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:   "session-abc",
+		Image:       "img",
+		GPUDeviceID: "GPU-aaa,GPU-zzz",
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownGPU)
+	assert.Equal(t, 0, mock.CreateCalled)
+}
+
+func TestCreateContainer_RejectsAllGPUsForRentals(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock).WithGPUs(fourGPUs)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:   "session-abc",
+		Image:       "img",
+		GPUDeviceID: "all",
+	})
+
+	assert.ErrorIs(t, err, ErrAllGPUs)
+	assert.Equal(t, 0, mock.CreateCalled)
+}
+
+func TestCreateContainer_RejectsUnknownGPUIndex(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock).WithGPUs(fourGPUs)
+
+	for _, id := range []string{"7", "4:2"} {
+		_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+			SessionID:   "session-abc",
+			Image:       "img",
+			GPUDeviceID: id,
+		})
+		assert.ErrorIs(t, err, ErrUnknownGPU, id)
+	}
+	assert.Equal(t, 0, mock.CreateCalled)
+}
+
+func TestCreateContainer_MiningGetsItsGPUOnly(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock).WithGPUs(fourGPUs)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:          "worldland-mining",
+		Image:              "img",
+		GPUDeviceID:        "GPU-ddd",
+		UseImageEntrypoint: true,
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, mock.LastCreateConfig.Env, "NVIDIA_VISIBLE_DEVICES=3")
+	assert.NotContains(t, mock.LastCreateConfig.Env, "NVIDIA_VISIBLE_DEVICES=all")
+}
@@ -0,0 +1,142 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+var (
+	ErrInvalidUlimit  = errors.New("invalid ulimit")
+	ErrInvalidIPCMode = errors.New("invalid ipc mode")
+)
+
+// Ulimit is a resource limit set in a rental container. -1 means unlimited.
+type Ulimit struct {
+	Name string `json:"name"` // memlock, nofile or stack
+	Soft int64  `json:"soft"`
+	Hard int64  `json:"hard"`
+}
+
+// UlimitNames are the limits rentals may set: memlock for pinned host
+// memory (CUDA, NCCL, RDMA), nofile for DataLoader workers and stack for
+// deep recursion in compiled extensions
+var UlimitNames = []string{"memlock", "nofile", "stack"}
+
+// ParseUlimit parses Docker's --ulimit syntax, "name=soft[:hard]". The hard
+// limit defaults to the soft one.
+func ParseUlimit(s string) (Ulimit, error) {
+	name, value, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok {
+		return Ulimit{}, fmt.Errorf("%w: %q (want name=soft[:hard])", ErrInvalidUlimit, s)
+	}
+	soft, hard, hasHard := strings.Cut(value, ":")
+	u := Ulimit{Name: name}
+	var err error
+	if u.Soft, err = strconv.ParseInt(soft, 10, 64); err != nil {
+		return Ulimit{}, fmt.Errorf("%w: %q", ErrInvalidUlimit, s)
+	}
+	u.Hard = u.Soft
+	if hasHard {
+		if u.Hard, err = strconv.ParseInt(hard, 10, 64); err != nil {
+			return Ulimit{}, fmt.Errorf("%w: %q", ErrInvalidUlimit, s)
+		}
+	}
+	return u, u.Validate()
+}
+
+// ParseUlimits parses a comma-separated list of ulimits
+func ParseUlimits(s string) ([]Ulimit, error) {
+	var out []Ulimit
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		u, err := ParseUlimit(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+// Validate checks the name and that the soft limit doesn't exceed the hard one
+func (u Ulimit) Validate() error {
+	known := false
+	for _, name := range UlimitNames {
+		known = known || u.Name == name
+	}
+	if !known {
+		return fmt.Errorf("%w: unsupported limit %q", ErrInvalidUlimit, u.Name)
+	}
+	if u.Soft < -1 || u.Hard < -1 || LimitExceeds(u.Soft, u.Hard) {
+		return fmt.Errorf("%w: %s soft %d, hard %d", ErrInvalidUlimit, u.Name, u.Soft, u.Hard)
+	}
+	return nil
+}
+
+// String formats the limit in --ulimit syntax
+func (u Ulimit) String() string {
+	return fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard)
+}
+
+// LimitExceeds reports whether limit a is above limit b, where -1 is unlimited
+func LimitExceeds(a, b int64) bool {
+	if b == -1 {
+		return false
+	}
+	return a == -1 || a > b
+}
+
+// MergeUlimits returns base with each limit in override replacing the one of
+// the same name, sorted by name
+func MergeUlimits(base, override []Ulimit) []Ulimit {
+	byName := make(map[string]Ulimit, len(base)+len(override))
+	for _, u := range base {
+		byName[u.Name] = u
+	}
+	for _, u := range override {
+		byName[u.Name] = u
+	}
+	out := make([]Ulimit, 0, len(byName))
+	for _, u := range byName {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// dockerUlimits converts ulimits to the HostConfig form
+func dockerUlimits(ulimits []Ulimit) []*container.Ulimit {
+	if len(ulimits) == 0 {
+		return nil
+	}
+	out := make([]*container.Ulimit, 0, len(ulimits))
+	for _, u := range ulimits {
+		out = append(out, &container.Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+	}
+	return out
+}
+
+// IPC modes a rental container may run with. Joining another container's IPC
+// namespace is never allowed.
+const (
+	IPCModePrivate   = "private"   // Own namespace, /dev/shm not shareable
+	IPCModeShareable = "shareable" // Own namespace (Docker's default)
+	IPCModeHost      = "host"      // Host namespace; /dev/shm is the host's
+)
+
+// ParseIPCMode validates an IPC mode. Empty keeps Docker's default.
+func ParseIPCMode(s string) (string, error) {
+	switch s {
+	case "", IPCModePrivate, IPCModeShareable, IPCModeHost:
+		return s, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidIPCMode, s)
+	}
+}
@@ -0,0 +1,60 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUlimit(t *testing.T) {
+	u, err := ParseUlimit("nofile=1024:65536")
+	require.NoError(t, err)
+	assert.Equal(t, Ulimit{Name: "nofile", Soft: 1024, Hard: 65536}, u)
+
+	u, err = ParseUlimit("memlock=-1")
+	require.NoError(t, err)
+	assert.Equal(t, Ulimit{Name: "memlock", Soft: -1, Hard: -1}, u)
+	assert.Equal(t, "memlock=-1:-1", u.String())
+
+	for _, bad := range []string{"nofile", "nofile=abc", "nproc=10", "nofile=100:10", "stack=-1:100", "stack=-2"} {
+		_, err := ParseUlimit(bad)
+		assert.ErrorIs(t, err, ErrInvalidUlimit, bad)
+	}
+}
+
+func TestParseUlimits(t *testing.T) {
+	ulimits, err := ParseUlimits("memlock=-1, stack=67108864,")
+	require.NoError(t, err)
+	assert.Equal(t, []Ulimit{{Name: "memlock", Soft: -1, Hard: -1}, {Name: "stack", Soft: 67108864, Hard: 67108864}}, ulimits)
+
+	ulimits, err = ParseUlimits("")
+	require.NoError(t, err)
+	assert.Empty(t, ulimits)
+}
+
+func TestMergeUlimits(t *testing.T) {
+	merged := MergeUlimits(
+		[]Ulimit{{Name: "stack", Soft: 8 << 20, Hard: 8 << 20}, {Name: "memlock", Soft: -1, Hard: -1}},
+		[]Ulimit{{Name: "stack", Soft: 64 << 20, Hard: 64 << 20}},
+	)
+	assert.Equal(t, []Ulimit{{Name: "memlock", Soft: -1, Hard: -1}, {Name: "stack", Soft: 64 << 20, Hard: 64 << 20}}, merged)
+}
+
+func TestLimitExceeds(t *testing.T) {
+	assert.False(t, LimitExceeds(10, 20))
+	assert.True(t, LimitExceeds(30, 20))
+	assert.True(t, LimitExceeds(-1, 20))
+	assert.False(t, LimitExceeds(-1, -1))
+	assert.False(t, LimitExceeds(1<<40, -1))
+}
+
+func TestParseIPCMode(t *testing.T) {
+	for _, mode := range []string{"", "private", "shareable", "host"} {
+		got, err := ParseIPCMode(mode)
+		require.NoError(t, err)
+		assert.Equal(t, mode, got)
+	}
+	_, err := ParseIPCMode("container:other")
+	assert.ErrorIs(t, err, ErrInvalidIPCMode)
+}
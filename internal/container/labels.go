@@ -0,0 +1,92 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Labels recorded on rental containers and networks. They carry what a
+// restarted node needs to re-adopt a rental it left running.
+const (
+	LabelSessionID     = "worldland.session_id"
+	LabelGPUDeviceID   = "worldland.gpu_device_id"
+	LabelAccessMode    = "worldland.access_mode"
+	LabelDiskQuota     = "worldland.disk_quota_bytes"
+	LabelNetwork       = "worldland.network"
+	LabelPricePerSec   = "worldland.price_per_sec"
+	LabelRestartPolicy = "worldland.restart_policy"
+	LabelWorkspace     = "worldland.workspace"
+	LabelCPUSet        = "worldland.cpuset"
+	LabelMemNodes      = "worldland.mem_nodes"
+	LabelSecurity      = "worldland.security_profile"
+	LabelRuntimeClass  = "worldland.runtime_class"
+	LabelStartedAt     = "worldland.started_at" // RFC 3339; kept across rebuilds, unlike the container's creation time
+)
+
+// RentalContainer is a labelled rental container found on the host
+type RentalContainer struct {
+	ContainerID string
+	SessionID   string
+	Image       string
+	State       string // "running", "exited", etc.
+	Created     time.Time
+	SSHPort     int           // Host port published for 22/tcp (0 = none)
+	Ports       []PortMapping // Other published ports
+	Labels      map[string]string
+	Export      bool // A data export container serving SessionID (see StartExport)
+}
+
+// ListRentalContainers returns every container carrying a rental session
+// label, running or not, including data export containers
+func (s *DockerService) ListRentalContainers(ctx context.Context) ([]RentalContainer, error) {
+	list, err := s.cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", LabelSessionID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rental containers: %w", err)
+	}
+
+	var out []RentalContainer
+	for _, c := range list {
+		sessionID := c.Labels[LabelSessionID]
+		exportOf := c.Labels[LabelExportOf]
+		if sessionID == "" && exportOf == "" {
+			continue
+		}
+		if exportOf != "" {
+			sessionID = exportOf
+		}
+		rc := RentalContainer{
+			ContainerID: c.ID,
+			SessionID:   sessionID,
+			Image:       c.Image,
+			State:       c.State,
+			Created:     time.Unix(c.Created, 0),
+			Labels:      c.Labels,
+			Export:      exportOf != "",
+		}
+		seen := make(map[PortMapping]bool)
+		for _, p := range c.Ports {
+			if p.PublicPort == 0 {
+				continue
+			}
+			if p.PrivatePort == 22 && p.Type == "tcp" {
+				rc.SSHPort = int(p.PublicPort)
+				continue
+			}
+			// Docker lists IPv4 and IPv6 bindings separately
+			m := PortMapping{ContainerPort: int(p.PrivatePort), HostPort: int(p.PublicPort), Protocol: p.Type}
+			if !seen[m] {
+				seen[m] = true
+				rc.Ports = append(rc.Ports, m)
+			}
+		}
+		out = append(out, rc)
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateContainer_LabelsRentalContainers(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID: "session-123",
+		Image:     "nvidia/cuda:12.1.1-runtime-ubuntu22.04",
+		SSHPort:   30001,
+		Labels:    map[string]string{LabelGPUDeviceID: "GPU-aaa"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		LabelSessionID:   "session-123",
+		LabelGPUDeviceID: "GPU-aaa",
+	}, mock.LastCreateConfig.Labels)
+}
+
+func TestListRentalContainers(t *testing.T) {
+	mock := &MockDockerClient{
+		Containers: []container.Summary{
+			{
+				ID:      "container-123",
+				Image:   "nvidia/cuda:12.1.1-runtime-ubuntu22.04",
+				State:   "running",
+				Created: 1700000000,
+				Labels:  map[string]string{LabelSessionID: "session-123", LabelAccessMode: "jupyter"},
+				Ports: []container.Port{
+					{PrivatePort: 22, PublicPort: 30001, Type: "tcp"},
+					{IP: "0.0.0.0", PrivatePort: 8888, PublicPort: 30002, Type: "tcp"},
+					{IP: "::", PrivatePort: 8888, PublicPort: 30002, Type: "tcp"},
+					{PrivatePort: 9000, Type: "tcp"}, // Exposed but not published
+				},
+			},
+			{ID: "worldland-mining", State: "running"}, // Not a rental
+		},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	rentals, err := svc.ListRentalContainers(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, rentals, 1)
+	r := rentals[0]
+	assert.Equal(t, "session-123", r.SessionID)
+	assert.Equal(t, "running", r.State)
+	assert.Equal(t, int64(1700000000), r.Created.Unix())
+	assert.Equal(t, 30001, r.SSHPort)
+	assert.Equal(t, []PortMapping{{ContainerPort: 8888, HostPort: 30002, Protocol: "tcp"}}, r.Ports)
+	assert.Equal(t, "jupyter", r.Labels[LabelAccessMode])
+}
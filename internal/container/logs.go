@@ -0,0 +1,90 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// DefaultLogMaxBytes caps log output when LogOptions.MaxBytes is unset
+const DefaultLogMaxBytes = 1 << 20
+
+// ErrLogLimitReached is returned once MaxBytes of output have been written
+var ErrLogLimitReached = errors.New("log size limit reached")
+
+// LogOptions selects which container output ContainerLogs returns
+type LogOptions struct {
+	Tail       int   // Last N lines (0 = all)
+	Follow     bool  // Keep streaming until the container exits or ctx ends
+	Timestamps bool  // Prefix lines with RFC3339 timestamps
+	MaxBytes   int64 // Output cap (0 = DefaultLogMaxBytes)
+}
+
+// ContainerLogs writes the container's stdout and stderr to w. Rental
+// containers run without a TTY, so Docker multiplexes both streams and they
+// are demultiplexed here. Output past MaxBytes is dropped and
+// ErrLogLimitReached returned.
+func (s *DockerService) ContainerLogs(ctx context.Context, containerID string, opts LogOptions, w io.Writer) error {
+	tail := "all"
+	if opts.Tail > 0 {
+		tail = strconv.Itoa(opts.Tail)
+	}
+
+	rc, err := s.cli.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Timestamps: opts.Timestamps,
+		Tail:       tail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+	defer rc.Close()
+
+	max := opts.MaxBytes
+	if max <= 0 {
+		max = DefaultLogMaxBytes
+	}
+	lw := &limitWriter{w: w, remaining: max}
+	if _, err := stdcopy.StdCopy(lw, lw, rc); err != nil {
+		if errors.Is(err, ErrLogLimitReached) {
+			return ErrLogLimitReached
+		}
+		if ctx.Err() != nil {
+			return nil // Follow ended by the caller
+		}
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+	return nil
+}
+
+// limitWriter passes through at most remaining bytes, then fails
+type limitWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, ErrLogLimitReached
+	}
+	truncated := int64(len(p)) > l.remaining
+	if truncated {
+		p = p[:l.remaining]
+	}
+	n, err := l.w.Write(p)
+	l.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	if truncated {
+		return n, ErrLogLimitReached
+	}
+	return n, nil
+}
@@ -0,0 +1,55 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// muxLogs builds a multiplexed stream like the daemon returns for non-TTY containers
+func muxLogs(t *testing.T, stdout, stderr string) []byte {
+	var buf bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(stdout))
+	require.NoError(t, err)
+	_, err = stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(stderr))
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestContainerLogs_DemultiplexesStreams(t *testing.T) {
+	mock := &MockDockerClient{Logs: muxLogs(t, "sshd started\n", "apt-get: warning\n")}
+	svc := NewDockerServiceWithClient(mock)
+
+	var out bytes.Buffer
+	err := svc.ContainerLogs(context.Background(), "container-123", LogOptions{Tail: 100, Follow: true}, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "sshd started\napt-get: warning\n", out.String())
+	assert.Equal(t, "100", mock.LastLogsOptions.Tail)
+	assert.True(t, mock.LastLogsOptions.Follow)
+	assert.True(t, mock.LastLogsOptions.ShowStdout)
+	assert.True(t, mock.LastLogsOptions.ShowStderr)
+}
+
+func TestContainerLogs_DefaultsToAllLines(t *testing.T) {
+	mock := &MockDockerClient{Logs: muxLogs(t, "a\n", "")}
+	svc := NewDockerServiceWithClient(mock)
+
+	require.NoError(t, svc.ContainerLogs(context.Background(), "container-123", LogOptions{}, &bytes.Buffer{}))
+	assert.Equal(t, "all", mock.LastLogsOptions.Tail)
+}
+
+func TestContainerLogs_CapsOutput(t *testing.T) {
+	mock := &MockDockerClient{Logs: muxLogs(t, "0123456789", "abcdef")}
+	svc := NewDockerServiceWithClient(mock)
+
+	var out bytes.Buffer
+	err := svc.ContainerLogs(context.Background(), "container-123", LogOptions{MaxBytes: 12}, &out)
+
+	assert.ErrorIs(t, err, ErrLogLimitReached)
+	assert.Equal(t, "0123456789ab", out.String())
+}
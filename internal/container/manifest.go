@@ -0,0 +1,264 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var ErrManifestUnavailable = errors.New("image manifest unavailable")
+
+// Manifest media types a registry may return for an image
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// maxManifestBytes bounds manifest and token responses
+const maxManifestBytes = 4 << 20
+
+// ManifestFetcher reads a manifest by digest from an image's registry and
+// returns its media type and body (swapped out in tests)
+type ManifestFetcher func(ctx context.Context, named reference.Named, dgst digest.Digest, auth *RegistryAuth) (string, []byte, error)
+
+// WithManifestFetcher replaces how manifests are read for the pre-pull size
+// check (for testing)
+func (s *DockerService) WithManifestFetcher(fn ManifestFetcher) *DockerService {
+	s.manifests = fn
+	return s
+}
+
+// remoteImageSize sums the config and layer sizes of ref in its registry,
+// following an index to the manifest for this node's platform. Layers are
+// counted compressed, so the size on disk after the pull is larger.
+func (s *DockerService) remoteImageSize(ctx context.Context, ref string, auth *RegistryAuth) (int64, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	var dgst digest.Digest
+	if canonical, ok := named.(reference.Canonical); ok {
+		dgst = canonical.Digest()
+	} else {
+		encodedAuth, err := s.registryAuth(ctx, ref, auth)
+		if err != nil {
+			return 0, err
+		}
+		dist, err := s.cli.DistributionInspect(ctx, reference.TagNameOnly(named).String(), encodedAuth)
+		if err != nil {
+			return 0, err
+		}
+		dgst = dist.Descriptor.Digest
+	}
+
+	creds, err := s.resolveAuth(ctx, ref, auth)
+	if err != nil {
+		return 0, err
+	}
+	mediaType, body, err := s.manifests(ctx, named, dgst, creds)
+	if err != nil {
+		return 0, err
+	}
+
+	if mediaType == specs.MediaTypeImageIndex || mediaType == mediaTypeDockerManifestList {
+		var index specs.Index
+		if err := json.Unmarshal(body, &index); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrManifestUnavailable, err)
+		}
+		found := false
+		for _, m := range index.Manifests {
+			if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH {
+				dgst, found = m.Digest, true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%w: no linux/%s manifest", ErrManifestUnavailable, runtime.GOARCH)
+		}
+		if mediaType, body, err = s.manifests(ctx, named, dgst, creds); err != nil {
+			return 0, err
+		}
+	}
+	if mediaType != specs.MediaTypeImageManifest && mediaType != mediaTypeDockerManifest {
+		return 0, fmt.Errorf("%w: unsupported media type %q", ErrManifestUnavailable, mediaType)
+	}
+
+	var manifest specs.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrManifestUnavailable, err)
+	}
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size, nil
+}
+
+// registryManifests fetches manifests over the registry HTTP API, with
+// basic or bearer token auth as the registry asks for
+type registryManifests struct {
+	client *http.Client
+	scheme string // "https" except in tests
+}
+
+// fetch implements ManifestFetcher
+func (r registryManifests) fetch(ctx context.Context, named reference.Named, dgst digest.Digest, auth *RegistryAuth) (string, []byte, error) {
+	host := reference.Domain(named)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", r.scheme, host, reference.Path(named), dgst)
+
+	resp, err := r.get(ctx, manifestURL, "")
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		authorization, err := r.authorize(ctx, challenge, auth)
+		if err != nil {
+			return "", nil, err
+		}
+		if resp, err = r.get(ctx, manifestURL, authorization); err != nil {
+			return "", nil, err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("%w: %s returned %s", ErrManifestUnavailable, host, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return "", nil, err
+	}
+	if digest.FromBytes(body) != dgst {
+		return "", nil, fmt.Errorf("%w: digest mismatch", ErrManifestUnavailable)
+	}
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	return mediaType, body, nil
+}
+
+// get requests a manifest, accepting every manifest and index type
+func (r registryManifests) get(ctx context.Context, url, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		specs.MediaTypeImageManifest, specs.MediaTypeImageIndex,
+		mediaTypeDockerManifest, mediaTypeDockerManifestList,
+	}, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return r.client.Do(req)
+}
+
+// authorize answers a registry's WWW-Authenticate challenge with an
+// Authorization header value
+func (r registryManifests) authorize(ctx context.Context, challenge string, auth *RegistryAuth) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if auth == nil || auth.Username == "" {
+			return "", fmt.Errorf("%w: registry requires credentials", ErrManifestUnavailable)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(auth.Username, auth.Password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("%w: unsupported auth challenge %q", ErrManifestUnavailable, scheme)
+	}
+
+	attrs := parseChallenge(params)
+	tokenURL, err := url.Parse(attrs["realm"])
+	if err != nil || attrs["realm"] == "" {
+		return "", fmt.Errorf("%w: invalid token realm", ErrManifestUnavailable)
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if attrs[key] != "" {
+			query.Set(key, attrs[key])
+		}
+	}
+
+	var req *http.Request
+	if auth != nil && auth.IdentityToken != "" {
+		// OAuth2 refresh token, as docker login stores for some registries
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {auth.IdentityToken},
+			"service":       {attrs["service"]},
+			"scope":         {attrs["scope"]},
+			"client_id":     {"worldland-node"},
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL.String(), strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		tokenURL.RawQuery = query.Encode()
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if auth != nil && auth.Username != "" {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: token request returned %s", ErrManifestUnavailable, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&token); err != nil {
+		return "", fmt.Errorf("%w: invalid token response: %v", ErrManifestUnavailable, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge reads the key="value" pairs of a WWW-Authenticate header
+func parseChallenge(params string) map[string]string {
+	attrs := make(map[string]string)
+	for params != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(params, ", "), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.ToLower(strings.TrimSpace(key))] = value
+		params = rest
+	}
+	return attrs
+}
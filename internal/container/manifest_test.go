@@ -0,0 +1,143 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unavailableManifests(ctx context.Context, named reference.Named, dgst digest.Digest, auth *RegistryAuth) (string, []byte, error) {
+	return "", nil, ErrManifestUnavailable
+}
+
+// stubManifests serves manifests by digest from memory
+type stubManifests map[digest.Digest]struct {
+	mediaType string
+	body      []byte
+}
+
+func (m stubManifests) add(mediaType string, v any) digest.Digest {
+	body, _ := json.Marshal(v)
+	dgst := digest.FromBytes(body)
+	m[dgst] = struct {
+		mediaType string
+		body      []byte
+	}{mediaType, body}
+	return dgst
+}
+
+func (m stubManifests) fetch(ctx context.Context, named reference.Named, dgst digest.Digest, auth *RegistryAuth) (string, []byte, error) {
+	entry, ok := m[dgst]
+	if !ok {
+		return "", nil, ErrManifestUnavailable
+	}
+	return entry.mediaType, entry.body, nil
+}
+
+func imageManifest(layerSizes ...int64) specs.Manifest {
+	manifest := specs.Manifest{Config: specs.Descriptor{Size: 1000}}
+	for _, size := range layerSizes {
+		manifest.Layers = append(manifest.Layers, specs.Descriptor{Size: size})
+	}
+	return manifest
+}
+
+func TestPrepareImage_RejectsOversizedManifestBeforePull(t *testing.T) {
+	manifests := stubManifests{}
+	dgst := manifests.add(specs.MediaTypeImageManifest, imageManifest(8<<30, 4<<30))
+	mock := &MockDockerClient{ImageMissing: true, DistributionDigest: string(dgst)}
+	svc := NewDockerServiceWithClient(mock).
+		WithManifestFetcher(manifests.fetch).
+		WithImagePolicy(ImagePolicy{MaxSizeBytes: 10 << 30})
+
+	_, err := svc.PrepareImage(context.Background(), "huge/model:1", nil, nil)
+
+	requirePolicyCode(t, err, ImageCodeTooLarge)
+	assert.Equal(t, []string{"docker.io/huge/model:1"}, mock.DistributionCalls)
+	assert.Empty(t, mock.PullCalls)
+}
+
+func TestPrepareImage_PullsWhenManifestUnderLimit(t *testing.T) {
+	manifests := stubManifests{}
+	dgst := manifests.add(specs.MediaTypeImageManifest, imageManifest(1<<30))
+	mock := &MockDockerClient{ImageMissing: true, ImageSize: 3 << 30, DistributionDigest: string(dgst)}
+	svc := NewDockerServiceWithClient(mock).
+		WithManifestFetcher(manifests.fetch).
+		WithImagePolicy(ImagePolicy{MaxSizeBytes: 10 << 30})
+
+	_, err := svc.PrepareImage(context.Background(), "small/model:1", nil, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"small/model:1"}, mock.PullCalls)
+}
+
+func TestRemoteImageSize_FollowsIndexToPlatformManifest(t *testing.T) {
+	manifests := stubManifests{}
+	other := manifests.add(specs.MediaTypeImageManifest, imageManifest(50<<30))
+	native := manifests.add(specs.MediaTypeImageManifest, imageManifest(2000, 3000))
+	index := manifests.add(specs.MediaTypeImageIndex, specs.Index{Manifests: []specs.Descriptor{
+		{Digest: other, Platform: &specs.Platform{OS: "windows", Architecture: runtime.GOARCH}},
+		{Digest: native, Platform: &specs.Platform{OS: "linux", Architecture: runtime.GOARCH}},
+	}})
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock).WithManifestFetcher(manifests.fetch)
+
+	size, err := svc.remoteImageSize(context.Background(), "repo/app@"+string(index), nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(6000), size)
+	assert.Empty(t, mock.DistributionCalls, "pinned reference needs no tag lookup")
+}
+
+func TestRegistryManifests_FetchesWithBearerToken(t *testing.T) {
+	body, _ := json.Marshal(imageManifest(42))
+	dgst := digest.FromBytes(body)
+
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			user, pass, _ := r.BasicAuth()
+			if user != "renter" || pass != "secret" || r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"token":"abc"}`)
+		case "/v2/team/app/manifests/" + dgst.String():
+			if r.Header.Get("Authorization") != "Bearer abc" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:team/app:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", specs.MediaTypeImageManifest)
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(srv.URL, "https://") + "/team/app")
+	require.NoError(t, err)
+	fetch := registryManifests{client: srv.Client(), scheme: "https"}.fetch
+
+	mediaType, got, err := fetch(context.Background(), named, dgst, &RegistryAuth{Username: "renter", Password: "secret"})
+
+	require.NoError(t, err)
+	assert.Equal(t, specs.MediaTypeImageManifest, mediaType)
+	assert.Equal(t, body, got)
+
+	_, _, err = fetch(context.Background(), named, dgst, nil)
+	assert.ErrorIs(t, err, ErrManifestUnavailable)
+}
@@ -0,0 +1,261 @@
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"os/exec"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+// Default egress block lists. IPv4 only: rental networks are created without IPv6.
+var (
+	// privateCIDRs covers RFC1918 ranges (host LAN, docker0 and other bridges)
+	// plus carrier-grade NAT space used by some cloud VPCs.
+	privateCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10"}
+
+	// linkLocalCIDRs covers link-local addresses including the
+	// 169.254.169.254 cloud metadata endpoint.
+	linkLocalCIDRs = []string{"169.254.0.0/16"}
+)
+
+// Rental network naming. Linux limits interface names to 15 characters, so
+// bridges are named from a hash of the session ID.
+const (
+	rentalNetworkPrefix = "wl-rental-"
+	rentalBridgePrefix  = "wlr-"
+	rentalChainPrefix   = "WLR-"
+)
+
+// EgressPolicy controls which destinations a rental container can reach
+type EgressPolicy struct {
+	BlockPrivate   bool     `json:"blockPrivate"`         // RFC1918 / CGNAT (host LAN, mining node, docker bridges)
+	BlockLinkLocal bool     `json:"blockLinkLocal"`       // 169.254.0.0/16 incl. cloud metadata
+	BlockRentals   bool     `json:"blockRentals"`         // Other rental networks on this host
+	BlockHost      bool     `json:"blockHost"`            // Services listening on the host itself (e.g. mining RPC)
+	AllowCIDRs     []string `json:"allowCidrs,omitempty"` // Exceptions, evaluated before any block
+	DenyCIDRs      []string `json:"denyCidrs,omitempty"`  // Additional blocked destinations
+}
+
+// DefaultEgressPolicy blocks everything except the public internet
+func DefaultEgressPolicy() EgressPolicy {
+	return EgressPolicy{
+		BlockPrivate:   true,
+		BlockLinkLocal: true,
+		BlockRentals:   true,
+		BlockHost:      true,
+	}
+}
+
+// Validate checks that all CIDRs in the policy parse
+func (p EgressPolicy) Validate() error {
+	for _, list := range [][]string{p.AllowCIDRs, p.DenyCIDRs} {
+		for _, cidr := range list {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("invalid egress CIDR %q: %w", cidr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Narrow applies a per-rental request to the node's policy p, which is the
+// most any rental may reach: the request can add blocks and denied CIDRs
+// and restrict the allow list, but never open what p blocks. Requested
+// allow CIDRs are intersected with p's; without any the rental keeps p's.
+func (p EgressPolicy) Narrow(req EgressPolicy) EgressPolicy {
+	out := EgressPolicy{
+		BlockPrivate:   p.BlockPrivate || req.BlockPrivate,
+		BlockLinkLocal: p.BlockLinkLocal || req.BlockLinkLocal,
+		BlockRentals:   p.BlockRentals || req.BlockRentals,
+		BlockHost:      p.BlockHost || req.BlockHost,
+		AllowCIDRs:     append([]string{}, p.AllowCIDRs...),
+		DenyCIDRs:      append(append([]string{}, p.DenyCIDRs...), req.DenyCIDRs...),
+	}
+	if req.AllowCIDRs == nil {
+		return out
+	}
+
+	out.AllowCIDRs = []string{}
+	for _, r := range req.AllowCIDRs {
+		_, rnet, err := net.ParseCIDR(r)
+		if err != nil {
+			continue
+		}
+		for _, a := range p.AllowCIDRs {
+			_, anet, err := net.ParseCIDR(a)
+			if err != nil {
+				continue
+			}
+			rOnes, _ := rnet.Mask.Size()
+			aOnes, _ := anet.Mask.Size()
+			switch {
+			case anet.Contains(rnet.IP) && rOnes >= aOnes:
+				out.AllowCIDRs = append(out.AllowCIDRs, rnet.String())
+			case rnet.Contains(anet.IP) && aOnes > rOnes:
+				out.AllowCIDRs = append(out.AllowCIDRs, anet.String())
+			}
+		}
+	}
+	return out
+}
+
+// CommandRunner executes an external command (swapped out in tests)
+type CommandRunner func(ctx context.Context, name string, args ...string) error
+
+// execRunner runs commands on the host
+func execRunner(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %v: %w: %s", name, args, err, out)
+	}
+	return nil
+}
+
+// EgressFirewall applies egress policies with iptables. Each rental gets its
+// own chain, jumped to from DOCKER-USER (forwarded traffic) and INPUT
+// (traffic to the host) for packets entering from the rental's bridge.
+type EgressFirewall struct {
+	run CommandRunner
+}
+
+// NewEgressFirewall creates an iptables-backed firewall
+func NewEgressFirewall() *EgressFirewall {
+	return &EgressFirewall{run: execRunner}
+}
+
+// NewEgressFirewallWithRunner creates a firewall with a custom command runner (for testing)
+func NewEgressFirewallWithRunner(run CommandRunner) *EgressFirewall {
+	return &EgressFirewall{run: run}
+}
+
+// Apply installs the policy for traffic leaving the given bridge
+func (f *EgressFirewall) Apply(ctx context.Context, bridge, chain string, policy EgressPolicy) error {
+	// Clear leftovers from a previous run that crashed before cleanup
+	f.Remove(ctx, bridge, chain)
+
+	for _, args := range egressRules(bridge, chain, policy) {
+		if err := f.run(ctx, "iptables", args...); err != nil {
+			// Don't leave a half-applied policy behind
+			f.Remove(ctx, bridge, chain)
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove deletes the rental's chain and the jumps to it. Errors are ignored
+// so removal is idempotent.
+func (f *EgressFirewall) Remove(ctx context.Context, bridge, chain string) {
+	for _, args := range egressTeardown(bridge, chain) {
+		_ = f.run(ctx, "iptables", args...)
+	}
+}
+
+// egressRules builds the iptables invocations that install a policy
+func egressRules(bridge, chain string, p EgressPolicy) [][]string {
+	rules := [][]string{
+		{"-N", chain},
+		// Replies to inbound connections (e.g. SSH from a LAN client) are always allowed
+		{"-A", chain, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "RETURN"},
+	}
+	for _, cidr := range p.AllowCIDRs {
+		rules = append(rules, []string{"-A", chain, "-d", cidr, "-j", "RETURN"})
+	}
+	if p.BlockRentals {
+		rules = append(rules, []string{"-A", chain, "-o", rentalBridgePrefix + "+", "-j", "DROP"})
+	}
+
+	var deny []string
+	if p.BlockPrivate {
+		deny = append(deny, privateCIDRs...)
+	}
+	if p.BlockLinkLocal {
+		deny = append(deny, linkLocalCIDRs...)
+	}
+	deny = append(deny, p.DenyCIDRs...)
+	for _, cidr := range deny {
+		rules = append(rules, []string{"-A", chain, "-d", cidr, "-j", "DROP"})
+	}
+	rules = append(rules, []string{"-A", chain, "-j", "RETURN"})
+
+	rules = append(rules, []string{"-I", "DOCKER-USER", "-i", bridge, "-j", chain})
+	if p.BlockHost {
+		rules = append(rules, []string{"-I", "INPUT", "-i", bridge, "-m", "conntrack", "!", "--ctstate", "ESTABLISHED,RELATED", "-j", "DROP"})
+	}
+	return rules
+}
+
+// egressTeardown builds the iptables invocations that remove a policy
+func egressTeardown(bridge, chain string) [][]string {
+	return [][]string{
+		{"-D", "DOCKER-USER", "-i", bridge, "-j", chain},
+		{"-D", "INPUT", "-i", bridge, "-m", "conntrack", "!", "--ctstate", "ESTABLISHED,RELATED", "-j", "DROP"},
+		{"-F", chain},
+		{"-X", chain},
+	}
+}
+
+// rentalNetworkNames derives the Docker network, bridge interface and
+// iptables chain names for a session
+func rentalNetworkNames(sessionID string) (networkName, bridge, chain string) {
+	sum := sha256.Sum256([]byte(sessionID))
+	short := hex.EncodeToString(sum[:])[:10]
+	return rentalNetworkPrefix + sessionID, rentalBridgePrefix + short, rentalChainPrefix + short
+}
+
+// WithEgressFirewall enables egress policy enforcement on rental networks
+func (s *DockerService) WithEgressFirewall(fw *EgressFirewall) *DockerService {
+	s.firewall = fw
+	return s
+}
+
+// CreateRentalNetwork creates a dedicated bridge network for a rental and
+// applies its egress policy. Returns the network name to attach the container to.
+func (s *DockerService) CreateRentalNetwork(ctx context.Context, sessionID string, policy EgressPolicy) (string, error) {
+	if err := policy.Validate(); err != nil {
+		return "", err
+	}
+
+	name, bridge, chain := rentalNetworkNames(sessionID)
+	_, err := s.cli.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver: "bridge",
+		Options: map[string]string{
+			"com.docker.network.bridge.name": bridge,
+		},
+		Labels: map[string]string{
+			LabelSessionID: sessionID,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create rental network: %w", err)
+	}
+
+	if s.firewall != nil {
+		if err := s.firewall.Apply(ctx, bridge, chain, policy); err != nil {
+			_ = s.cli.NetworkRemove(ctx, name)
+			return "", fmt.Errorf("failed to apply egress policy: %w", err)
+		}
+	} else {
+		slog.Warn("rental network created without egress firewall", "session", sessionID)
+	}
+
+	return name, nil
+}
+
+// RemoveRentalNetwork removes a rental's egress rules and network.
+// The rental container must already be removed.
+func (s *DockerService) RemoveRentalNetwork(ctx context.Context, sessionID string) error {
+	name, bridge, chain := rentalNetworkNames(sessionID)
+	if s.firewall != nil {
+		s.firewall.Remove(ctx, bridge, chain)
+	}
+	if err := s.cli.NetworkRemove(ctx, name); err != nil {
+		return fmt.Errorf("failed to remove rental network: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner records iptables invocations instead of executing them
+type fakeRunner struct {
+	calls  []string
+	failOn string // Fail any command containing this substring
+}
+
+func (f *fakeRunner) run(ctx context.Context, name string, args ...string) error {
+	cmd := name + " " + strings.Join(args, " ")
+	f.calls = append(f.calls, cmd)
+	if f.failOn != "" && strings.Contains(cmd, f.failOn) {
+		return errors.New("iptables failed")
+	}
+	return nil
+}
+
+func (f *fakeRunner) contains(cmd string) bool {
+	for _, c := range f.calls {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCreateRentalNetwork_CreatesBridgeAndAppliesDefaultPolicy(t *testing.T) {
+	mock := &MockDockerClient{}
+	runner := &fakeRunner{}
+	svc := NewDockerServiceWithClient(mock).WithEgressFirewall(NewEgressFirewallWithRunner(runner.run))
+
+	name, err := svc.CreateRentalNetwork(context.Background(), "session-abc", DefaultEgressPolicy())
+
+	require.NoError(t, err)
+	assert.Equal(t, "wl-rental-session-abc", name)
+	assert.Equal(t, []string{"wl-rental-session-abc"}, mock.NetworkCreates)
+
+	_, bridge, chain := rentalNetworkNames("session-abc")
+	assert.LessOrEqual(t, len(bridge), 15, "bridge name must fit IFNAMSIZ")
+	assert.Equal(t, bridge, mock.LastNetworkOptions.Options["com.docker.network.bridge.name"])
+	assert.Equal(t, "session-abc", mock.LastNetworkOptions.Labels["worldland.session_id"])
+
+	assert.True(t, runner.contains("iptables -N "+chain))
+	assert.True(t, runner.contains("iptables -A "+chain+" -d 10.0.0.0/8 -j DROP"))
+	assert.True(t, runner.contains("iptables -A "+chain+" -d 192.168.0.0/16 -j DROP"))
+	assert.True(t, runner.contains("iptables -A "+chain+" -d 169.254.0.0/16 -j DROP"))
+	assert.True(t, runner.contains("iptables -A "+chain+" -o wlr-+ -j DROP"))
+	assert.True(t, runner.contains("iptables -I DOCKER-USER -i "+bridge+" -j "+chain))
+	assert.True(t, runner.contains("iptables -I INPUT -i "+bridge+" -m conntrack ! --ctstate ESTABLISHED,RELATED -j DROP"))
+}
+
+func TestCreateRentalNetwork_AllowOverridesComeBeforeBlocks(t *testing.T) {
+	mock := &MockDockerClient{}
+	runner := &fakeRunner{}
+	svc := NewDockerServiceWithClient(mock).WithEgressFirewall(NewEgressFirewallWithRunner(runner.run))
+
+	policy := DefaultEgressPolicy()
+	policy.AllowCIDRs = []string{"10.1.2.0/24"}
+	policy.BlockLinkLocal = false
+
+	_, err := svc.CreateRentalNetwork(context.Background(), "session-abc", policy)
+	require.NoError(t, err)
+
+	_, _, chain := rentalNetworkNames("session-abc")
+	allowIdx, blockIdx := -1, -1
+	for i, c := range runner.calls {
+		if c == "iptables -A "+chain+" -d 10.1.2.0/24 -j RETURN" {
+			allowIdx = i
+		}
+		if c == "iptables -A "+chain+" -d 10.0.0.0/8 -j DROP" {
+			blockIdx = i
+		}
+	}
+	require.NotEqual(t, -1, allowIdx)
+	require.NotEqual(t, -1, blockIdx)
+	assert.Less(t, allowIdx, blockIdx)
+	assert.False(t, runner.contains("iptables -A "+chain+" -d 169.254.0.0/16 -j DROP"))
+}
+
+func TestCreateRentalNetwork_RejectsInvalidCIDR(t *testing.T) {
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock)
+
+	policy := DefaultEgressPolicy()
+	policy.DenyCIDRs = []string{"not-a-cidr"}
+
+	_, err := svc.CreateRentalNetwork(context.Background(), "session-abc", policy)
+
+	require.Error(t, err)
+	assert.Empty(t, mock.NetworkCreates)
+}
+
+func TestEgressPolicy_NarrowNeverLoosensNodePolicy(t *testing.T) {
+	node := DefaultEgressPolicy()
+	node.AllowCIDRs = []string{"10.0.0.0/16", "172.20.1.0/24"}
+	node.DenyCIDRs = []string{"1.2.3.4/32"}
+
+	// Unblocking is ignored; blocks and denies add up
+	got := node.Narrow(EgressPolicy{DenyCIDRs: []string{"5.6.7.0/24"}})
+	assert.True(t, got.BlockPrivate && got.BlockLinkLocal && got.BlockRentals && got.BlockHost)
+	assert.Equal(t, node.AllowCIDRs, got.AllowCIDRs)
+	assert.Equal(t, []string{"1.2.3.4/32", "5.6.7.0/24"}, got.DenyCIDRs)
+
+	// Allow lists are intersected
+	got = node.Narrow(EgressPolicy{AllowCIDRs: []string{"10.0.5.0/24", "172.20.0.0/16", "192.168.0.0/16"}})
+	assert.Equal(t, []string{"10.0.5.0/24", "172.20.1.0/24"}, got.AllowCIDRs)
+
+	// An empty allow list drops the node's exceptions
+	got = node.Narrow(EgressPolicy{AllowCIDRs: []string{}})
+	assert.Empty(t, got.AllowCIDRs)
+
+	open := EgressPolicy{}
+	assert.True(t, open.Narrow(EgressPolicy{BlockHost: true}).BlockHost)
+}
+
+func TestCreateRentalNetwork_RemovesNetworkWhenFirewallFails(t *testing.T) {
+	mock := &MockDockerClient{}
+	runner := &fakeRunner{failOn: "DOCKER-USER -i"}
+	svc := NewDockerServiceWithClient(mock).WithEgressFirewall(NewEgressFirewallWithRunner(runner.run))
+
+	_, err := svc.CreateRentalNetwork(context.Background(), "session-abc", DefaultEgressPolicy())
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"wl-rental-session-abc"}, mock.NetworkRemoves)
+}
+
+func TestRemoveRentalNetwork_TearsDownRulesAndNetwork(t *testing.T) {
+	mock := &MockDockerClient{}
+	runner := &fakeRunner{}
+	svc := NewDockerServiceWithClient(mock).WithEgressFirewall(NewEgressFirewallWithRunner(runner.run))
+
+	err := svc.RemoveRentalNetwork(context.Background(), "session-abc")
+
+	require.NoError(t, err)
+	_, bridge, chain := rentalNetworkNames("session-abc")
+	assert.True(t, runner.contains("iptables -D DOCKER-USER -i "+bridge+" -j "+chain))
+	assert.True(t, runner.contains("iptables -X "+chain))
+	assert.Equal(t, []string{"wl-rental-session-abc"}, mock.NetworkRemoves)
+}
+
+func TestCreateContainer_AttachesRentalNetwork(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:   "session-abc",
+		Image:       "img",
+		NetworkName: "wl-rental-session-abc",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, container.NetworkMode("wl-rental-session-abc"), mock.LastHostConfig.NetworkMode)
+}
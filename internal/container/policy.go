@@ -0,0 +1,182 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+)
+
+// Image policy rejection codes, returned to the Hub and API clients
+const (
+	ImageCodeInvalid        = "IMAGE_INVALID"
+	ImageCodeDenied         = "IMAGE_DENIED"
+	ImageCodeNotAllowed     = "IMAGE_NOT_ALLOWED"
+	ImageCodeDigestRequired = "IMAGE_DIGEST_REQUIRED"
+	ImageCodeTooLarge       = "IMAGE_TOO_LARGE"
+	ImageCodeResolveFailed  = "IMAGE_RESOLVE_FAILED"
+	ImageCodeAuthRequired   = "IMAGE_AUTH_REQUIRED"
+)
+
+// ImagePolicyError reports an image rejected at admission
+type ImagePolicyError struct {
+	Code   string // One of the ImageCode* constants
+	Image  string
+	Reason string
+}
+
+func (e *ImagePolicyError) Error() string {
+	return fmt.Sprintf("image %s rejected: %s", e.Image, e.Reason)
+}
+
+// ImagePolicy is the operator's admission policy for rental images.
+//
+// Patterns match the normalized repository name without tag or digest, e.g.
+// "docker.io/library/ubuntu" or "ghcr.io/corp/trainer". They use path.Match
+// syntax; a trailing "/**" matches every repository under a prefix
+// ("ghcr.io/corp/**").
+type ImagePolicy struct {
+	Allow         []string // If set, only matching repositories are admitted
+	Deny          []string // Matching repositories are always rejected
+	RequireDigest []string // Matching repositories must be referenced by @sha256 digest
+	MaxSizeBytes  int64    // Reject images larger than this, checked before and after the pull (0 = unlimited)
+	ResolveDigest bool     // Pin tags to the registry's current digest before pulling
+}
+
+// Validate checks that all patterns parse
+func (p ImagePolicy) Validate() error {
+	for _, list := range [][]string{p.Allow, p.Deny, p.RequireDigest} {
+		for _, pattern := range list {
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+				return fmt.Errorf("invalid image pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchRepo reports whether repo matches any of the patterns
+func matchRepo(patterns []string, repo string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if strings.HasPrefix(repo, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WithImagePolicy enables admission checks in PrepareImage
+func (s *DockerService) WithImagePolicy(p ImagePolicy) *DockerService {
+	s.imagePolicy = &p
+	if p.MaxSizeBytes > 0 && s.manifests == nil {
+		s.manifests = registryManifests{client: &http.Client{Timeout: 30 * time.Second}, scheme: "https"}.fetch
+	}
+	return s
+}
+
+// PrepareImage admits a rental image under the operator's policy and makes
+// sure it is available locally. Returns the reference to run, which is pinned
+// to a digest when the policy resolves tags. Rejections are *ImagePolicyError.
+// onProgress, if set, receives pull progress.
+func (s *DockerService) PrepareImage(ctx context.Context, imageName string, auth *RegistryAuth, onProgress PullProgressFunc) (string, error) {
+	ref := imageName
+	p := s.imagePolicy
+	if p != nil {
+		named, err := reference.ParseNormalizedNamed(imageName)
+		if err != nil {
+			return "", &ImagePolicyError{Code: ImageCodeInvalid, Image: imageName, Reason: err.Error()}
+		}
+		named = reference.TagNameOnly(named)
+		repo := named.Name()
+
+		if matchRepo(p.Deny, repo) {
+			return "", &ImagePolicyError{Code: ImageCodeDenied, Image: imageName, Reason: "repository is denied by node policy"}
+		}
+		if len(p.Allow) > 0 && !matchRepo(p.Allow, repo) {
+			return "", &ImagePolicyError{Code: ImageCodeNotAllowed, Image: imageName, Reason: "repository is not on the node allowlist"}
+		}
+
+		_, pinned := named.(reference.Canonical)
+		if !pinned && matchRepo(p.RequireDigest, repo) {
+			return "", &ImagePolicyError{Code: ImageCodeDigestRequired, Image: imageName, Reason: "repository must be referenced by digest"}
+		}
+
+		if !pinned && p.ResolveDigest {
+			encodedAuth, err := s.registryAuth(ctx, imageName, auth)
+			if err != nil {
+				return "", &ImagePolicyError{Code: ImageCodeResolveFailed, Image: imageName, Reason: err.Error()}
+			}
+			dist, err := s.cli.DistributionInspect(ctx, named.String(), encodedAuth)
+			if err != nil {
+				return "", &ImagePolicyError{Code: ImageCodeResolveFailed, Image: imageName, Reason: err.Error()}
+			}
+			canonical, err := reference.WithDigest(reference.TrimNamed(named), dist.Descriptor.Digest)
+			if err != nil {
+				return "", &ImagePolicyError{Code: ImageCodeResolveFailed, Image: imageName, Reason: err.Error()}
+			}
+			ref = canonical.String()
+			slog.Info("resolved image tag to digest", "image", imageName, "ref", ref)
+		}
+	}
+
+	_, inspectErr := s.cli.ImageInspect(ctx, ref)
+	alreadyLocal := inspectErr == nil
+
+	if p != nil && p.MaxSizeBytes > 0 && !alreadyLocal && s.manifests != nil {
+		// The manifest sums compressed layers, so an image under the limit
+		// here can still fail the exact check after the pull below
+		size, err := s.remoteImageSize(ctx, ref, auth)
+		if err != nil {
+			slog.Warn("failed to check image size before pull", "image", ref, "error", err)
+		} else if size > p.MaxSizeBytes {
+			return "", &ImagePolicyError{
+				Code:   ImageCodeTooLarge,
+				Image:  imageName,
+				Reason: fmt.Sprintf("image download size %d bytes exceeds limit of %d bytes", size, p.MaxSizeBytes),
+			}
+		}
+	}
+
+	if err := s.ensureImage(ctx, ref, auth, onProgress, true); err != nil {
+		return "", fmt.Errorf("failed to ensure image: %w", err)
+	}
+
+	if p != nil && p.MaxSizeBytes > 0 {
+		info, err := s.cli.ImageInspect(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to inspect image %s: %w", ref, err)
+		}
+		if info.Size > p.MaxSizeBytes {
+			if !alreadyLocal {
+				// Don't keep the oversized pull around
+				if _, err := s.cli.ImageRemove(ctx, ref, image.RemoveOptions{PruneChildren: true}); err != nil {
+					slog.Warn("failed to remove rejected image", "image", ref, "error", err)
+				} else if s.imageCache != nil {
+					s.imageCache.changed()
+				}
+			}
+			return "", &ImagePolicyError{
+				Code:   ImageCodeTooLarge,
+				Image:  imageName,
+				Reason: fmt.Sprintf("image size %d bytes exceeds limit of %d bytes", info.Size, p.MaxSizeBytes),
+			}
+		}
+	}
+
+	if s.imageCache != nil {
+		s.imageCache.Touch(ref)
+	}
+	return ref, nil
+}
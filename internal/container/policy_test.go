@@ -0,0 +1,116 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func requirePolicyCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var policyErr *ImagePolicyError
+	require.True(t, errors.As(err, &policyErr), "expected ImagePolicyError, got %v", err)
+	assert.Equal(t, code, policyErr.Code)
+}
+
+func TestPrepareImage_NoPolicyPullsAsIs(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true}
+	svc := NewDockerServiceWithClient(mock)
+
+	ref, err := svc.PrepareImage(context.Background(), "ubuntu:22.04", nil, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ubuntu:22.04", ref)
+	assert.Equal(t, []string{"ubuntu:22.04"}, mock.PullCalls)
+}
+
+func TestPrepareImage_AllowAndDenyPatterns(t *testing.T) {
+	svc := NewDockerServiceWithClient(&MockDockerClient{}).WithImagePolicy(ImagePolicy{
+		Allow: []string{"docker.io/nvidia/*", "ghcr.io/corp/**"},
+		Deny:  []string{"ghcr.io/corp/legacy/**"},
+	})
+
+	_, err := svc.PrepareImage(context.Background(), "nvidia/cuda:12.1.1-runtime-ubuntu22.04", nil, nil)
+	assert.NoError(t, err)
+
+	_, err = svc.PrepareImage(context.Background(), "ghcr.io/corp/ml/trainer:v2", nil, nil)
+	assert.NoError(t, err)
+
+	_, err = svc.PrepareImage(context.Background(), "ubuntu:22.04", nil, nil)
+	requirePolicyCode(t, err, ImageCodeNotAllowed)
+
+	_, err = svc.PrepareImage(context.Background(), "ghcr.io/corp/legacy/app:1", nil, nil)
+	requirePolicyCode(t, err, ImageCodeDenied)
+
+	_, err = svc.PrepareImage(context.Background(), "Not A Valid Ref", nil, nil)
+	requirePolicyCode(t, err, ImageCodeInvalid)
+}
+
+func TestPrepareImage_RequiresDigestForPinnedRepos(t *testing.T) {
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock).WithImagePolicy(ImagePolicy{
+		RequireDigest: []string{"ghcr.io/corp/**"},
+	})
+
+	_, err := svc.PrepareImage(context.Background(), "ghcr.io/corp/trainer:latest", nil, nil)
+	requirePolicyCode(t, err, ImageCodeDigestRequired)
+
+	ref, err := svc.PrepareImage(context.Background(), "ghcr.io/corp/trainer@"+testDigest, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/corp/trainer@"+testDigest, ref)
+}
+
+func TestPrepareImage_ResolvesTagToDigest(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, DistributionDigest: testDigest}
+	svc := NewDockerServiceWithClient(mock).WithImagePolicy(ImagePolicy{ResolveDigest: true})
+
+	ref, err := svc.PrepareImage(context.Background(), "pytorch/pytorch", nil, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker.io/pytorch/pytorch:latest"}, mock.DistributionCalls)
+	assert.Equal(t, "docker.io/pytorch/pytorch@"+testDigest, ref)
+	assert.Equal(t, []string{ref}, mock.PullCalls)
+}
+
+func TestPrepareImage_ResolveFailure(t *testing.T) {
+	mock := &MockDockerClient{DistributionError: errors.New("unauthorized")}
+	svc := NewDockerServiceWithClient(mock).WithImagePolicy(ImagePolicy{ResolveDigest: true})
+
+	_, err := svc.PrepareImage(context.Background(), "ghcr.io/corp/private:1", nil, nil)
+
+	requirePolicyCode(t, err, ImageCodeResolveFailed)
+}
+
+func TestPrepareImage_RejectsOversizedImageAndRemovesPull(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, ImageSize: 20 << 30, DistributionDigest: testDigest}
+	svc := NewDockerServiceWithClient(mock).
+		WithManifestFetcher(unavailableManifests).
+		WithImagePolicy(ImagePolicy{MaxSizeBytes: 10 << 30})
+
+	_, err := svc.PrepareImage(context.Background(), "huge/model:1", nil, nil)
+
+	requirePolicyCode(t, err, ImageCodeTooLarge)
+	assert.Equal(t, []string{"huge/model:1"}, mock.ImageRemoves)
+}
+
+func TestPrepareImage_OversizedLocalImageIsKept(t *testing.T) {
+	mock := &MockDockerClient{ImageSize: 20 << 30}
+	svc := NewDockerServiceWithClient(mock).
+		WithManifestFetcher(unavailableManifests).
+		WithImagePolicy(ImagePolicy{MaxSizeBytes: 10 << 30})
+
+	_, err := svc.PrepareImage(context.Background(), "huge/model:1", nil, nil)
+
+	requirePolicyCode(t, err, ImageCodeTooLarge)
+	assert.Empty(t, mock.ImageRemoves)
+}
+
+func TestImagePolicy_ValidateRejectsBadPattern(t *testing.T) {
+	assert.Error(t, ImagePolicy{Allow: []string{"docker.io/[abc"}}.Validate())
+	assert.NoError(t, ImagePolicy{Allow: []string{"ghcr.io/corp/**"}}.Validate())
+}
@@ -0,0 +1,135 @@
+package container
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// pullProgressInterval throttles progress callbacks during a pull
+const pullProgressInterval = 2 * time.Second
+
+// LayerProgress is the download state of one image layer
+type LayerProgress struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"` // Docker's status text, e.g. "Downloading", "Pull complete"
+	CurrentBytes int64  `json:"currentBytes"`
+	TotalBytes   int64  `json:"totalBytes"` // 0 until the download starts
+}
+
+// PullProgress summarizes an image pull
+type PullProgress struct {
+	Image        string          `json:"image"`
+	Status       string          `json:"status"` // Last status line from the registry
+	CurrentBytes int64           `json:"currentBytes"`
+	TotalBytes   int64           `json:"totalBytes"` // Grows as layer sizes become known
+	LayersDone   int             `json:"layersDone"`
+	LayersTotal  int             `json:"layersTotal"`
+	Layers       []LayerProgress `json:"layers,omitempty"`
+	Done         bool            `json:"done"`
+}
+
+// PullProgressFunc receives throttled pull progress updates
+type PullProgressFunc func(PullProgress)
+
+// Layer statuses that mark the layer's bytes as fully downloaded
+var layerDownloaded = map[string]bool{
+	"Verifying Checksum": true,
+	"Download complete":  true,
+	"Extracting":         true,
+	"Pull complete":      true,
+	"Already exists":     true,
+}
+
+// Layer statuses that mark the layer as finished
+var layerFinished = map[string]bool{
+	"Pull complete":  true,
+	"Already exists": true,
+}
+
+// Statuses that only appear for layers (other messages carry the tag as ID)
+var layerStatuses = map[string]bool{
+	"Pulling fs layer": true,
+	"Waiting":          true,
+	"Downloading":      true,
+	"Retrying":         true,
+}
+
+// readPullProgress consumes an ImagePull JSON stream, reporting progress to
+// onProgress at most once per interval plus once when the pull completes.
+// Returns the error embedded in the stream, if any.
+func readPullProgress(r io.Reader, imageName string, interval time.Duration, onProgress PullProgressFunc) error {
+	dec := json.NewDecoder(r)
+	p := PullProgress{Image: imageName}
+	index := make(map[string]int)
+	var lastReport time.Time
+
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if msg.Status != "" {
+			p.Status = msg.Status
+		}
+		if msg.ID != "" && (layerStatuses[msg.Status] || layerDownloaded[msg.Status]) {
+			i, ok := index[msg.ID]
+			if !ok {
+				i = len(p.Layers)
+				index[msg.ID] = i
+				p.Layers = append(p.Layers, LayerProgress{ID: msg.ID})
+			}
+			layer := &p.Layers[i]
+			layer.Status = msg.Status
+			if msg.Status == "Downloading" && msg.Progress != nil {
+				layer.CurrentBytes = msg.Progress.Current
+				if msg.Progress.Total > 0 {
+					layer.TotalBytes = msg.Progress.Total
+				}
+			}
+			if layerDownloaded[msg.Status] {
+				layer.CurrentBytes = layer.TotalBytes
+			}
+			p.recount()
+		}
+
+		if onProgress != nil && time.Since(lastReport) >= interval {
+			lastReport = time.Now()
+			onProgress(p.Snapshot())
+		}
+	}
+
+	p.Done = true
+	if onProgress != nil {
+		onProgress(p.Snapshot())
+	}
+	return nil
+}
+
+// recount recomputes the totals from the layers
+func (p *PullProgress) recount() {
+	p.CurrentBytes, p.TotalBytes, p.LayersDone = 0, 0, 0
+	for _, l := range p.Layers {
+		p.CurrentBytes += l.CurrentBytes
+		p.TotalBytes += l.TotalBytes
+		if layerFinished[l.Status] {
+			p.LayersDone++
+		}
+	}
+	p.LayersTotal = len(p.Layers)
+}
+
+// Snapshot returns a copy that doesn't share the layer slice
+func (p PullProgress) Snapshot() PullProgress {
+	p.Layers = append([]LayerProgress(nil), p.Layers...)
+	return p
+}
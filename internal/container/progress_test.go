@@ -0,0 +1,76 @@
+package container
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pullStream = `{"status":"Pulling from pytorch/pytorch","id":"2.1"}
+{"status":"Already exists","progressDetail":{},"id":"aaa"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"bbb"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"ccc"}
+{"status":"Downloading","progressDetail":{"current":100,"total":1000},"id":"bbb"}
+{"status":"Downloading","progressDetail":{"current":50,"total":500},"id":"ccc"}
+{"status":"Downloading","progressDetail":{"current":600,"total":1000},"id":"bbb"}
+{"status":"Download complete","progressDetail":{},"id":"ccc"}
+{"status":"Extracting","progressDetail":{"current":200,"total":500},"id":"ccc"}
+{"status":"Pull complete","progressDetail":{},"id":"ccc"}
+`
+
+func TestReadPullProgress_TracksLayersAndTotals(t *testing.T) {
+	var updates []PullProgress
+	err := readPullProgress(strings.NewReader(pullStream), "pytorch/pytorch:2.1", 0, func(p PullProgress) {
+		updates = append(updates, p)
+	})
+	require.NoError(t, err)
+
+	final := updates[len(updates)-1]
+	assert.True(t, final.Done)
+	assert.Equal(t, 3, final.LayersTotal)
+	assert.Equal(t, 2, final.LayersDone)
+	assert.Equal(t, int64(1500), final.TotalBytes)
+	assert.Equal(t, int64(1100), final.CurrentBytes) // bbb 600/1000 + ccc 500/500
+	assert.Equal(t, "bbb", final.Layers[1].ID)
+	assert.Equal(t, "Downloading", final.Layers[1].Status)
+
+	// Snapshots don't share layer state
+	assert.Equal(t, int64(100), updates[4].Layers[1].CurrentBytes)
+}
+
+func TestReadPullProgress_Throttles(t *testing.T) {
+	calls := 0
+	err := readPullProgress(strings.NewReader(pullStream), "img", pullProgressInterval, func(p PullProgress) {
+		calls++
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls) // first message + completion
+}
+
+func TestReadPullProgress_ReturnsStreamError(t *testing.T) {
+	stream := `{"status":"Pulling fs layer","id":"aaa"}
+{"errorDetail":{"message":"unauthorized: authentication required"},"error":"unauthorized: authentication required"}
+`
+	err := readPullProgress(strings.NewReader(stream), "img", 0, nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthorized")
+}
+
+func TestPrepareImage_ReportsPullProgress(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, PullStream: pullStream}
+	svc := NewDockerServiceWithClient(mock)
+
+	var last PullProgress
+	_, err := svc.PrepareImage(context.Background(), "pytorch/pytorch:2.1", nil, func(p PullProgress) {
+		last = p
+	})
+
+	require.NoError(t, err)
+	assert.True(t, last.Done)
+	assert.Equal(t, "pytorch/pytorch:2.1", last.Image)
+}
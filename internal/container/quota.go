@@ -0,0 +1,118 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// quotaDrivers are storage drivers that accept --storage-opt size=N.
+// overlay2 only supports it on an xfs backing filesystem mounted with pquota,
+// which can't be detected from the daemon info alone, so CreateContainer
+// retries without the option if the daemon rejects it.
+var quotaDrivers = map[string]bool{
+	"devicemapper":  true,
+	"btrfs":         true,
+	"zfs":           true,
+	"windowsfilter": true,
+}
+
+// SupportsDiskQuota reports whether the Docker storage driver can enforce
+// per-container writable layer size limits. The result is cached.
+func (s *DockerService) SupportsDiskQuota(ctx context.Context) bool {
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+
+	if s.quotaChecked {
+		return s.quotaSupported
+	}
+
+	info, err := s.cli.Info(ctx)
+	if err != nil {
+		// Don't cache failures; the next rental will ask again
+		slog.Warn("failed to query docker info for storage driver", "error", err)
+		return false
+	}
+	s.quotaChecked = true
+	s.quotaSupported = storageDriverSupportsQuota(info.Driver, info.DriverStatus)
+	slog.Info("storage driver quota support detected",
+		"driver", info.Driver, "supported", s.quotaSupported)
+	return s.quotaSupported
+}
+
+// disableDiskQuota marks storage-opt quotas as unsupported after the daemon rejected one
+func (s *DockerService) disableDiskQuota() {
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	s.quotaChecked = true
+	s.quotaSupported = false
+}
+
+// storageDriverSupportsQuota decides quota support from the driver name and status
+func storageDriverSupportsQuota(driver string, status [][2]string) bool {
+	if quotaDrivers[driver] {
+		return true
+	}
+	if driver == "overlay2" {
+		for _, kv := range status {
+			if kv[0] == "Backing Filesystem" && kv[1] == "xfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isStorageOptError returns true if the daemon rejected the storage-opt size option
+func isStorageOptError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "storage-opt") || strings.Contains(msg, "storage opt")
+}
+
+// DiskUsage returns the size of the container's writable layer plus its
+// volumes (the workspace and any writable paths over a read-only root) in
+// bytes. This asks the daemon to compute the sizes, which walks the
+// filesystem, so callers should poll it sparingly.
+func (s *DockerService) DiskUsage(ctx context.Context, containerID string) (int64, error) {
+	inspect, _, err := s.cli.ContainerInspectWithRaw(ctx, containerID, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect container size: %w", err)
+	}
+	var size int64
+	if inspect.SizeRw != nil {
+		size = *inspect.SizeRw
+	}
+	volumes := make(map[string]bool)
+	for _, m := range inspect.Mounts {
+		if m.Type == mount.TypeVolume {
+			volumes[m.Name] = true
+		}
+	}
+	if len(volumes) > 0 {
+		vs, err := s.volumeUsage(ctx, volumes)
+		if err != nil {
+			return 0, err
+		}
+		size += vs
+	}
+	return size, nil
+}
+
+// scratchTmpfs converts scratch mounts to the HostConfig.Tmpfs map
+func scratchTmpfs(mounts []ScratchMount) map[string]string {
+	if len(mounts) == 0 {
+		return nil
+	}
+	tmpfs := make(map[string]string, len(mounts))
+	for _, m := range mounts {
+		opts := "rw,nosuid,nodev"
+		if m.SizeBytes > 0 {
+			opts = fmt.Sprintf("%s,size=%d", opts, m.SizeBytes)
+		}
+		tmpfs[m.Path] = opts
+	}
+	return tmpfs
+}
@@ -0,0 +1,181 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// RegistryAuth holds renter-supplied credentials for pulling a private image.
+// It is passed through to the pull and never stored or logged.
+type RegistryAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identityToken,omitempty"` // OAuth refresh token instead of a password
+	ServerAddress string `json:"serverAddress,omitempty"` // Defaults to the image's registry
+}
+
+// String implements fmt.Stringer with secrets redacted
+func (a RegistryAuth) String() string {
+	return fmt.Sprintf("{username=%s server=%s secret=%s}", a.Username, a.ServerAddress, redacted)
+}
+
+// LogValue implements slog.LogValuer with secrets redacted
+func (a RegistryAuth) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("username", a.Username),
+		slog.String("server", a.ServerAddress),
+		slog.String("secret", redacted),
+	)
+}
+
+// CredentialHelperFunc fetches credentials for a registry from a Docker
+// credential helper (swapped out in tests)
+type CredentialHelperFunc func(ctx context.Context, helper, serverURL string) (RegistryAuth, error)
+
+// execCredentialHelper runs docker-credential-<helper> get, the protocol used
+// by docker login's credHelpers (ecr-login, gcloud, pass, ...)
+func execCredentialHelper(ctx context.Context, helper, serverURL string) (RegistryAuth, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// stdout may hold a partial secret; only report stderr
+		return RegistryAuth{}, fmt.Errorf("credential helper %s: %w: %s", helper, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var creds struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return RegistryAuth{}, fmt.Errorf("credential helper %s returned invalid output", helper)
+	}
+
+	auth := RegistryAuth{ServerAddress: serverURL}
+	if creds.Username == "<token>" {
+		auth.IdentityToken = creds.Secret
+	} else {
+		auth.Username = creds.Username
+		auth.Password = creds.Secret
+	}
+	return auth, nil
+}
+
+// WithCredentialHelpers configures Docker credential helpers for the
+// operator's own registries, keyed by registry host (e.g.
+// "123456789.dkr.ecr.us-east-1.amazonaws.com" -> "ecr-login"). They are used
+// when a rental image comes from one of these registries and the request
+// carries no credentials.
+func (s *DockerService) WithCredentialHelpers(helpers map[string]string) *DockerService {
+	s.credHelpers = helpers
+	if s.credHelper == nil {
+		s.credHelper = execCredentialHelper
+	}
+	return s
+}
+
+// WithCredentialHelperFunc replaces the credential helper runner (for testing)
+func (s *DockerService) WithCredentialHelperFunc(fn CredentialHelperFunc) *DockerService {
+	s.credHelper = fn
+	return s
+}
+
+// resolveAuth returns the credentials for pulling imageName: the renter's,
+// else the operator's credential helper for its registry, else nil
+func (s *DockerService) resolveAuth(ctx context.Context, imageName string, auth *RegistryAuth) (*RegistryAuth, error) {
+	if auth != nil {
+		return auth, nil
+	}
+	host := registryHost(imageName)
+	helper, ok := s.credHelpers[host]
+	if !ok || s.credHelper == nil {
+		return nil, nil
+	}
+	creds, err := s.credHelper(ctx, helper, host)
+	if err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// registryAuth returns the encoded X-Registry-Auth value for pulling
+// imageName, or "" for anonymous pulls
+func (s *DockerService) registryAuth(ctx context.Context, imageName string, auth *RegistryAuth) (string, error) {
+	auth, err := s.resolveAuth(ctx, imageName, auth)
+	if err != nil || auth == nil {
+		return "", err
+	}
+
+	server := auth.ServerAddress
+	if server == "" {
+		server = registryHost(imageName)
+	}
+	return registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		IdentityToken: auth.IdentityToken,
+		ServerAddress: server,
+	})
+}
+
+// recordPull remembers whether the image with this ID was pulled with
+// registry credentials. Kept in memory only (see checkImageAccess).
+func (s *DockerService) recordPull(id string, private bool) {
+	s.pullsMu.Lock()
+	defer s.pullsMu.Unlock()
+	if s.privatePull == nil {
+		s.privatePull = make(map[string]bool)
+	}
+	s.privatePull[id] = private
+}
+
+// checkImageAccess keeps one renter's private image from being run by
+// another out of the local cache. An image this node pulled with credentials
+// is only reused once the registry accepts the caller's credentials (or the
+// operator's credential helper) for it. Images of unknown origin, such as
+// those pulled before a node restart, are checked the same way; an anonymous
+// success marks them public so later uses skip the registry.
+func (s *DockerService) checkImageAccess(ctx context.Context, imageName, id string, auth *RegistryAuth) error {
+	s.pullsMu.Lock()
+	private, known := s.privatePull[id]
+	s.pullsMu.Unlock()
+	if known && !private {
+		return nil
+	}
+
+	encodedAuth, err := s.registryAuth(ctx, imageName, auth)
+	if err != nil {
+		return &ImagePolicyError{Code: ImageCodeAuthRequired, Image: imageName, Reason: err.Error()}
+	}
+	if _, err := s.cli.DistributionInspect(ctx, imageName, encodedAuth); err != nil {
+		return &ImagePolicyError{
+			Code:   ImageCodeAuthRequired,
+			Image:  imageName,
+			Reason: fmt.Sprintf("registry refused access to the cached image: %v", err),
+		}
+	}
+	if !known {
+		s.recordPull(id, encodedAuth != "")
+	}
+	return nil
+}
+
+// registryHost returns the registry domain of an image reference
+// ("docker.io" for Docker Hub images)
+func registryHost(imageName string) string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
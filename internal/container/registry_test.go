@@ -0,0 +1,150 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAuth(t *testing.T, encoded string) registry.AuthConfig {
+	cfg, err := registry.DecodeAuthConfig(encoded)
+	require.NoError(t, err)
+	return *cfg
+}
+
+func TestCreateContainer_PullsWithRenterCredentials(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "container-123"},
+		ImageMissing:   true,
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID: "session-abc",
+		Image:     "registry.corp.example.com/ml/train:1.0",
+		RegistryAuth: &RegistryAuth{
+			Username: "renter",
+			Password: "s3cret",
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry.corp.example.com/ml/train:1.0"}, mock.PullCalls)
+	auth := decodeAuth(t, mock.LastPullOptions.RegistryAuth)
+	assert.Equal(t, "renter", auth.Username)
+	assert.Equal(t, "s3cret", auth.Password)
+	assert.Equal(t, "registry.corp.example.com", auth.ServerAddress)
+}
+
+func TestCreateContainer_PullsPublicImageAnonymously(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "container-123"},
+		ImageMissing:   true,
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "session-abc", Image: "ubuntu:22.04"})
+
+	require.NoError(t, err)
+	assert.Empty(t, mock.LastPullOptions.RegistryAuth)
+}
+
+func TestCreateContainer_UsesOperatorCredentialHelper(t *testing.T) {
+	mock := &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "container-123"},
+		ImageMissing:   true,
+	}
+	var helperCalls []string
+	svc := NewDockerServiceWithClient(mock).
+		WithCredentialHelpers(map[string]string{"ecr.example.com": "ecr-login"}).
+		WithCredentialHelperFunc(func(ctx context.Context, helper, serverURL string) (RegistryAuth, error) {
+			helperCalls = append(helperCalls, helper+"@"+serverURL)
+			return RegistryAuth{ServerAddress: serverURL, IdentityToken: "tok"}, nil
+		})
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "a", Image: "ecr.example.com/node/base:1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ecr-login@ecr.example.com"}, helperCalls)
+	assert.Equal(t, "tok", decodeAuth(t, mock.LastPullOptions.RegistryAuth).IdentityToken)
+
+	// Other registries are pulled anonymously
+	_, err = svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "b", Image: "ubuntu:22.04"})
+	require.NoError(t, err)
+	assert.Len(t, helperCalls, 1)
+	assert.Empty(t, mock.LastPullOptions.RegistryAuth)
+}
+
+func TestCreateContainer_CredentialHelperFailureFailsCreate(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true}
+	svc := NewDockerServiceWithClient(mock).
+		WithCredentialHelpers(map[string]string{"ecr.example.com": "ecr-login"}).
+		WithCredentialHelperFunc(func(ctx context.Context, helper, serverURL string) (RegistryAuth, error) {
+			return RegistryAuth{}, errors.New("no credentials")
+		})
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "a", Image: "ecr.example.com/node/base:1"})
+
+	require.Error(t, err)
+	assert.Empty(t, mock.PullCalls)
+	assert.Equal(t, 0, mock.CreateCalled)
+}
+
+func TestRegistryAuth_RedactsSecrets(t *testing.T) {
+	auth := RegistryAuth{Username: "renter", Password: "s3cret", IdentityToken: "tok"}
+
+	assert.NotContains(t, fmt.Sprintf("%v", auth), "s3cret")
+	assert.NotContains(t, fmt.Sprintf("%+v", &auth), "tok")
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("pull", "auth", auth)
+	assert.Contains(t, buf.String(), "renter")
+	assert.NotContains(t, buf.String(), "s3cret")
+}
+
+func TestPrepareImage_RefusesCachedPrivateImageWithoutAuth(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, ImageID: "sha256:private", PrivateRegistry: true}
+	svc := NewDockerServiceWithClient(mock)
+	ctx := context.Background()
+	image := "registry.corp.example.com/ml/train:1.0"
+
+	_, err := svc.PrepareImage(ctx, image, &RegistryAuth{Username: "renter-a", Password: "s3cret"}, nil)
+	require.NoError(t, err)
+
+	_, err = svc.PrepareImage(ctx, image, nil, nil)
+	requirePolicyCode(t, err, ImageCodeAuthRequired)
+
+	_, err = svc.PrepareImage(ctx, image, &RegistryAuth{Username: "renter-b", Password: "other"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "renter-b", decodeAuth(t, mock.DistributionAuth[len(mock.DistributionAuth)-1]).Username)
+	assert.Len(t, mock.PullCalls, 1, "cached copy is reused once access is granted")
+}
+
+func TestPrepareImage_ReusesCachedPublicImageWithoutRegistry(t *testing.T) {
+	mock := &MockDockerClient{ImageMissing: true, ImageID: "sha256:public"}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.PrepareImage(context.Background(), "ubuntu:22.04", nil, nil)
+	require.NoError(t, err)
+	_, err = svc.PrepareImage(context.Background(), "ubuntu:22.04", nil, nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, mock.DistributionCalls)
+}
+
+func TestPrepareImage_ChecksCachedImageOfUnknownOrigin(t *testing.T) {
+	mock := &MockDockerClient{ImageID: "sha256:before-restart", PrivateRegistry: true}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.PrepareImage(context.Background(), "registry.corp.example.com/ml/train:1.0", nil, nil)
+
+	requirePolicyCode(t, err, ImageCodeAuthRequired)
+	assert.Empty(t, mock.PullCalls)
+}
@@ -0,0 +1,122 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+var ErrInvalidRuntime = errors.New("invalid container runtime")
+
+// GPUAttach selects how a container's GPUs are handed to it
+type GPUAttach string
+
+const (
+	// GPUAttachEnv sets NVIDIA_VISIBLE_DEVICES for the nvidia runtime's hook
+	GPUAttachEnv GPUAttach = "env"
+	// GPUAttachDeviceRequest asks dockerd for the GPUs like `docker run --gpus`,
+	// which works with runc and with sandboxed runtimes that support it (gVisor's nvproxy)
+	GPUAttachDeviceRequest GPUAttach = "device-request"
+	// GPUAttachCDI requests the GPUs by their Container Device Interface name
+	// ("nvidia.com/gpu=0") from the host's CDI specs (see cdi.go)
+	GPUAttachCDI GPUAttach = "cdi"
+)
+
+// Runtime is the OCI runtime a container runs under and how its GPUs are
+// attached. The zero value is DefaultRuntime.
+type Runtime struct {
+	Name string    // Runtime registered with dockerd, e.g. "nvidia", "runc", "runsc", "kata"
+	GPUs GPUAttach // Empty = env for the nvidia runtime, device-request otherwise
+}
+
+// DefaultRuntime is the nvidia runtime with GPUs selected by env
+var DefaultRuntime = Runtime{Name: "nvidia", GPUs: GPUAttachEnv}
+
+// ParseRuntime parses "name[:attach]", e.g. "runsc:device-request"
+func ParseRuntime(s string) (Runtime, error) {
+	name, attach, _ := strings.Cut(strings.TrimSpace(s), ":")
+	if name == "" {
+		return Runtime{}, fmt.Errorf("%w: %q", ErrInvalidRuntime, s)
+	}
+	r := Runtime{Name: name, GPUs: GPUAttach(attach)}.withDefaults()
+	switch r.GPUs {
+	case GPUAttachEnv, GPUAttachDeviceRequest, GPUAttachCDI:
+		return r, nil
+	default:
+		return Runtime{}, fmt.Errorf("%w: unknown gpu attach mode %q", ErrInvalidRuntime, attach)
+	}
+}
+
+// String formats the runtime as accepted by ParseRuntime
+func (r Runtime) String() string {
+	r = r.withDefaults()
+	return r.Name + ":" + string(r.GPUs)
+}
+
+// withDefaults fills in the runtime name and GPU attach mode
+func (r Runtime) withDefaults() Runtime {
+	if r.Name == "" {
+		return DefaultRuntime
+	}
+	if r.GPUs == "" {
+		r.GPUs = GPUAttachDeviceRequest
+		if r.Name == DefaultRuntime.Name {
+			r.GPUs = GPUAttachEnv
+		}
+	}
+	return r
+}
+
+// gpuEnv returns the env selecting the container's GPUs (see gpuDevices) in
+// env mode. Without devices the runtime exposes none.
+func (r Runtime) gpuEnv(devices []string) []string {
+	env := []string{"NVIDIA_DRIVER_CAPABILITIES=all"}
+	if r.GPUs != GPUAttachEnv {
+		return env
+	}
+	visible := "none"
+	if len(devices) > 0 {
+		visible = strings.Join(devices, ",")
+	}
+	return append([]string{fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", visible)}, env...)
+}
+
+// deviceRequests returns the GPU device requests in device-request and CDI
+// mode. In CDI mode devices are CDI names (see cdiDevices).
+func (r Runtime) deviceRequests(devices []string) []container.DeviceRequest {
+	if len(devices) == 0 {
+		return nil
+	}
+	switch r.GPUs {
+	case GPUAttachCDI:
+		return []container.DeviceRequest{{Driver: "cdi", DeviceIDs: devices}}
+	case GPUAttachDeviceRequest:
+		req := container.DeviceRequest{Driver: "nvidia", Capabilities: [][]string{{"gpu"}}}
+		if len(devices) == 1 && devices[0] == "all" {
+			req.Count = -1
+		} else {
+			req.DeviceIDs = devices
+		}
+		return []container.DeviceRequest{req}
+	default:
+		return nil
+	}
+}
+
+// Runtimes returns the OCI runtimes the Docker daemon advertises, sorted
+func (s *DockerService) Runtimes(ctx context.Context) ([]string, error) {
+	info, err := s.cli.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query docker info: %w", err)
+	}
+	names := make([]string, 0, len(info.Runtimes))
+	for name := range info.Runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
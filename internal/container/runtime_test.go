@@ -0,0 +1,102 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuntime(t *testing.T) {
+	tests := map[string]Runtime{
+		"nvidia":                {Name: "nvidia", GPUs: GPUAttachEnv},
+		"runc":                  {Name: "runc", GPUs: GPUAttachDeviceRequest},
+		"runsc":                 {Name: "runsc", GPUs: GPUAttachDeviceRequest},
+		"nvidia:device-request": {Name: "nvidia", GPUs: GPUAttachDeviceRequest},
+		"kata:env":              {Name: "kata", GPUs: GPUAttachEnv},
+		"runc:cdi":              {Name: "runc", GPUs: GPUAttachCDI},
+	}
+	for in, want := range tests {
+		got, err := ParseRuntime(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, bad := range []string{"", ":env", "runc:vfio"} {
+		_, err := ParseRuntime(bad)
+		assert.ErrorIs(t, err, ErrInvalidRuntime, bad)
+	}
+	assert.Equal(t, "nvidia:env", Runtime{}.String())
+}
+
+func TestCreateContainer_DeviceRequestRuntime(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:   "session-abc",
+		Image:       "img",
+		GPUDeviceID: "GPU-aaa,GPU-bbb",
+		SSHPort:     30001,
+		Runtime:     Runtime{Name: "runsc"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "runsc", mock.LastHostConfig.Runtime)
+	assert.Equal(t, []container.DeviceRequest{{
+		Driver:       "nvidia",
+		DeviceIDs:    []string{"GPU-aaa", "GPU-bbb"},
+		Capabilities: [][]string{{"gpu"}},
+	}}, mock.LastHostConfig.DeviceRequests)
+	for _, env := range mock.LastCreateConfig.Env {
+		assert.NotContains(t, env, "NVIDIA_VISIBLE_DEVICES")
+	}
+	assert.Contains(t, mock.LastCreateConfig.Env, "NVIDIA_DRIVER_CAPABILITIES=all")
+}
+
+func TestCreateContainer_DeviceRequestAllGPUs(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:          "mining",
+		Image:              "img",
+		GPUDeviceID:        "all",
+		UseImageEntrypoint: true,
+		Runtime:            Runtime{Name: "runc"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, mock.LastHostConfig.DeviceRequests, 1)
+	assert.Equal(t, -1, mock.LastHostConfig.DeviceRequests[0].Count)
+	assert.Empty(t, mock.LastHostConfig.DeviceRequests[0].DeviceIDs)
+}
+
+func TestCreateContainer_DefaultRuntimeIsNvidia(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "session-abc", Image: "img", GPUDeviceID: "1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "nvidia", mock.LastHostConfig.Runtime)
+	assert.Nil(t, mock.LastHostConfig.DeviceRequests)
+	assert.Contains(t, mock.LastCreateConfig.Env, "NVIDIA_VISIBLE_DEVICES=1")
+}
+
+func TestRuntimes(t *testing.T) {
+	mock := &MockDockerClient{InfoResponse: system.Info{Runtimes: map[string]system.RuntimeWithStatus{
+		"runc":   {},
+		"nvidia": {},
+		"runsc":  {},
+	}}}
+	svc := NewDockerServiceWithClient(mock)
+
+	runtimes, err := svc.Runtimes(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nvidia", "runc", "runsc"}, runtimes)
+}
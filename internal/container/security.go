@@ -0,0 +1,170 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+var (
+	ErrInvalidSecurityProfile = errors.New("invalid security profile")
+	ErrUserNamespaceRequired  = errors.New("security profile requires docker userns-remap")
+)
+
+// Security profile presets
+const (
+	SecurityProfileDefault = "default" // Docker's defaults, renters get passwordless sudo
+	SecurityProfileStrict  = "strict"  // Minimal capabilities, no privilege escalation, pids limit
+)
+
+// SeccompUnconfined disables seccomp filtering
+const SeccompUnconfined = "unconfined"
+
+// strictCapabilities are what the rental entrypoint needs to create the rental
+// user and run sshd (privilege separation chroot, PAM login audit). Docker's
+// other defaults (MKNOD, NET_RAW, SETFCAP, SETPCAP, FSETID) are dropped.
+var strictCapabilities = []string{
+	"CHOWN", "DAC_OVERRIDE", "FOWNER", "SETUID", "SETGID",
+	"SYS_CHROOT", "KILL", "AUDIT_WRITE", "NET_BIND_SERVICE",
+}
+
+// strictPidsLimit bounds fork bombs while leaving room for DataLoader workers
+const strictPidsLimit = 4096
+
+// DefaultWritablePaths stay writable over a read-only root filesystem. They
+// are seeded from the image, so the entrypoint can still create the rental
+// user and configure sshd; packages must already be in the image.
+var DefaultWritablePaths = []string{"/etc", "/var"}
+
+// readOnlyTmpfs are scratch directories given tmpfs over a read-only root
+var readOnlyTmpfs = []string{"/tmp", "/run"}
+
+// SecurityProfile hardens a rental container. The zero value keeps Docker's
+// defaults.
+type SecurityProfile struct {
+	Name string // Preset the profile started from (for status and logs)
+
+	CapDrop         []string // Capabilities to drop ("ALL" drops every one not in CapAdd)
+	CapAdd          []string // Capabilities to keep or add
+	NoNewPrivileges bool     // Block setuid escalation; renters lose sudo
+	PidsLimit       int64    // Max processes (0 = unlimited)
+
+	ReadOnlyRootfs bool     // Mount the image's filesystem read-only
+	WritablePaths  []string // Volumes over a read-only root, seeded from the image and removed with the container
+
+	Seccomp string // Seccomp profile JSON (empty = Docker's default, "unconfined" = none)
+
+	RequireUserNS bool // Refuse to create rentals unless the daemon remaps user namespaces
+}
+
+// SecurityPreset returns a named preset
+func SecurityPreset(name string) (SecurityProfile, error) {
+	switch name {
+	case "", SecurityProfileDefault:
+		return SecurityProfile{Name: SecurityProfileDefault}, nil
+	case SecurityProfileStrict:
+		return SecurityProfile{
+			Name:            SecurityProfileStrict,
+			CapDrop:         []string{"ALL"},
+			CapAdd:          strictCapabilities,
+			NoNewPrivileges: true,
+			PidsLimit:       strictPidsLimit,
+		}, nil
+	default:
+		return SecurityProfile{}, fmt.Errorf("%w: unknown preset %q", ErrInvalidSecurityProfile, name)
+	}
+}
+
+// LoadSeccompProfile reads a seccomp profile JSON file for SecurityProfile.Seccomp.
+// "unconfined" is passed through. The daemon takes the profile's content, not its path.
+func LoadSeccompProfile(path string) (string, error) {
+	if path == "" || path == SeccompUnconfined {
+		return path, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read seccomp profile: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: seccomp profile %s is not valid JSON: %v", ErrInvalidSecurityProfile, path, err)
+	}
+	return buf.String(), nil
+}
+
+// AllowsSudo reports whether the rental user gets passwordless sudo. sudo
+// can't raise privileges under no-new-privileges.
+func (p SecurityProfile) AllowsSudo() bool {
+	return !p.NoNewPrivileges
+}
+
+// apply sets the profile's options on a rental container's host config
+func (p SecurityProfile) apply(hostConfig *container.HostConfig) {
+	hostConfig.CapDrop = p.CapDrop
+	hostConfig.CapAdd = p.CapAdd
+	if p.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+	if p.Seccomp != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+p.Seccomp)
+	}
+	if p.PidsLimit > 0 {
+		limit := p.PidsLimit
+		hostConfig.PidsLimit = &limit
+	}
+	if p.ReadOnlyRootfs {
+		hostConfig.ReadonlyRootfs = true
+		for _, path := range p.WritablePaths {
+			// Anonymous volumes are seeded from the image
+			hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{Type: mount.TypeVolume, Target: path})
+		}
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = make(map[string]string)
+		}
+		for _, path := range readOnlyTmpfs {
+			if _, ok := hostConfig.Tmpfs[path]; !ok {
+				hostConfig.Tmpfs[path] = "rw,nosuid,nodev"
+			}
+		}
+	}
+}
+
+// UserNamespaceRemapped reports whether the daemon runs containers in a
+// remapped user namespace (dockerd --userns-remap), where root in a rental
+// is an unprivileged user on the host
+func (s *DockerService) UserNamespaceRemapped(ctx context.Context) (bool, error) {
+	info, err := s.cli.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to query docker info: %w", err)
+	}
+	for _, opt := range info.SecurityOptions {
+		if strings.Contains(opt, "name=userns") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkSecurity enforces the profile's daemon requirements before a rental is created
+func (s *DockerService) checkSecurity(ctx context.Context, p SecurityProfile) error {
+	if !p.RequireUserNS {
+		return nil
+	}
+	remapped, err := s.UserNamespaceRemapped(ctx)
+	if err != nil {
+		return err
+	}
+	if !remapped {
+		slog.Warn("refusing rental without user namespace remapping", "profile", p.Name)
+		return ErrUserNamespaceRequired
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strictProfile(t *testing.T) SecurityProfile {
+	p, err := SecurityPreset(SecurityProfileStrict)
+	require.NoError(t, err)
+	return p
+}
+
+func TestCreateContainer_SecurityProfiles(t *testing.T) {
+	pids := int64(4096)
+	readOnly := strictProfile(t)
+	readOnly.ReadOnlyRootfs = true
+	readOnly.WritablePaths = DefaultWritablePaths
+	seccomp := SecurityProfile{Name: "custom", Seccomp: `{"defaultAction":"SCMP_ACT_ERRNO"}`, PidsLimit: 512}
+	customPids := int64(512)
+
+	tests := []struct {
+		name    string
+		profile SecurityProfile
+		want    container.HostConfig
+		sudo    bool
+	}{
+		{
+			name:    "default",
+			profile: SecurityProfile{Name: SecurityProfileDefault},
+			sudo:    true,
+		},
+		{
+			name:    "strict",
+			profile: strictProfile(t),
+			want: container.HostConfig{
+				CapDrop:     []string{"ALL"},
+				CapAdd:      strictCapabilities,
+				SecurityOpt: []string{"no-new-privileges:true"},
+				Resources:   container.Resources{PidsLimit: &pids},
+			},
+		},
+		{
+			name:    "strict read-only",
+			profile: readOnly,
+			want: container.HostConfig{
+				CapDrop:        []string{"ALL"},
+				CapAdd:         strictCapabilities,
+				SecurityOpt:    []string{"no-new-privileges:true"},
+				Resources:      container.Resources{PidsLimit: &pids},
+				ReadonlyRootfs: true,
+				Mounts: []mount.Mount{
+					{Type: mount.TypeVolume, Target: "/etc"},
+					{Type: mount.TypeVolume, Target: "/var"},
+				},
+				Tmpfs: map[string]string{"/tmp": "rw,nosuid,nodev", "/run": "rw,nosuid,nodev"},
+			},
+		},
+		{
+			name:    "custom seccomp",
+			profile: seccomp,
+			want: container.HostConfig{
+				SecurityOpt: []string{`seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`},
+				Resources:   container.Resources{PidsLimit: &customPids},
+			},
+			sudo: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+			svc := NewDockerServiceWithClient(mock)
+
+			_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+				SessionID: "session-abc",
+				Image:     "img",
+				SSHPort:   30001,
+				Security:  tt.profile,
+			})
+			require.NoError(t, err)
+
+			hc := mock.LastHostConfig
+			assert.Equal(t, tt.want.CapDrop, hc.CapDrop)
+			assert.Equal(t, tt.want.CapAdd, hc.CapAdd)
+			assert.Equal(t, tt.want.SecurityOpt, hc.SecurityOpt)
+			assert.Equal(t, tt.want.PidsLimit, hc.PidsLimit)
+			assert.Equal(t, tt.want.ReadonlyRootfs, hc.ReadonlyRootfs)
+			assert.Equal(t, tt.want.Mounts, hc.Mounts)
+			assert.Equal(t, tt.want.Tmpfs, hc.Tmpfs)
+			if tt.sudo {
+				assert.NotContains(t, mock.LastCreateConfig.Env, "RENTAL_SUDO=0")
+			} else {
+				assert.Contains(t, mock.LastCreateConfig.Env, "RENTAL_SUDO=0")
+			}
+		})
+	}
+}
+
+func TestCreateContainer_ReadOnlyKeepsScratchAndWorkspace(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+	profile := strictProfile(t)
+	profile.ReadOnlyRootfs = true
+	profile.WritablePaths = []string{"/etc"}
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:       "session-abc",
+		Image:           "img",
+		SSHPort:         30001,
+		WorkspaceVolume: "wl-rental-session-abc-workspace",
+		ScratchMounts:   []ScratchMount{{Path: "/tmp", SizeBytes: 1 << 30}},
+		Security:        profile,
+	})
+
+	require.NoError(t, err)
+	hc := mock.LastHostConfig
+	require.Len(t, hc.Mounts, 2)
+	assert.Equal(t, WorkspacePath, hc.Mounts[0].Target)
+	assert.Equal(t, "/etc", hc.Mounts[1].Target)
+	assert.Equal(t, "rw,nosuid,nodev,size=1073741824", hc.Tmpfs["/tmp"])
+	assert.Equal(t, "rw,nosuid,nodev", hc.Tmpfs["/run"])
+}
+
+func TestCreateContainer_MiningIgnoresSecurityProfile(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:          "mining",
+		Image:              "img",
+		UseImageEntrypoint: true,
+		Security:           strictProfile(t),
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, mock.LastHostConfig.CapDrop)
+	assert.Nil(t, mock.LastHostConfig.PidsLimit)
+}
+
+func TestCreateContainer_RequiresUserNamespace(t *testing.T) {
+	profile := strictProfile(t)
+	profile.RequireUserNS = true
+
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "session-abc", Image: "img", Security: profile})
+	assert.ErrorIs(t, err, ErrUserNamespaceRequired)
+	assert.Equal(t, 0, mock.CreateCalled)
+
+	mock = &MockDockerClient{
+		CreateResponse: container.CreateResponse{ID: "container-123"},
+		InfoResponse:   system.Info{SecurityOptions: []string{"name=seccomp,profile=builtin", "name=userns"}},
+	}
+	svc = NewDockerServiceWithClient(mock)
+	_, err = svc.CreateContainer(context.Background(), ContainerConfig{SessionID: "session-abc", Image: "img", Security: profile})
+	assert.NoError(t, err)
+}
+
+func TestSecurityPreset(t *testing.T) {
+	p, err := SecurityPreset("")
+	require.NoError(t, err)
+	assert.Equal(t, SecurityProfile{Name: SecurityProfileDefault}, p)
+	assert.True(t, p.AllowsSudo())
+
+	p = strictProfile(t)
+	assert.False(t, p.AllowsSudo())
+	assert.NotContains(t, p.CapAdd, "NET_RAW")
+	assert.NotContains(t, p.CapAdd, "MKNOD")
+
+	_, err = SecurityPreset("paranoid")
+	assert.ErrorIs(t, err, ErrInvalidSecurityProfile)
+}
+
+func TestLoadSeccompProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "seccomp.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\n  \"defaultAction\": \"SCMP_ACT_ERRNO\"\n}\n"), 0o644))
+
+	profile, err := LoadSeccompProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"defaultAction":"SCMP_ACT_ERRNO"}`, profile)
+
+	profile, err = LoadSeccompProfile(SeccompUnconfined)
+	require.NoError(t, err)
+	assert.Equal(t, SeccompUnconfined, profile)
+
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte("not json"), 0o644))
+	_, err = LoadSeccompProfile(bad)
+	assert.ErrorIs(t, err, ErrInvalidSecurityProfile)
+
+	_, err = LoadSeccompProfile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestDiskUsage_IncludesWritablePathVolumes(t *testing.T) {
+	size := int64(100)
+	mock := &MockDockerClient{
+		InspectResponse: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "container-123"},
+			Mounts: []types.MountPoint{
+				{Type: mount.TypeVolume, Name: "wl-rental-session-123-workspace", Destination: "/home/ubuntu"},
+				{Type: mount.TypeVolume, Name: "3f9a", Destination: "/var"},
+				{Type: mount.TypeTmpfs, Destination: "/tmp"},
+			},
+		},
+		SizeRw: &size,
+		Volumes: []*volume.Volume{
+			{Name: "wl-rental-session-123-workspace", UsageData: &volume.UsageData{Size: 1000}},
+			{Name: "3f9a", UsageData: &volume.UsageData{Size: 10}},
+		},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	usage, err := svc.DiskUsage(context.Background(), "container-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1110), usage)
+}
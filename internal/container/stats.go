@@ -0,0 +1,76 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ResourceStats is a point-in-time resource reading for a container. CPU,
+// network and block I/O are cumulative counters since the container started.
+type ResourceStats struct {
+	At               time.Time `json:"at"`
+	CPUNanos         uint64    `json:"cpuNanos"`         // Total CPU time consumed
+	MemoryBytes      uint64    `json:"memoryBytes"`      // Current usage excluding reclaimable page cache
+	MemoryLimitBytes uint64    `json:"memoryLimitBytes"` // cgroup limit
+	NetRxBytes       uint64    `json:"netRxBytes"`
+	NetTxBytes       uint64    `json:"netTxBytes"`
+	BlockReadBytes   uint64    `json:"blockReadBytes"`
+	BlockWriteBytes  uint64    `json:"blockWriteBytes"`
+}
+
+// ContainerStats takes a single stats sample from the Docker stats API
+func (s *DockerService) ContainerStats(ctx context.Context, containerID string) (*ResourceStats, error) {
+	resp, err := s.cli.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var raw container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+	return resourceStats(raw), nil
+}
+
+// resourceStats flattens the daemon's stats response
+func resourceStats(raw container.StatsResponse) *ResourceStats {
+	stats := &ResourceStats{
+		At:               raw.Read,
+		CPUNanos:         raw.CPUStats.CPUUsage.TotalUsage,
+		MemoryBytes:      raw.MemoryStats.Usage,
+		MemoryLimitBytes: raw.MemoryStats.Limit,
+	}
+	if stats.At.IsZero() {
+		stats.At = time.Now()
+	}
+
+	// Same accounting as `docker stats`: page cache the kernel can reclaim
+	// isn't charged (inactive_file on cgroup v2, total_inactive_file on v1)
+	cache, ok := raw.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = raw.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < stats.MemoryBytes {
+		stats.MemoryBytes -= cache
+	}
+
+	for _, n := range raw.Networks {
+		stats.NetRxBytes += n.RxBytes
+		stats.NetTxBytes += n.TxBytes
+	}
+	for _, e := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			stats.BlockReadBytes += e.Value
+		case "write":
+			stats.BlockWriteBytes += e.Value
+		}
+	}
+	return stats
+}
@@ -0,0 +1,65 @@
+package container
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerStats_FlattensResponse(t *testing.T) {
+	read := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock := &MockDockerClient{Stats: container.StatsResponse{
+		Read: read,
+		CPUStats: container.CPUStats{
+			CPUUsage: container.CPUUsage{TotalUsage: 5_000_000_000},
+		},
+		MemoryStats: container.MemoryStats{
+			Usage: 3 << 30,
+			Limit: 16 << 30,
+			Stats: map[string]uint64{"inactive_file": 1 << 30},
+		},
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: 100, TxBytes: 10},
+			"eth1": {RxBytes: 50, TxBytes: 5},
+		},
+		BlkioStats: container.BlkioStats{IoServiceBytesRecursive: []container.BlkioStatEntry{
+			{Op: "Read", Value: 4096},
+			{Op: "Write", Value: 8192},
+			{Op: "read", Value: 1024},
+			{Op: "Total", Value: 13312},
+		}},
+	}}
+	svc := NewDockerServiceWithClient(mock)
+
+	stats, err := svc.ContainerStats(context.Background(), "container-123")
+
+	require.NoError(t, err)
+	assert.True(t, read.Equal(stats.At))
+	assert.Equal(t, uint64(5_000_000_000), stats.CPUNanos)
+	assert.Equal(t, uint64(2<<30), stats.MemoryBytes)
+	assert.Equal(t, uint64(16<<30), stats.MemoryLimitBytes)
+	assert.Equal(t, uint64(150), stats.NetRxBytes)
+	assert.Equal(t, uint64(15), stats.NetTxBytes)
+	assert.Equal(t, uint64(5120), stats.BlockReadBytes)
+	assert.Equal(t, uint64(8192), stats.BlockWriteBytes)
+}
+
+func TestContainerStats_CgroupV1Cache(t *testing.T) {
+	mock := &MockDockerClient{Stats: container.StatsResponse{
+		MemoryStats: container.MemoryStats{
+			Usage: 1000,
+			Stats: map[string]uint64{"total_inactive_file": 400},
+		},
+	}}
+	svc := NewDockerServiceWithClient(mock)
+
+	stats, err := svc.ContainerStats(context.Background(), "container-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, uint64(600), stats.MemoryBytes)
+	assert.False(t, stats.At.IsZero())
+}
@@ -0,0 +1,80 @@
+package container
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+)
+
+// WorkspacePath is where a rental's workspace volume is mounted: the rental
+// user's home, so files, dotfiles and installed user packages survive a rebuild
+const WorkspacePath = "/home/ubuntu"
+
+// WorkspaceVolumeName returns the workspace volume name for a session
+func WorkspaceVolumeName(sessionID string) string {
+	return rentalNetworkPrefix + sessionID + "-workspace"
+}
+
+// CreateWorkspace creates the named volume holding a rental's workspace.
+// Returns the volume name to set as ContainerConfig.WorkspaceVolume.
+func (s *DockerService) CreateWorkspace(ctx context.Context, sessionID string) (string, error) {
+	vol, err := s.cli.VolumeCreate(ctx, volume.CreateOptions{
+		Name:   WorkspaceVolumeName(sessionID),
+		Labels: map[string]string{LabelSessionID: sessionID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create workspace volume: %w", err)
+	}
+	return vol.Name, nil
+}
+
+// RemoveWorkspace deletes a rental's workspace volume. Containers using it
+// must already be removed. A missing volume is not an error.
+func (s *DockerService) RemoveWorkspace(ctx context.Context, sessionID string) error {
+	err := s.cli.VolumeRemove(ctx, WorkspaceVolumeName(sessionID), true)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove workspace volume: %w", err)
+	}
+	return nil
+}
+
+// workspaceMounts mounts the workspace volume at WorkspacePath
+func workspaceMounts(volumeName string, readOnly bool) []mount.Mount {
+	if volumeName == "" {
+		return nil
+	}
+	return []mount.Mount{{
+		Type:     mount.TypeVolume,
+		Source:   volumeName,
+		Target:   WorkspacePath,
+		ReadOnly: readOnly,
+	}}
+}
+
+// volumeUsage returns the total size of the named volumes. The daemon computes
+// sizes for every volume, so this is only asked for containers that mount one.
+func (s *DockerService) volumeUsage(ctx context.Context, names map[string]bool) (int64, error) {
+	du, err := s.cli.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure volumes: %w", err)
+	}
+	var size int64
+	for _, v := range du.Volumes {
+		if names[v.Name] && v.UsageData != nil && v.UsageData.Size > 0 {
+			size += v.UsageData.Size
+		}
+	}
+	return size, nil
+}
+
+// RenameContainer renames a container
+func (s *DockerService) RenameContainer(ctx context.Context, containerID, name string) error {
+	if err := s.cli.ContainerRename(ctx, containerID, name); err != nil {
+		return fmt.Errorf("failed to rename container: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWorkspace_LabelsVolume(t *testing.T) {
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock)
+
+	name, err := svc.CreateWorkspace(context.Background(), "session-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, "wl-rental-session-123-workspace", name)
+	require.Len(t, mock.VolumeCreates, 1)
+	assert.Equal(t, "session-123", mock.VolumeCreates[0].Labels[LabelSessionID])
+}
+
+func TestRemoveWorkspace_IgnoresMissingVolume(t *testing.T) {
+	mock := &MockDockerClient{VolumeRemoveError: errdefs.NotFound(assert.AnError)}
+	svc := NewDockerServiceWithClient(mock)
+
+	assert.NoError(t, svc.RemoveWorkspace(context.Background(), "session-123"))
+	assert.Equal(t, []string{"wl-rental-session-123-workspace"}, mock.VolumeRemoves)
+}
+
+func TestCreateContainer_MountsWorkspaceAtHome(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "container-123"}}
+	svc := NewDockerServiceWithClient(mock)
+
+	_, err := svc.CreateContainer(context.Background(), ContainerConfig{
+		SessionID:       "session-123",
+		Image:           "img",
+		SSHPort:         30001,
+		WorkspaceVolume: "wl-rental-session-123-workspace",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []mount.Mount{{
+		Type:   mount.TypeVolume,
+		Source: "wl-rental-session-123-workspace",
+		Target: "/home/ubuntu",
+	}}, mock.LastHostConfig.Mounts)
+}
+
+func TestStartExport_MountsWorkspaceReadOnly(t *testing.T) {
+	mock := &MockDockerClient{CreateResponse: container.CreateResponse{ID: "export-123"}}
+	svc := exportService(t, mock)
+
+	_, err := svc.StartExport(context.Background(), ExportConfig{
+		SessionID:       "session-123",
+		ContainerID:     "container-123",
+		SSHPort:         30001,
+		WorkspaceVolume: "wl-rental-session-123-workspace",
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, mock.LastHostConfig.Mounts, mount.Mount{
+		Type:     mount.TypeVolume,
+		Source:   "wl-rental-session-123-workspace",
+		Target:   "/home/ubuntu",
+		ReadOnly: true,
+	})
+}
+
+func TestDiskUsage_IncludesWorkspaceVolume(t *testing.T) {
+	size := int64(4096)
+	mock := &MockDockerClient{
+		InspectResponse: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "container-123"},
+			Mounts: []types.MountPoint{
+				{Type: mount.TypeVolume, Name: "wl-rental-session-123-workspace", Destination: "/home/ubuntu"},
+			},
+		},
+		SizeRw: &size,
+		Volumes: []*volume.Volume{
+			{Name: "other", UsageData: &volume.UsageData{Size: 1 << 30}},
+			{Name: "wl-rental-session-123-workspace", UsageData: &volume.UsageData{Size: 1000}},
+		},
+	}
+	svc := NewDockerServiceWithClient(mock)
+
+	usage, err := svc.DiskUsage(context.Background(), "container-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(5096), usage)
+}
+
+func TestRenameContainer(t *testing.T) {
+	mock := &MockDockerClient{}
+	svc := NewDockerServiceWithClient(mock)
+
+	require.NoError(t, svc.RenameContainer(context.Background(), "container-123", "session-123-prev"))
+	assert.Equal(t, []string{"container-123 -> session-123-prev"}, mock.Renames)
+}
@@ -10,6 +10,6 @@ type GPUProvider interface {
 	GetDeviceCount() (int, error)
 	// GetMetrics returns current metrics for all GPUs
 	GetMetrics() ([]GPUMetrics, error)
-	// GetSpecs returns static specifications for all GPUs
+	// GetSpecs returns static specifications for all GPUs and their MIG slices
 	GetSpecs() ([]GPUSpec, error)
 }
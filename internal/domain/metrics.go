@@ -3,6 +3,7 @@ package domain
 // GPUMetrics represents collected GPU metrics from NVML
 type GPUMetrics struct {
 	UUID        string `json:"uuid"`
+	Index       int    `json:"index"` // NVML device index
 	Name        string `json:"name"`
 	MemoryTotal uint64 `json:"memory_total_mb"`
 	MemoryUsed  uint64 `json:"memory_used_mb"`
@@ -11,10 +12,30 @@ type GPUMetrics struct {
 	Temperature uint32 `json:"temperature_c"`
 }
 
-// GPUSpec represents static GPU specifications for node registration
+// GPUSpec represents static GPU specifications for node registration.
+// A GPU in MIG mode is listed with MIGMode set, followed by its MIG slices,
+// which carry their parent's name, index and bus ID and their own memory.
 type GPUSpec struct {
 	UUID        string `json:"uuid"`
 	Name        string `json:"name"`
 	MemoryTotal uint64 `json:"memory_total_mb"`
 	DriverVer   string `json:"driver_version"`
+	Index       int    `json:"index"`      // NVML device index
+	PCIBusID    string `json:"pci_bus_id"` // e.g. "00000000:3B:00.0"
+
+	MIGMode    bool   `json:"mig_mode,omitempty"`    // GPU is partitioned; only its slices are usable
+	ParentUUID string `json:"parent_uuid,omitempty"` // MIG slice: the GPU it belongs to
+	MIGProfile string `json:"mig_profile,omitempty"` // MIG slice: profile, e.g. "1g.10gb"
+	MIGIndex   int    `json:"mig_index,omitempty"`   // MIG slice: index on its parent
+}
+
+// IsMIGSlice reports whether the spec is a MIG slice rather than a GPU
+func (s GPUSpec) IsMIGSlice() bool {
+	return s.ParentUUID != ""
+}
+
+// Rentable reports whether the device can be assigned to a rental: a whole
+// GPU or a MIG slice, but not a GPU in MIG mode
+func (s GPUSpec) Rentable() bool {
+	return !s.MIGMode
 }
@@ -0,0 +1,150 @@
+// Package inventory tracks the node's rentable GPU units: whole GPUs and the
+// MIG slices of GPUs in MIG mode.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/worldland/worldland-node/internal/domain"
+)
+
+var (
+	ErrUnknownUnit = errors.New("gpu or mig slice not found on this host")
+	ErrMIGParent   = errors.New("gpu is in mig mode; only its slices can be rented")
+	ErrUnitInUse   = errors.New("gpu or mig slice already assigned")
+)
+
+// Unit is a rentable GPU or MIG slice and the session holding it
+type Unit struct {
+	domain.GPUSpec
+	SessionID string `json:"session_id,omitempty"` // Empty = free
+}
+
+// Units returns the rentable specs: GPUs not in MIG mode and MIG slices
+func Units(specs []domain.GPUSpec) []domain.GPUSpec {
+	var units []domain.GPUSpec
+	for _, spec := range specs {
+		if spec.Rentable() {
+			units = append(units, spec)
+		}
+	}
+	return units
+}
+
+// Allocator hands out GPUs and MIG slices so that no two rentals share one
+type Allocator struct {
+	mu       sync.Mutex
+	specs    []domain.GPUSpec  // Every GPU and slice, in GetSpecs order
+	assigned map[string]string // unit UUID -> sessionID
+}
+
+// NewAllocator creates an allocator for the host's GPUs and their MIG slices
+func NewAllocator(specs []domain.GPUSpec) *Allocator {
+	return &Allocator{
+		specs:    specs,
+		assigned: make(map[string]string),
+	}
+}
+
+// Allocate assigns the units in gpuDeviceID (a comma-separated list of GPU
+// or MIG slice UUIDs, GPU indexes, or "all" for every unit) to a session.
+// Nothing is assigned unless all of them are free or already the session's,
+// so allocating again for the same session, e.g. an adopted rental, is safe.
+func (a *Allocator) Allocate(sessionID, gpuDeviceID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	units, err := a.resolve(gpuDeviceID)
+	if err != nil {
+		return err
+	}
+	for _, uuid := range units {
+		if owner := a.assigned[uuid]; owner != "" && owner != sessionID {
+			return fmt.Errorf("%w: %s (session %s)", ErrUnitInUse, uuid, owner)
+		}
+	}
+	for _, uuid := range units {
+		a.assigned[uuid] = sessionID
+	}
+	return nil
+}
+
+// Release frees a session's units. Releasing an unknown session is a no-op.
+func (a *Allocator) Release(sessionID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for uuid, owner := range a.assigned {
+		if owner == sessionID {
+			delete(a.assigned, uuid)
+		}
+	}
+}
+
+// Assigned returns the UUIDs of a session's units in inventory order
+func (a *Allocator) Assigned(sessionID string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var uuids []string
+	for _, spec := range Units(a.specs) {
+		if a.assigned[spec.UUID] == sessionID {
+			uuids = append(uuids, spec.UUID)
+		}
+	}
+	return uuids
+}
+
+// Status returns every rentable unit and the session holding it
+func (a *Allocator) Status() []Unit {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var units []Unit
+	for _, spec := range Units(a.specs) {
+		units = append(units, Unit{GPUSpec: spec, SessionID: a.assigned[spec.UUID]})
+	}
+	return units
+}
+
+// resolve returns the UUIDs of the units named in gpuDeviceID
+func (a *Allocator) resolve(gpuDeviceID string) ([]string, error) {
+	if gpuDeviceID == "all" {
+		var uuids []string
+		for _, spec := range Units(a.specs) {
+			uuids = append(uuids, spec.UUID)
+		}
+		return uuids, nil
+	}
+
+	var uuids []string
+	for _, id := range strings.Split(gpuDeviceID, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		spec, ok := a.find(id)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownUnit, id)
+		}
+		if !spec.Rentable() {
+			return nil, fmt.Errorf("%w: %s", ErrMIGParent, id)
+		}
+		uuids = append(uuids, spec.UUID)
+	}
+	return uuids, nil
+}
+
+// find looks a unit up by UUID, or a GPU by NVML index
+func (a *Allocator) find(id string) (domain.GPUSpec, bool) {
+	for _, spec := range a.specs {
+		if spec.UUID == id || (!spec.IsMIGSlice() && strconv.Itoa(spec.Index) == id) {
+			return spec, true
+		}
+	}
+	return domain.GPUSpec{}, false
+}
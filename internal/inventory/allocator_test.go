@@ -0,0 +1,103 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/adapters/nvml"
+	"github.com/worldland/worldland-node/internal/domain"
+)
+
+// mixedHost has a whole GPU and an A100 split into three slices
+func mixedHost(t *testing.T) []domain.GPUSpec {
+	t.Helper()
+	specs := []domain.GPUSpec{{UUID: "GPU-aaa", Name: "NVIDIA A100", MemoryTotal: 81920, Index: 0}}
+	specs = append(specs, nvml.MIGLayout(
+		domain.GPUSpec{UUID: "GPU-bbb", Name: "NVIDIA A100", MemoryTotal: 81920, Index: 1},
+		"3g.40gb", "2g.20gb", "1g.10gb",
+	)...)
+
+	provider := nvml.NewMockGPUProvider(nil, specs)
+	got, err := provider.GetSpecs()
+	require.NoError(t, err)
+	return got
+}
+
+func TestUnits_ListsSlicesInsteadOfMIGParent(t *testing.T) {
+	units := Units(mixedHost(t))
+
+	require.Len(t, units, 4)
+	assert.Equal(t, "GPU-aaa", units[0].UUID)
+	assert.Equal(t, "MIG-bbb-0", units[1].UUID)
+	assert.Equal(t, "GPU-bbb", units[1].ParentUUID)
+	assert.Equal(t, "3g.40gb", units[1].MIGProfile)
+	assert.Equal(t, uint64(40*1024), units[1].MemoryTotal)
+	assert.Equal(t, 1, units[3].Index)
+	assert.Equal(t, 2, units[3].MIGIndex)
+}
+
+func TestAllocate_SlicesAreSeparateUnits(t *testing.T) {
+	a := NewAllocator(mixedHost(t))
+
+	require.NoError(t, a.Allocate("session-1", "MIG-bbb-0"))
+	require.NoError(t, a.Allocate("session-2", "MIG-bbb-1,MIG-bbb-2"))
+	require.NoError(t, a.Allocate("session-3", "0"))
+
+	status := a.Status()
+	require.Len(t, status, 4)
+	assert.Equal(t, "session-3", status[0].SessionID)
+	assert.Equal(t, "session-1", status[1].SessionID)
+	assert.Equal(t, "session-2", status[2].SessionID)
+	assert.Equal(t, "session-2", status[3].SessionID)
+}
+
+func TestAllocate_RejectsUnitInUse(t *testing.T) {
+	a := NewAllocator(mixedHost(t))
+	require.NoError(t, a.Allocate("session-1", "MIG-bbb-1"))
+
+	err := a.Allocate("session-2", "MIG-bbb-0,MIG-bbb-1")
+
+	assert.ErrorIs(t, err, ErrUnitInUse)
+	// Nothing was assigned to the failed session
+	assert.Empty(t, a.Status()[1].SessionID)
+}
+
+func TestAllocate_SameSessionAgain(t *testing.T) {
+	a := NewAllocator(mixedHost(t))
+	require.NoError(t, a.Allocate("session-1", "GPU-aaa"))
+
+	assert.NoError(t, a.Allocate("session-1", "GPU-aaa"))
+}
+
+func TestAllocate_RejectsMIGParentAndUnknown(t *testing.T) {
+	a := NewAllocator(mixedHost(t))
+
+	assert.ErrorIs(t, a.Allocate("session-1", "GPU-bbb"), ErrMIGParent)
+	assert.ErrorIs(t, a.Allocate("session-1", "1"), ErrMIGParent)
+	assert.ErrorIs(t, a.Allocate("session-1", "MIG-zzz-0"), ErrUnknownUnit)
+}
+
+func TestAllocate_AllTakesEveryUnit(t *testing.T) {
+	a := NewAllocator(mixedHost(t))
+	require.NoError(t, a.Allocate("session-1", "all"))
+
+	assert.ErrorIs(t, a.Allocate("session-2", "MIG-bbb-2"), ErrUnitInUse)
+	var uuids []string
+	for _, u := range a.Status() {
+		assert.Equal(t, "session-1", u.SessionID)
+		uuids = append(uuids, u.UUID)
+	}
+	assert.Equal(t, uuids, a.Assigned("session-1"))
+	assert.Empty(t, a.Assigned("session-2"))
+}
+
+func TestRelease_FreesUnits(t *testing.T) {
+	a := NewAllocator(mixedHost(t))
+	require.NoError(t, a.Allocate("session-1", "MIG-bbb-0"))
+
+	a.Release("session-1")
+	a.Release("unknown")
+
+	assert.NoError(t, a.Allocate("session-2", "MIG-bbb-0"))
+}
@@ -9,6 +9,8 @@ import (
 var (
 	ErrNoAvailablePorts = errors.New("no available ports in range")
 	ErrPortNotAllocated = errors.New("port not allocated")
+	ErrPortInUse        = errors.New("port already allocated")
+	ErrPortOutOfRange   = errors.New("port outside managed range")
 )
 
 // Allocation tracks a single port allocation
@@ -75,6 +77,30 @@ func (pm *PortManager) Allocate(sessionID string) (int, error) {
 	return 0, ErrNoAvailablePorts
 }
 
+// Reserve allocates a specific port for the given session, e.g. for a
+// container that already publishes it. The port must be available.
+func (pm *PortManager) Reserve(port int, sessionID string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if port < pm.minPort || port > pm.maxPort {
+		return ErrPortOutOfRange
+	}
+
+	now := time.Now()
+	if alloc, exists := pm.allocations[port]; exists {
+		if alloc.ReleasedAt == nil || now.Sub(*alloc.ReleasedAt) < pm.gracePeriod {
+			return ErrPortInUse
+		}
+	}
+
+	pm.allocations[port] = &Allocation{
+		SessionID:   sessionID,
+		AllocatedAt: now,
+	}
+	return nil
+}
+
 // Release marks a port as released (starts grace period countdown)
 func (pm *PortManager) Release(port int) error {
 	pm.mu.Lock()
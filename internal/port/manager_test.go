@@ -102,6 +102,24 @@ func TestAllocate_ReusesReleasedPortAfterGracePeriod(t *testing.T) {
 	assert.Equal(t, port2, port)
 }
 
+func TestReserve_AllocatesSpecificPort(t *testing.T) {
+	pm := NewPortManager(30000, 30010, time.Minute)
+
+	require.NoError(t, pm.Reserve(30005, "session-1"))
+	assert.False(t, pm.IsAvailable(30005))
+	assert.ErrorIs(t, pm.Reserve(30005, "session-2"), ErrPortInUse)
+	assert.ErrorIs(t, pm.Reserve(40000, "session-2"), ErrPortOutOfRange)
+
+	// Allocate skips the reserved port
+	for i := 0; i < 5; i++ {
+		_, err := pm.Allocate("session-3")
+		require.NoError(t, err)
+	}
+	port, err := pm.Allocate("session-3")
+	require.NoError(t, err)
+	assert.Equal(t, 30006, port)
+}
+
 func TestAvailableCount_ReturnsCorrectCount(t *testing.T) {
 	pm := NewPortManager(30000, 30009, 30*time.Minute) // 10 ports
 
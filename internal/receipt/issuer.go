@@ -0,0 +1,92 @@
+package receipt
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Usage is the billable span of a rental that a receipt covers
+type Usage struct {
+	SessionID      string
+	StartedAt      time.Time
+	EndedAt        time.Time
+	GPUCount       int
+	PricePerSecond string // Wei per GPU second
+	Final          bool
+}
+
+// Issuer signs, numbers and stores receipts
+type Issuer struct {
+	signer  Signer
+	store   *FileStore // nil = not persisted
+	chainID int64
+
+	mu        sync.Mutex
+	sequences map[string]uint64 // sessionID -> last issued sequence
+}
+
+// NewIssuer creates an issuer signing with the provider wallet
+func NewIssuer(signer Signer, store *FileStore, chainID int64) *Issuer {
+	return &Issuer{
+		signer:    signer,
+		store:     store,
+		chainID:   chainID,
+		sequences: make(map[string]uint64),
+	}
+}
+
+// Issue signs and stores a receipt for the usage. Sequences continue from
+// receipts already in the store, so a restarted node doesn't reuse them.
+func (i *Issuer) Issue(u Usage) (*Receipt, error) {
+	if u.EndedAt.Before(u.StartedAt) {
+		return nil, fmt.Errorf("%w: ends before it starts", ErrInvalidReceipt)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	seq, known := i.sequences[u.SessionID]
+	if !known && i.store != nil {
+		stored, err := i.store.Load(u.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range stored {
+			if r.Sequence > seq {
+				seq = r.Sequence
+			}
+		}
+	}
+	seq++
+
+	seconds := uint64(u.EndedAt.Sub(u.StartedAt) / time.Second)
+	r := &Receipt{
+		SessionID:      u.SessionID,
+		Sequence:       seq,
+		StartedAt:      uint64(u.StartedAt.Unix()),
+		EndedAt:        uint64(u.EndedAt.Unix()),
+		GPUSeconds:     seconds * uint64(u.GPUCount),
+		PricePerSecond: u.PricePerSecond,
+		Final:          u.Final,
+		ChainID:        i.chainID,
+	}
+	if r.PricePerSecond == "" {
+		r.PricePerSecond = "0"
+	}
+	if err := Sign(r, i.signer); err != nil {
+		return nil, err
+	}
+	if i.store != nil {
+		if err := i.store.Save(*r); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.Final {
+		delete(i.sequences, u.SessionID)
+	} else {
+		i.sequences[u.SessionID] = seq
+	}
+	return r, nil
+}
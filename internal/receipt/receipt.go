@@ -0,0 +1,135 @@
+// Package receipt produces provider-signed usage receipts for rental
+// settlement. Receipts are EIP-712 typed data so renters, providers and the
+// Hub can verify them on or off chain without trusting the Hub's records.
+package receipt
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid receipt signature")
+	ErrInvalidReceipt   = errors.New("invalid receipt")
+)
+
+// DefaultChainID is the EIP-712 domain chain (BNB Chain, as used for SIWE)
+const DefaultChainID = 56
+
+const (
+	domainName    = "Worldland Usage Receipt"
+	domainVersion = "1"
+)
+
+var (
+	domainTypeHash  = crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId)"))
+	receiptTypeHash = crypto.Keccak256([]byte("UsageReceipt(string sessionId,address provider,uint64 sequence,uint64 startedAt,uint64 endedAt,uint64 gpuSeconds,uint256 pricePerSecond,bool final)"))
+)
+
+// Receipt is a provider-signed statement of a rental's GPU usage. Periodic
+// receipts cover the rental so far; the final receipt covers all of it.
+type Receipt struct {
+	SessionID      string `json:"session_id"`
+	Provider       string `json:"provider"`         // Signing wallet address
+	Sequence       uint64 `json:"sequence"`         // Increases with each receipt for the session
+	StartedAt      uint64 `json:"started_at"`       // Unix seconds
+	EndedAt        uint64 `json:"ended_at"`         // Unix seconds, end of the covered period
+	GPUSeconds     uint64 `json:"gpu_seconds"`      // Covered seconds times assigned GPUs
+	PricePerSecond string `json:"price_per_second"` // Wei per GPU second, decimal
+	Final          bool   `json:"final"`
+	ChainID        int64  `json:"chain_id"`
+	Signature      string `json:"signature"` // 0x-prefixed 65-byte signature
+}
+
+// Signer signs EIP-712 digests with the provider wallet key.
+// auth.SIWEClient satisfies it.
+type Signer interface {
+	GetAddress() string
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// Hash returns the EIP-712 digest of the receipt (signature excluded)
+func (r *Receipt) Hash() ([]byte, error) {
+	if !common.IsHexAddress(r.Provider) {
+		return nil, fmt.Errorf("%w: provider %q is not an address", ErrInvalidReceipt, r.Provider)
+	}
+	price, ok := new(big.Int).SetString(r.PricePerSecond, 10)
+	if !ok || price.Sign() < 0 {
+		return nil, fmt.Errorf("%w: price %q", ErrInvalidReceipt, r.PricePerSecond)
+	}
+
+	domain := crypto.Keccak256(
+		domainTypeHash,
+		crypto.Keccak256([]byte(domainName)),
+		crypto.Keccak256([]byte(domainVersion)),
+		word(big.NewInt(r.ChainID)),
+	)
+
+	final := int64(0)
+	if r.Final {
+		final = 1
+	}
+	structHash := crypto.Keccak256(
+		receiptTypeHash,
+		crypto.Keccak256([]byte(r.SessionID)),
+		common.LeftPadBytes(common.HexToAddress(r.Provider).Bytes(), 32),
+		word(new(big.Int).SetUint64(r.Sequence)),
+		word(new(big.Int).SetUint64(r.StartedAt)),
+		word(new(big.Int).SetUint64(r.EndedAt)),
+		word(new(big.Int).SetUint64(r.GPUSeconds)),
+		word(price),
+		word(big.NewInt(final)),
+	)
+
+	return crypto.Keccak256([]byte("\x19\x01"), domain, structHash), nil
+}
+
+// Sign sets the provider address and signature on the receipt
+func Sign(r *Receipt, signer Signer) error {
+	r.Provider = signer.GetAddress()
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := signer.SignHash(hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign receipt: %w", err)
+	}
+	r.Signature = "0x" + hex.EncodeToString(sig)
+	return nil
+}
+
+// Verify checks that the receipt was signed by its provider address
+func Verify(r Receipt) error {
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(r.Signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	// Wallets use v = 27/28; go-ethereum recovers with 0/1
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(r.Provider) {
+		return fmt.Errorf("%w: not signed by %s", ErrInvalidSignature, r.Provider)
+	}
+	return nil
+}
+
+// word encodes an unsigned integer as a 32-byte ABI word
+func word(v *big.Int) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
@@ -0,0 +1,123 @@
+package receipt
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/auth"
+)
+
+// Well-known test key (Hardhat account #0)
+const testKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+func testSigner(t *testing.T) *auth.SIWEClient {
+	client, err := auth.NewSIWEClient("http://hub.example.com", testKeyHex)
+	require.NoError(t, err)
+	return client
+}
+
+func TestReceipt_HashMatchesEIP712Vector(t *testing.T) {
+	r := Receipt{
+		SessionID:      "session-123",
+		Provider:       "0x71C7656EC7ab88b098defB751B7401B5f6d8976F",
+		Sequence:       3,
+		StartedAt:      1700000000,
+		EndedAt:        1700003600,
+		GPUSeconds:     7200,
+		PricePerSecond: "2777777777778",
+		Final:          true,
+		ChainID:        56,
+	}
+
+	hash, err := r.Hash()
+
+	require.NoError(t, err)
+	// Computed with go-ethereum's apitypes.TypedDataAndHash for the same typed data
+	assert.Equal(t, "c8aac630c77cd0c815821459a082f9acf7b415b9853092620bafe3d739e7a411", hex.EncodeToString(hash))
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := testSigner(t)
+	r := Receipt{SessionID: "session-123", Sequence: 1, StartedAt: 100, EndedAt: 200, GPUSeconds: 100, PricePerSecond: "10", ChainID: DefaultChainID}
+
+	require.NoError(t, Sign(&r, signer))
+
+	assert.Equal(t, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", r.Provider)
+	assert.Len(t, r.Signature, 2+65*2)
+	assert.NoError(t, Verify(r))
+
+	tampered := r
+	tampered.GPUSeconds = 1000
+	assert.ErrorIs(t, Verify(tampered), ErrInvalidSignature)
+
+	otherChain := r
+	otherChain.ChainID = 1
+	assert.ErrorIs(t, Verify(otherChain), ErrInvalidSignature)
+
+	forged := r
+	forged.Provider = "0x71C7656EC7ab88b098defB751B7401B5f6d8976F"
+	assert.ErrorIs(t, Verify(forged), ErrInvalidSignature)
+
+	malformed := r
+	malformed.Signature = "0x1234"
+	assert.ErrorIs(t, Verify(malformed), ErrInvalidSignature)
+}
+
+func TestVerify_AcceptsRecoveryIDWithoutOffset(t *testing.T) {
+	key, err := crypto.HexToECDSA(testKeyHex)
+	require.NoError(t, err)
+	r := Receipt{SessionID: "s", Provider: crypto.PubkeyToAddress(key.PublicKey).Hex(), PricePerSecond: "0", ChainID: DefaultChainID}
+	hash, err := r.Hash()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(hash, key) // v = 0/1
+	require.NoError(t, err)
+	r.Signature = hex.EncodeToString(sig)
+
+	assert.NoError(t, Verify(r))
+}
+
+func TestReceipt_RejectsInvalidFields(t *testing.T) {
+	_, err := (&Receipt{Provider: "not-an-address", PricePerSecond: "1"}).Hash()
+	assert.ErrorIs(t, err, ErrInvalidReceipt)
+
+	_, err = (&Receipt{Provider: "0x71C7656EC7ab88b098defB751B7401B5f6d8976F", PricePerSecond: "1.5"}).Hash()
+	assert.ErrorIs(t, err, ErrInvalidReceipt)
+}
+
+func TestIssuer_NumbersStoresAndFinalizes(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "receipts.jsonl"))
+	issuer := NewIssuer(testSigner(t), store, DefaultChainID)
+	start := time.Unix(1700000000, 0)
+
+	first, err := issuer.Issue(Usage{SessionID: "s1", StartedAt: start, EndedAt: start.Add(90 * time.Second), GPUCount: 2, PricePerSecond: "5"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), first.Sequence)
+	assert.Equal(t, uint64(180), first.GPUSeconds)
+	assert.False(t, first.Final)
+
+	final, err := issuer.Issue(Usage{SessionID: "s1", StartedAt: start, EndedAt: start.Add(time.Hour), GPUCount: 2, PricePerSecond: "5", Final: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), final.Sequence)
+	assert.Equal(t, uint64(7200), final.GPUSeconds)
+	assert.NoError(t, Verify(*final))
+
+	stored, err := store.Load("s1")
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, *final, stored[1])
+
+	// A restarted node continues the sequence from the store
+	restarted := NewIssuer(testSigner(t), store, DefaultChainID)
+	next, err := restarted.Issue(Usage{SessionID: "s1", StartedAt: start, EndedAt: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), next.Sequence)
+	assert.Equal(t, "0", next.PricePerSecond)
+
+	_, err = issuer.Issue(Usage{SessionID: "s2", StartedAt: start, EndedAt: start.Add(-time.Second)})
+	assert.ErrorIs(t, err, ErrInvalidReceipt)
+}
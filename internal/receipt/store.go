@@ -0,0 +1,80 @@
+package receipt
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps receipts on local disk as JSON lines so the provider holds
+// its own copy of everything sent to the Hub
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a store writing to path. The file and its directory
+// are created on first use, readable only by the node user.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save appends a receipt
+func (s *FileStore) Save(r Receipt) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create receipt directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open receipt store: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write receipt: %w", err)
+	}
+	return nil
+}
+
+// Load returns the stored receipts of a session in the order they were issued
+func (s *FileStore) Load(sessionID string) ([]Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open receipt store: %w", err)
+	}
+	defer f.Close()
+
+	var out []Receipt
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Receipt
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // Skip a torn write rather than losing the rest
+		}
+		if r.SessionID == sessionID {
+			out = append(out, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read receipt store: %w", err)
+	}
+	return out, nil
+}
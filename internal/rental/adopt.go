@@ -0,0 +1,132 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// AdoptRentals re-tracks rental containers left running by a previous node
+// process (a detach shutdown). Running containers become running rentals
+// again with their ports reserved, so status, metering, stop and cleanup work
+// as before. Rental containers that are no longer running are removed: their
+// cleanup was cut short by the restart. Returns the adopted rentals; errors
+// for individual containers are joined.
+func (re *RentalExecutor) AdoptRentals(ctx context.Context) ([]*RentalState, error) {
+	found, err := re.docker.ListRentalContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var adopted []*RentalState
+	var errs []error
+	for _, rc := range found {
+		re.mu.RLock()
+		_, tracked := re.activeRentals[rc.SessionID]
+		re.mu.RUnlock()
+		if tracked {
+			continue
+		}
+
+		// Exports outlive their rental only when the node restarted mid-grace
+		if rc.Export {
+			if err := re.docker.RemoveExport(ctx, rc.SessionID); err != nil {
+				errs = append(errs, fmt.Errorf("session %s: %w", rc.SessionID, err))
+			}
+			continue
+		}
+
+		if rc.State != "running" {
+			if err := re.docker.RemoveContainer(ctx, rc.ContainerID, true); err != nil {
+				errs = append(errs, fmt.Errorf("session %s: %w", rc.SessionID, err))
+				continue
+			}
+			if rc.Labels[container.LabelNetwork] != "" {
+				_ = re.docker.RemoveRentalNetwork(ctx, rc.SessionID)
+			}
+			if rc.Labels[container.LabelWorkspace] != "" {
+				_ = re.docker.RemoveWorkspace(ctx, rc.SessionID)
+			}
+			continue
+		}
+
+		state, err := re.adopt(rc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", rc.SessionID, err))
+		}
+		adopted = append(adopted, copyState(state))
+	}
+	return adopted, errors.Join(errs...)
+}
+
+// adopt tracks a running rental container from its labels. The rental is
+// tracked even if its ports can't be reserved, since the container holds them
+// regardless; the reservation error is returned.
+func (re *RentalExecutor) adopt(rc container.RentalContainer) (*RentalState, error) {
+	diskQuota, _ := strconv.ParseInt(rc.Labels[container.LabelDiskQuota], 10, 64)
+	mode, err := container.ParseAccessMode(rc.Labels[container.LabelAccessMode])
+	if err != nil {
+		mode = container.AccessModeSSH
+	}
+	policy, err := ParseRestartPolicy(rc.Labels[container.LabelRestartPolicy])
+	if err != nil {
+		policy = re.restartPolicy
+	}
+	startedAt, err := time.Parse(time.RFC3339, rc.Labels[container.LabelStartedAt])
+	if err != nil {
+		startedAt = rc.Created // Created before the label existed
+	}
+
+	state := &RentalState{
+		SessionID:       rc.SessionID,
+		ContainerID:     rc.ContainerID,
+		Image:           rc.Image,
+		GPUDeviceID:     rc.Labels[container.LabelGPUDeviceID],
+		SSHPort:         rc.SSHPort,
+		StartedAt:       startedAt,
+		DiskQuotaBytes:  diskQuota,
+		NetworkName:     rc.Labels[container.LabelNetwork],
+		WorkspaceVolume: rc.Labels[container.LabelWorkspace],
+		SecurityProfile: rc.Labels[container.LabelSecurity],
+		RuntimeClass:    rc.Labels[container.LabelRuntimeClass],
+		CPUSet:          rc.Labels[container.LabelCPUSet],
+		MemNodes:        rc.Labels[container.LabelMemNodes],
+		Ports:           rc.Ports,
+		AccessMode:      mode,
+		PricePerSecond:  rc.Labels[container.LabelPricePerSec],
+		RestartPolicy:   policy,
+	}
+
+	re.mu.Lock()
+	ev, _ := setPhase(state, PhaseRunning, "adopted after node restart")
+	re.activeRentals[rc.SessionID] = state
+	re.mu.Unlock()
+	re.emit(ev)
+
+	var errs []error
+	if err := re.reserveCPUs(rc); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reserve cpus: %w", err))
+	}
+	if err := re.reserveGPUs(rc); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reserve gpus: %w", err))
+	} else if re.gpus != nil && state.GPUDeviceID != "" {
+		// Count the GPUs actually held, e.g. for a rental labelled "all"
+		re.mu.Lock()
+		state.GPUDeviceID = strings.Join(re.gpus.Assigned(rc.SessionID), ",")
+		re.mu.Unlock()
+	}
+	for _, port := range state.hostPorts() {
+		if port == 0 {
+			continue
+		}
+		if err := re.portManager.Reserve(port, rc.SessionID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reserve port %d: %w", port, err))
+		}
+	}
+	return state, errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestStartRental_LabelsContainerForAdoption(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:      "session-123",
+		GPUDeviceID:    "GPU-aaa",
+		AccessMode:     container.AccessModeJupyter,
+		DiskQuotaBytes: 1 << 30,
+		PricePerSecond: "100",
+		RestartPolicy:  &RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 2},
+	})
+	require.NoError(t, err)
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.CreateCalls, 1)
+	assert.Equal(t, map[string]string{
+		container.LabelGPUDeviceID:   "GPU-aaa",
+		container.LabelAccessMode:    "jupyter",
+		container.LabelDiskQuota:     "1073741824",
+		container.LabelNetwork:       "",
+		container.LabelPricePerSec:   "100",
+		container.LabelRestartPolicy: "on-failure:2",
+		container.LabelWorkspace:     "wl-rental-session-123-workspace",
+		container.LabelCPUSet:        "",
+		container.LabelMemNodes:      "",
+		container.LabelSecurity:      "",
+		container.LabelRuntimeClass:  "default",
+		container.LabelStartedAt:     state.StartedAt.Format(time.RFC3339),
+	}, mockDocker.CreateCalls[0].Labels)
+}
+
+func TestAdoptRentals_TracksRunningAndRemovesStopped(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{
+			{
+				ContainerID: "container-1",
+				SessionID:   "session-1",
+				Image:       "nvidia/cuda:12.1.1-runtime-ubuntu22.04",
+				State:       "running",
+				Created:     created,
+				SSHPort:     30001,
+				Ports:       []container.PortMapping{{ContainerPort: 8888, HostPort: 30002, Protocol: "tcp"}},
+				Labels: map[string]string{
+					container.LabelSessionID:     "session-1",
+					container.LabelGPUDeviceID:   "GPU-aaa",
+					container.LabelAccessMode:    "jupyter",
+					container.LabelDiskQuota:     "1073741824",
+					container.LabelNetwork:       "wl-rental-session-1",
+					container.LabelPricePerSec:   "100",
+					container.LabelRestartPolicy: "on-failure:2",
+					container.LabelWorkspace:     "wl-rental-session-1-workspace",
+					container.LabelSecurity:      "strict",
+					container.LabelRuntimeClass:  "sandboxed",
+				},
+			},
+			{
+				ContainerID: "container-2",
+				SessionID:   "session-2",
+				State:       "exited",
+				Labels: map[string]string{
+					container.LabelNetwork:   "wl-rental-session-2",
+					container.LabelWorkspace: "wl-rental-session-2-workspace",
+				},
+			},
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, time.Minute)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	adopted, err := executor.AdoptRentals(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, adopted, 1)
+
+	state, err := executor.GetRentalStatus("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+	assert.Equal(t, "container-1", state.ContainerID)
+	assert.Equal(t, "GPU-aaa", state.GPUDeviceID)
+	assert.Equal(t, 30001, state.SSHPort)
+	assert.Equal(t, created, state.StartedAt)
+	assert.Equal(t, int64(1<<30), state.DiskQuotaBytes)
+	assert.Equal(t, "wl-rental-session-1", state.NetworkName)
+	assert.Equal(t, "wl-rental-session-1-workspace", state.WorkspaceVolume)
+	assert.Equal(t, "strict", state.SecurityProfile)
+	assert.Equal(t, "sandboxed", state.RuntimeClass)
+	assert.Equal(t, container.AccessModeJupyter, state.AccessMode)
+	assert.Equal(t, "100", state.PricePerSecond)
+	assert.Equal(t, RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 2}, state.RestartPolicy)
+	assert.Equal(t, []int{30001, 30002}, mockPort.ReserveCalls)
+	assert.Equal(t, []Phase{PhaseRunning}, phasesOf(events))
+
+	_, err = executor.GetRentalStatus("session-2")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.Equal(t, []string{"container-2"}, mockDocker.RemoveCalls)
+	assert.Equal(t, []string{"session-2"}, mockDocker.NetworkRemoveCalls)
+	assert.Equal(t, []string{"session-2"}, mockDocker.WorkspaceRemoveCalls)
+
+	// Adopted rentals stop like any other
+	require.NoError(t, executor.StopRental(context.Background(), "session-1"))
+	assert.Equal(t, []string{"container-1"}, mockDocker.StopCalls)
+}
+
+func TestAdoptRentals_KeepsLabelledStartTime(t *testing.T) {
+	started := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{{
+			ContainerID: "container-1",
+			SessionID:   "session-1",
+			State:       "running",
+			Created:     started.Add(6 * time.Hour), // Rebuilt since
+			Labels:      map[string]string{container.LabelStartedAt: started.Format(time.RFC3339)},
+		}},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+
+	_, err := executor.AdoptRentals(context.Background())
+	require.NoError(t, err)
+
+	state, err := executor.GetRentalStatus("session-1")
+	require.NoError(t, err)
+	assert.True(t, started.Equal(state.StartedAt))
+}
@@ -0,0 +1,49 @@
+package rental
+
+import (
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/topology"
+)
+
+// CPUAllocator assigns rentals dedicated CPUs on their GPUs' NUMA nodes.
+// topology.Allocator satisfies it.
+type CPUAllocator interface {
+	Allocate(sessionID, gpuDeviceID string, count int) (topology.Assignment, error)
+	Reserve(sessionID string, cpus []int) error
+	Release(sessionID string)
+}
+
+// WithCPUPinning pins each rental to CPUCount dedicated CPUs (and their
+// memory nodes) next to its GPUs instead of only setting a CPU quota
+func (re *RentalExecutor) WithCPUPinning(cpus CPUAllocator) *RentalExecutor {
+	re.cpus = cpus
+	return re
+}
+
+// allocateCPUs assigns a rental's cpuset. Rentals without a CPU count, or
+// executors without pinning, stay unpinned.
+func (re *RentalExecutor) allocateCPUs(req StartRentalRequest) (topology.Assignment, error) {
+	if re.cpus == nil || req.CPUCount <= 0 {
+		return topology.Assignment{}, nil
+	}
+	return re.cpus.Allocate(req.SessionID, req.GPUDeviceID, int(req.CPUCount))
+}
+
+// releaseCPUs frees a rental's cpuset
+func (re *RentalExecutor) releaseCPUs(sessionID string) {
+	if re.cpus != nil {
+		re.cpus.Release(sessionID)
+	}
+}
+
+// reserveCPUs re-records the cpuset of an adopted rental from its labels
+func (re *RentalExecutor) reserveCPUs(rc container.RentalContainer) error {
+	if re.cpus == nil || rc.Labels[container.LabelCPUSet] == "" {
+		return nil
+	}
+	cpus, err := topology.ParseCPUList(rc.Labels[container.LabelCPUSet])
+	if err != nil {
+		return err
+	}
+	return re.cpus.Reserve(rc.SessionID, cpus)
+}
@@ -0,0 +1,99 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/topology"
+)
+
+// MockCPUAllocator implements CPUAllocator for testing
+type MockCPUAllocator struct {
+	Assignment topology.Assignment
+	Err        error
+
+	AllocateCalls []string // "sessionID gpuDeviceID"
+	Reserved      map[string][]int
+	Released      []string
+}
+
+func (m *MockCPUAllocator) Allocate(sessionID, gpuDeviceID string, count int) (topology.Assignment, error) {
+	m.AllocateCalls = append(m.AllocateCalls, sessionID+" "+gpuDeviceID)
+	return m.Assignment, m.Err
+}
+
+func (m *MockCPUAllocator) Reserve(sessionID string, cpus []int) error {
+	if m.Reserved == nil {
+		m.Reserved = make(map[string][]int)
+	}
+	m.Reserved[sessionID] = cpus
+	return nil
+}
+
+func (m *MockCPUAllocator) Release(sessionID string) {
+	m.Released = append(m.Released, sessionID)
+}
+
+func TestStartRental_PinsCPUs(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	cpus := &MockCPUAllocator{Assignment: topology.Assignment{CPUs: []int{8, 9, 10, 11}, Mems: []int{1}}}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 10*time.Millisecond).WithCPUPinning(cpus)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", GPUDeviceID: "GPU-bbb", CPUCount: 4})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"session-123 GPU-bbb"}, cpus.AllocateCalls)
+	require.Len(t, mockDocker.CreateCalls, 1)
+	assert.Equal(t, "8-11", mockDocker.CreateCalls[0].CPUSet)
+	assert.Equal(t, "1", mockDocker.CreateCalls[0].MemNodes)
+	assert.Equal(t, "8-11", mockDocker.CreateCalls[0].Labels[container.LabelCPUSet])
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "8-11", state.CPUSet)
+	assert.Equal(t, "1", state.MemNodes)
+
+	// CPUs are released once the container is stopped
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Contains(t, cpus.Released, "session-123")
+}
+
+func TestStartRental_FailsWithoutFreeCPUs(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	cpus := &MockCPUAllocator{Err: topology.ErrInsufficientCPUs}
+	executor := NewRentalExecutor(mockDocker, mockPort, time.Minute).WithCPUPinning(cpus)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", CPUCount: 4})
+
+	assert.True(t, errors.Is(err, topology.ErrInsufficientCPUs))
+	assert.Empty(t, mockDocker.CreateCalls)
+	assert.Len(t, mockPort.ReleaseCalls, 1)
+}
+
+func TestAdoptRentals_ReservesCPUSet(t *testing.T) {
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{{
+			ContainerID: "container-1",
+			SessionID:   "session-1",
+			State:       "running",
+			Labels:      map[string]string{container.LabelCPUSet: "8-11", container.LabelMemNodes: "1"},
+		}},
+	}
+	cpus := &MockCPUAllocator{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithCPUPinning(cpus)
+
+	_, err := executor.AdoptRentals(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"session-1": {8, 9, 10, 11}}, cpus.Reserved)
+	state, err := executor.GetRentalStatus("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, "8-11", state.CPUSet)
+}
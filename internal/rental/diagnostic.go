@@ -0,0 +1,179 @@
+package rental
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+var (
+	ErrDiagnosticNotAllowed = errors.New("diagnostic command not allowed")
+	ErrRentalNotRunning     = errors.New("rental is not running")
+)
+
+// DefaultDiagnosticCommands is the allowlist used when the operator doesn't
+// configure one. Support staff pick a command by name; arguments are fixed.
+var DefaultDiagnosticCommands = map[string][]string{
+	"gpu":       {"nvidia-smi"},
+	"disk":      {"df", "-h"},
+	"memory":    {"free", "-m"},
+	"processes": {"ps", "aux"},
+}
+
+const (
+	defaultDiagnosticTimeout   = 30 * time.Second
+	defaultDiagnosticMaxOutput = 64 * 1024
+)
+
+// DiagnosticRequest names an allowlisted command and who asked for it
+type DiagnosticRequest struct {
+	SessionID   string
+	Command     string // Allowlist name, e.g. "gpu"
+	RequestedBy string // Support staff identity from the Hub (recorded in the audit trail)
+}
+
+// DiagnosticAudit is one audit trail record. Every invocation is recorded,
+// including rejected ones.
+type DiagnosticAudit struct {
+	Time        time.Time `json:"time"`
+	SessionID   string    `json:"session_id"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Command     string    `json:"command"`
+	Argv        []string  `json:"argv,omitempty"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	ExitCode    int       `json:"exit_code"`
+	DurationMS  int64     `json:"duration_ms"`
+	OutputBytes int       `json:"output_bytes"`
+	Truncated   bool      `json:"truncated,omitempty"`
+	TimedOut    bool      `json:"timed_out,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AuditLogger records diagnostic invocations
+type AuditLogger interface {
+	Record(entry DiagnosticAudit) error
+}
+
+// FileAuditLog appends audit records to a file as JSON lines
+type FileAuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileAuditLog creates an audit log writing to path. The file and its
+// directory are created on first use, readable only by the node user.
+func NewFileAuditLog(path string) *FileAuditLog {
+	return &FileAuditLog{path: path}
+}
+
+// Record appends one entry
+func (l *FileAuditLog) Record(entry DiagnosticAudit) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// WithDiagnostics sets the diagnostic command allowlist, per-command timeout,
+// output cap (per stream) and the audit trail. A nil commands map keeps the
+// defaults; a zero timeout or cap keeps the default.
+func (re *RentalExecutor) WithDiagnostics(commands map[string][]string, timeout time.Duration, maxOutput int64, audit AuditLogger) *RentalExecutor {
+	if commands != nil {
+		re.diagCommands = commands
+	}
+	if timeout > 0 {
+		re.diagTimeout = timeout
+	}
+	if maxOutput > 0 {
+		re.diagMaxOutput = maxOutput
+	}
+	re.audit = audit
+	return re
+}
+
+// RunDiagnostic executes an allowlisted command in a running rental's
+// container and records the invocation in the audit trail. If the audit
+// record can't be written the output is withheld.
+func (re *RentalExecutor) RunDiagnostic(ctx context.Context, req DiagnosticRequest) (*container.ExecResult, error) {
+	entry := DiagnosticAudit{
+		Time:        time.Now().UTC(),
+		SessionID:   req.SessionID,
+		Command:     req.Command,
+		RequestedBy: req.RequestedBy,
+		ExitCode:    -1,
+	}
+
+	result, err := re.runDiagnostic(ctx, req, &entry)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	entry.DurationMS = time.Since(entry.Time).Milliseconds()
+
+	if re.audit != nil {
+		if auditErr := re.audit.Record(entry); auditErr != nil {
+			return nil, fmt.Errorf("failed to record diagnostic audit: %w", auditErr)
+		}
+	}
+	return result, err
+}
+
+func (re *RentalExecutor) runDiagnostic(ctx context.Context, req DiagnosticRequest, entry *DiagnosticAudit) (*container.ExecResult, error) {
+	argv, allowed := re.diagCommands[req.Command]
+	if !allowed {
+		return nil, fmt.Errorf("%w: %q", ErrDiagnosticNotAllowed, req.Command)
+	}
+	entry.Argv = argv
+
+	re.mu.RLock()
+	state, exists := re.activeRentals[req.SessionID]
+	var phase Phase
+	if exists {
+		phase = state.Phase
+		entry.ContainerID = state.ContainerID
+	}
+	re.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+	if phase != PhaseRunning {
+		return nil, fmt.Errorf("%w: %s", ErrRentalNotRunning, phase)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, re.diagTimeout)
+	defer cancel()
+
+	result, err := re.docker.Exec(ctx, entry.ContainerID, argv, re.diagMaxOutput)
+	if err != nil {
+		return nil, err
+	}
+	entry.ExitCode = result.ExitCode
+	entry.OutputBytes = len(result.Stdout) + len(result.Stderr)
+	entry.Truncated = result.Truncated
+	entry.TimedOut = result.TimedOut
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package rental
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// memoryAudit collects audit records in memory
+type memoryAudit struct {
+	entries []DiagnosticAudit
+	err     error
+}
+
+func (m *memoryAudit) Record(entry DiagnosticAudit) error {
+	m.entries = append(m.entries, entry)
+	return m.err
+}
+
+func TestRunDiagnostic_RunsAllowlistedCommand(t *testing.T) {
+	var gotMax int64
+	mockDocker := &MockDockerService{
+		execFunc: func(ctx context.Context, containerID string, cmd []string, maxBytes int64) (*container.ExecResult, error) {
+			gotMax = maxBytes
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+			return &container.ExecResult{ExitCode: 0, Stdout: "GPU 0: NVIDIA A100\n"}, nil
+		},
+	}
+	audit := &memoryAudit{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+	executor.WithDiagnostics(nil, 5*time.Second, 1024, audit)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	result, err := executor.RunDiagnostic(context.Background(), DiagnosticRequest{
+		SessionID: "session-123", Command: "gpu", RequestedBy: "support@worldland",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "GPU 0: NVIDIA A100\n", result.Stdout)
+	assert.Equal(t, [][]string{{"nvidia-smi"}}, mockDocker.ExecCalls)
+	assert.Equal(t, int64(1024), gotMax)
+
+	require.Len(t, audit.entries, 1)
+	entry := audit.entries[0]
+	assert.Equal(t, "session-123", entry.SessionID)
+	assert.Equal(t, "container-123", entry.ContainerID)
+	assert.Equal(t, "gpu", entry.Command)
+	assert.Equal(t, []string{"nvidia-smi"}, entry.Argv)
+	assert.Equal(t, "support@worldland", entry.RequestedBy)
+	assert.Equal(t, 0, entry.ExitCode)
+	assert.Equal(t, len("GPU 0: NVIDIA A100\n"), entry.OutputBytes)
+	assert.Empty(t, entry.Error)
+}
+
+func TestRunDiagnostic_RejectsUnknownCommand(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	audit := &memoryAudit{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+	executor.WithDiagnostics(map[string][]string{"disk": {"df", "-h"}}, 0, 0, audit)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	_, err = executor.RunDiagnostic(context.Background(), DiagnosticRequest{SessionID: "session-123", Command: "gpu"})
+
+	assert.ErrorIs(t, err, ErrDiagnosticNotAllowed)
+	assert.Empty(t, mockDocker.ExecCalls)
+	require.Len(t, audit.entries, 1)
+	assert.Contains(t, audit.entries[0].Error, "not allowed")
+}
+
+func TestRunDiagnostic_RequiresRunningRental(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.RunDiagnostic(context.Background(), DiagnosticRequest{SessionID: "nonexistent", Command: "gpu"})
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+
+	_, err = executor.RunDiagnostic(context.Background(), DiagnosticRequest{SessionID: "session-123", Command: "gpu"})
+	assert.ErrorIs(t, err, ErrRentalNotRunning)
+}
+
+func TestRunDiagnostic_WithholdsOutputWhenAuditFails(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+	executor.WithDiagnostics(nil, 0, 0, &memoryAudit{err: errors.New("disk full")})
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	result, err := executor.RunDiagnostic(context.Background(), DiagnosticRequest{SessionID: "session-123", Command: "disk"})
+
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "disk full")
+}
+
+func TestFileAuditLog_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "diagnostics.jsonl")
+	log := NewFileAuditLog(path)
+
+	require.NoError(t, log.Record(DiagnosticAudit{SessionID: "s1", Command: "gpu"}))
+	require.NoError(t, log.Record(DiagnosticAudit{SessionID: "s2", Command: "disk", Error: "boom"}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []DiagnosticAudit
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e DiagnosticAudit
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	require.Len(t, entries, 2)
+	assert.Equal(t, "s1", entries[0].SessionID)
+	assert.Equal(t, "boom", entries[1].Error)
+}
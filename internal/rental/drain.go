@@ -0,0 +1,116 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDraining is returned by StartRental while the node is draining
+var ErrDraining = errors.New("node is draining")
+
+// drainPollInterval is how often WaitForRentals rechecks live rentals
+const drainPollInterval = time.Second
+
+// SetDraining turns drain mode on or off. A draining node rejects new
+// rentals while existing ones run until they are stopped.
+func (re *RentalExecutor) SetDraining(draining bool) {
+	re.mu.Lock()
+	re.draining = draining
+	re.mu.Unlock()
+}
+
+// Draining reports whether the node is rejecting new rentals
+func (re *RentalExecutor) Draining() bool {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+	return re.draining
+}
+
+// LiveRentals returns how many rentals are starting, running or stopping.
+// Stopped rentals waiting for cleanup don't count.
+func (re *RentalExecutor) LiveRentals() int {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	n := 0
+	for _, state := range re.activeRentals {
+		if state.Phase.Starting() || state.Phase == PhaseRunning || state.Phase == PhaseStopping {
+			n++
+		}
+	}
+	return n
+}
+
+// WaitForRentals blocks until no rental is live or ctx is done
+func (re *RentalExecutor) WaitForRentals(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for re.LiveRentals() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// StopAll stops every starting or running rental, e.g. when a drain hits its
+// deadline. The node is going away, so there is no grace period: containers,
+// networks, workspaces and ports are released before StopAll returns, and
+// aborted starts have finished cleaning up unless ctx is done first. Errors
+// of individual stops are joined.
+func (re *RentalExecutor) StopAll(ctx context.Context) error {
+	re.mu.RLock()
+	var sessions []string
+	starting := make(map[string]*RentalState)
+	for id, state := range re.activeRentals {
+		if state.Phase.Starting() {
+			starting[id] = state
+		}
+		if state.Phase.Starting() || state.Phase == PhaseRunning {
+			sessions = append(sessions, id)
+		}
+	}
+	re.mu.RUnlock()
+
+	var errs []error
+	for _, id := range sessions {
+		if err := re.stopRental(ctx, id, false); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if err := re.waitForAborts(ctx, starting); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// waitForAborts blocks until the given starting rentals have been cleaned
+// up by their StartRental calls
+func (re *RentalExecutor) waitForAborts(ctx context.Context, starting map[string]*RentalState) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		re.mu.RLock()
+		pending := 0
+		for id, state := range starting {
+			if re.activeRentals[id] == state && state.Phase != PhaseFailed {
+				pending++
+			}
+		}
+		re.mu.RUnlock()
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestStartRental_RejectedWhileDraining(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+
+	executor.SetDraining(true)
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+
+	assert.ErrorIs(t, err, ErrDraining)
+	assert.True(t, executor.Draining())
+	assert.Empty(t, mockDocker.CreateCalls)
+
+	executor.SetDraining(false)
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	assert.NoError(t, err)
+}
+
+func TestWaitForRentals_ReturnsWhenRentalsEnd(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, time.Minute)
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	executor.SetDraining(true)
+
+	// Deadline reached with the rental still running
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, executor.WaitForRentals(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 1, executor.LiveRentals())
+
+	// A stopped rental awaiting cleanup no longer counts
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	assert.Equal(t, 0, executor.LiveRentals())
+	assert.NoError(t, executor.WaitForRentals(context.Background()))
+}
+
+func TestStopAll_StopsRunningRentals(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+	for i, id := range []string{"session-1", "session-2"} {
+		cid := []string{"container-1", "container-2"}[i]
+		mockDocker.createContainerFunc = func(ctx context.Context, cfg container.ContainerConfig) (string, error) {
+			return cid, nil
+		}
+		_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: id})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, executor.StopAll(context.Background()))
+
+	assert.ElementsMatch(t, []string{"container-1", "container-2"}, mockDocker.StopCalls)
+	assert.Equal(t, 0, executor.LiveRentals())
+}
+
+func TestStopAll_ReleasesResourcesBeforeReturning(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, time.Hour)
+	executor.WithDataExport(true)
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	require.NoError(t, executor.StopAll(context.Background()))
+
+	// No grace period and no export: everything is gone on return
+	assert.Empty(t, mockDocker.ExportCalls)
+	assert.Len(t, mockDocker.RemoveCalls, 1)
+	assert.Equal(t, []int{30001}, mockPort.ReleaseCalls)
+	_, err = executor.GetRentalStatus("session-123")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
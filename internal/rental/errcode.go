@@ -0,0 +1,64 @@
+package rental
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/inventory"
+	"github.com/worldland/worldland-node/internal/topology"
+)
+
+// ErrorCode maps a start or rebuild failure to the code reported to the Hub
+// and API clients and the HTTP status for it. Returns "" and
+// http.StatusInternalServerError for errors without a specific code.
+func ErrorCode(err error) (string, int) {
+	var policyErr *container.ImagePolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return policyErr.Code, http.StatusForbidden
+	case errors.Is(err, ErrSessionAlreadyActive):
+		return "RENTAL_EXISTS", http.StatusConflict
+	case errors.Is(err, ErrInvalidExposedPort):
+		return "INVALID_EXPOSED_PORT", http.StatusBadRequest
+	case errors.Is(err, container.ErrInvalidAccessMode):
+		return "INVALID_ACCESS_MODE", http.StatusBadRequest
+	case errors.Is(err, ErrEnvNotAllowed):
+		return "ENV_NOT_ALLOWED", http.StatusBadRequest
+	case errors.Is(err, ErrInvalidEnv), errors.Is(err, ErrInvalidWorkDir), errors.Is(err, ErrInvalidInitCommand):
+		return "INVALID_RUNTIME_SPEC", http.StatusBadRequest
+	case errors.Is(err, ErrInvalidDiskQuota):
+		return "INVALID_DISK_QUOTA", http.StatusBadRequest
+	case errors.Is(err, ErrInvalidIPCSettings):
+		return "INVALID_IPC_SETTINGS", http.StatusBadRequest
+	case errors.Is(err, ErrContainerNotHealthy), errors.Is(err, ErrAccessNotReady):
+		return "CONTAINER_NOT_READY", http.StatusServiceUnavailable
+	case errors.Is(err, ErrStartAborted):
+		return "RENTAL_STOPPED", http.StatusConflict
+	case errors.Is(err, ErrDraining):
+		return "NODE_DRAINING", http.StatusServiceUnavailable
+	case errors.Is(err, ErrSessionNotFound):
+		return "RENTAL_NOT_FOUND", http.StatusNotFound
+	case errors.Is(err, ErrRentalNotRunning):
+		return "RENTAL_NOT_RUNNING", http.StatusConflict
+	case errors.Is(err, ErrRebuildUnavailable):
+		return "REBUILD_UNAVAILABLE", http.StatusConflict
+	case errors.Is(err, topology.ErrInsufficientCPUs):
+		return "INSUFFICIENT_CPUS", http.StatusServiceUnavailable
+	case errors.Is(err, container.ErrUserNamespaceRequired):
+		return "USERNS_REQUIRED", http.StatusServiceUnavailable
+	case errors.Is(err, ErrUnknownRuntimeClass):
+		return "UNKNOWN_RUNTIME_CLASS", http.StatusBadRequest
+	case errors.Is(err, ErrRuntimeUnavailable):
+		return "RUNTIME_UNAVAILABLE", http.StatusServiceUnavailable
+	case errors.Is(err, container.ErrUnknownGPU), errors.Is(err, container.ErrAllGPUs), errors.Is(err, inventory.ErrUnknownUnit):
+		return "UNKNOWN_GPU", http.StatusBadRequest
+	case errors.Is(err, inventory.ErrMIGParent):
+		return "GPU_MIG_ENABLED", http.StatusBadRequest
+	case errors.Is(err, inventory.ErrUnitInUse):
+		return "GPU_IN_USE", http.StatusConflict
+	case errors.Is(err, container.ErrCDIDeviceNotFound):
+		return "CDI_DEVICE_NOT_FOUND", http.StatusServiceUnavailable
+	}
+	return "", http.StatusInternalServerError
+}
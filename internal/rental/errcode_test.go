@@ -0,0 +1,32 @@
+package rental
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/inventory"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   string
+		status int
+	}{
+		{&container.ImagePolicyError{Code: container.ImageCodeDenied, Image: "x"}, container.ImageCodeDenied, http.StatusForbidden},
+		{fmt.Errorf("start: %w", ErrSessionAlreadyActive), "RENTAL_EXISTS", http.StatusConflict},
+		{fmt.Errorf("%w: -1", ErrInvalidDiskQuota), "INVALID_DISK_QUOTA", http.StatusBadRequest},
+		{inventory.ErrUnitInUse, "GPU_IN_USE", http.StatusConflict},
+		{ErrRentalNotRunning, "RENTAL_NOT_RUNNING", http.StatusConflict},
+		{errors.New("docker exploded"), "", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		code, status := ErrorCode(tt.err)
+		assert.Equal(t, tt.code, code, tt.err.Error())
+		assert.Equal(t, tt.status, status, tt.err.Error())
+	}
+}
@@ -0,0 +1,28 @@
+package rental
+
+// Event types emitted by the executor for changes the Hub should hear about
+// outside of a command/ack exchange.
+const (
+	EventDataExport        = "rental_data_export"
+	EventDiskQuotaExceeded = "rental_disk_quota_exceeded"
+	EventImagePullProgress = "image_pull_progress"
+	EventPhaseChanged      = "rental_phase_changed"
+	EventRentalFailed      = "rental_failed"
+	EventRentalRebuilt     = "rental_rebuilt"
+	EventRentalRestarted   = "rental_restarted"
+	EventUsageFinal        = "rental_usage_final"
+)
+
+// Event is an out-of-band rental notification (forwarded to the Hub by the node daemon)
+type Event struct {
+	Type      string
+	SessionID string
+	Payload   map[string]interface{}
+}
+
+// emit delivers an event to the OnEvent hook if one is registered
+func (re *RentalExecutor) emit(ev Event) {
+	if re.OnEvent != nil {
+		re.OnEvent(ev)
+	}
+}
@@ -2,8 +2,13 @@ package rental
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,24 +19,86 @@ var (
 	ErrSessionAlreadyActive = errors.New("session already has active rental")
 	ErrSessionNotFound      = errors.New("rental session not found")
 	ErrContainerNotHealthy  = errors.New("container failed health check within timeout")
+	ErrInvalidExposedPort   = errors.New("invalid exposed port")
 )
 
+// maxExposedPorts limits additional published ports per rental
+const maxExposedPorts = 8
+
 // RentalState tracks an active rental's runtime state
 type RentalState struct {
 	SessionID   string
 	ContainerID string
+	Image       string // Admitted image reference (digest-pinned when the policy resolves tags)
+	GPUDeviceID string // Assigned GPU UUIDs or indexes
 	SSHPort     int
 	StartedAt   time.Time
 	StoppedAt   *time.Time
+
+	DiskQuotaBytes int64 // Writable layer quota (0 = unlimited)
+	DiskUsageBytes int64 // Last measured writable layer size
+	QuotaExceeded  bool  // Set once usage exceeded the quota
+
+	NetworkName     string // Dedicated Docker network (empty when isolation is disabled)
+	CPUSet          string // Dedicated CPUs, e.g. "8-11" (empty = unpinned)
+	MemNodes        string // NUMA memory nodes of the CPUs
+	WorkspaceVolume string // Volume mounted at the rental user's home, kept across rebuilds
+	SecurityProfile string // Name of the container security profile
+	RuntimeClass    string // Runtime class the container runs under (see WithRuntimes)
+
+	Ports []container.PortMapping // Additional published ports
+
+	AccessMode container.AccessMode // ssh, jupyter or code-server
+
+	PricePerSecond string // Wei per GPU second
+
+	RestartPolicy RestartPolicy // Applied when the container crashes (see CheckRentals)
+	Restarts      int           // Crash restarts so far
+
+	ExportUntil *time.Time // Read-only SFTP access to the stopped rental's files ends (see WithDataExport)
+
+	Pull *container.PullProgress // Image pull progress while the rental is starting
+
+	Phase         Phase             // Current lifecycle phase
+	History       []PhaseTransition // Every phase change, oldest first
+	FailureReason string            // Set when the rental entered the failed phase
+
+	cancelStart context.CancelFunc         // Aborts an in-flight StartRental or RebuildRental (see StopRental)
+	failedLogs  []byte                     // Output tail of a container that failed to start
+	spec        *container.ContainerConfig // Container config for rebuilds (nil when adopted)
+}
+
+// GPUCount returns how many GPUs are assigned to the rental
+func (s *RentalState) GPUCount() int {
+	if s.GPUDeviceID == "" {
+		return 0
+	}
+	return len(strings.Split(s.GPUDeviceID, ","))
+}
+
+// hostPorts returns every host port held by the rental, SSH first
+func (s *RentalState) hostPorts() []int {
+	ports := []int{s.SSHPort}
+	for _, p := range s.Ports {
+		ports = append(ports, p.HostPort)
+	}
+	return ports
 }
 
 // ConnectionInfo provides SSH connection details for the user
 type ConnectionInfo struct {
-	Host       string // Host IP or domain
-	Port       int    // SSH port
-	User       string // SSH username (ubuntu)
-	Command    string // Ready-to-use SSH command
+	Host        string // Host IP or domain
+	Port        int    // SSH port
+	User        string // SSH username (ubuntu)
+	Command     string // Ready-to-use SSH command
 	ContainerID string
+
+	Ports []container.PortMapping // Additional published ports
+
+	AccessMode  container.AccessMode
+	AccessPort  int    // Host port of the access service (SSH port for ssh)
+	AccessURL   string // Browser URL for jupyter/code-server (empty for ssh)
+	AccessToken string // Jupyter token / code-server password
 }
 
 // StartRentalRequest contains parameters for starting a rental
@@ -43,20 +110,59 @@ type StartRentalRequest struct {
 	MemoryBytes int64
 	CPUCount    int64
 	Host        string // Host address for SSH command (e.g., "provider.example.com")
+
+	DiskQuotaBytes int64                    // Writable layer quota (0 = executor default), at most the executor maximum
+	ScratchMounts  []container.ScratchMount // tmpfs scratch space
+
+	ShmSizeBytes int64              // /dev/shm size (0 = executor default, see WithIPCLimits)
+	Ulimits      []container.Ulimit // Override the executor's default ulimits
+	IPCMode      string             // Empty = executor default
+
+	RuntimeClass string // Empty = DefaultRuntimeClass
+
+	EgressPolicy *container.EgressPolicy // Per-rental restrictions on the executor default (nil = default as is)
+
+	ExposedPorts []container.PortMapping // Container ports to publish (HostPort is assigned)
+
+	AccessMode container.AccessMode // Empty = ssh
+
+	Env         container.Env // Renter environment variables (validated against the denylist)
+	InitCommand string        // Startup command run alongside the access service
+	WorkDir     string        // Absolute working directory
+
+	RegistryAuth *container.RegistryAuth // Private image credentials, used for the pull only
+
+	PricePerSecond string // Wei per GPU second, carried into usage receipts
+
+	RestartPolicy *RestartPolicy // nil = executor default
 }
 
 // DockerServiceInterface defines operations needed from Docker service
 type DockerServiceInterface interface {
+	PrepareImage(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error)
 	CreateContainer(ctx context.Context, cfg container.ContainerConfig) (string, error)
 	StartContainer(ctx context.Context, containerID string) error
 	StopContainer(ctx context.Context, containerID string, timeoutSeconds int) error
 	RemoveContainer(ctx context.Context, containerID string, force bool) error
 	InspectContainer(ctx context.Context, containerID string) (*container.ContainerInfo, error)
+	DiskUsage(ctx context.Context, containerID string) (int64, error)
+	ContainerLogs(ctx context.Context, containerID string, opts container.LogOptions, w io.Writer) error
+	Exec(ctx context.Context, containerID string, cmd []string, maxBytes int64) (*container.ExecResult, error)
+	ContainerStats(ctx context.Context, containerID string) (*container.ResourceStats, error)
+	ListRentalContainers(ctx context.Context) ([]container.RentalContainer, error)
+	StartExport(ctx context.Context, cfg container.ExportConfig) (string, error)
+	RemoveExport(ctx context.Context, sessionID string) error
+	CreateWorkspace(ctx context.Context, sessionID string) (string, error)
+	RemoveWorkspace(ctx context.Context, sessionID string) error
+	RenameContainer(ctx context.Context, containerID, name string) error
+	CreateRentalNetwork(ctx context.Context, sessionID string, policy container.EgressPolicy) (string, error)
+	RemoveRentalNetwork(ctx context.Context, sessionID string) error
 }
 
 // PortManagerInterface defines operations needed from port manager
 type PortManagerInterface interface {
 	Allocate(sessionID string) (int, error)
+	Reserve(port int, sessionID string) error
 	Release(port int) error
 }
 
@@ -65,10 +171,51 @@ type RentalExecutor struct {
 	docker         DockerServiceInterface
 	portManager    PortManagerInterface
 	mu             sync.RWMutex
-	activeRentals  map[string]*RentalState // sessionID -> RentalState
+	activeRentals  map[string]*RentalState // sessionID -> RentalState (every phase until cleaned)
 	gracePeriod    time.Duration           // Time before container cleanup
 	healthTimeout  time.Duration           // Max time to wait for health check
 	healthInterval time.Duration           // Interval between health checks
+
+	defaultDiskQuota    int64 // Applied when a request has no quota (see WithDiskQuota)
+	maxDiskQuota        int64 // Largest quota a rental may get (0 = no cap, see WithMaxDiskQuota)
+	stopOnQuotaExceeded bool  // Stop rentals over quota instead of only flagging them
+
+	isolateNetworks bool                   // Give each rental its own Docker network
+	egressPolicy    container.EgressPolicy // Default egress policy for isolated rentals
+
+	prober       ReadinessProber // Access service probe (nil = container health only)
+	readyTimeout time.Duration   // Max time to wait for the access service
+
+	envDenylist []string // Env patterns renters may not set (see WithEnvDenylist)
+
+	diagCommands  map[string][]string // Diagnostic allowlist (see WithDiagnostics)
+	diagTimeout   time.Duration
+	diagMaxOutput int64
+	audit         AuditLogger // Diagnostic audit trail (nil = not recorded)
+
+	usage          map[string]*UsageRecord // sessionID -> usage, kept past cleanup (see WithMetering)
+	gpuMetrics     GPUMetricsSource        // GPU samples for usage records (nil = container stats only)
+	usageRetention time.Duration
+
+	restartPolicy RestartPolicy // Default for rentals that don't request one
+
+	draining bool // Reject new rentals (see SetDraining)
+
+	dataExport bool // Serve stopped rentals' files during the grace period (see WithDataExport)
+
+	cpus CPUAllocator // NUMA-aware cpusets (nil = CPU quota only, see WithCPUPinning)
+
+	gpus GPUAllocator // GPU and MIG slice assignments (nil = untracked, see WithGPUAllocator)
+
+	ipcLimits IPCLimits // Shared memory, ulimit and IPC defaults and maxima
+
+	security container.SecurityProfile // Hardening applied to every rental container
+
+	runtimeClasses    map[string]container.Runtime // Runtimes rentals may ask for by class
+	availableRuntimes []string                     // Runtimes the Docker daemon advertises (nil = unchecked)
+
+	// OnEvent is called for out-of-band rental events (e.g. quota exceeded)
+	OnEvent func(ev Event)
 }
 
 // NewRentalExecutor creates a new rental executor
@@ -80,76 +227,297 @@ func NewRentalExecutor(docker DockerServiceInterface, portManager PortManagerInt
 		gracePeriod:    gracePeriod,
 		healthTimeout:  60 * time.Second, // Per RESEARCH.md Pattern 2
 		healthInterval: 2 * time.Second,
+		envDenylist:    DefaultEnvDenylist,
+		diagCommands:   DefaultDiagnosticCommands,
+		diagTimeout:    defaultDiagnosticTimeout,
+		diagMaxOutput:  defaultDiagnosticMaxOutput,
+		usage:          make(map[string]*UsageRecord),
+		usageRetention: defaultUsageRetention,
+		restartPolicy:  RestartPolicy{Mode: RestartNever},
 	}
 }
 
-// StartRental allocates port, creates container, starts it, waits for health, returns connection info
+// WithNetworkIsolation places each rental on its own Docker network with the
+// given default egress policy. Requests may override the policy.
+func (re *RentalExecutor) WithNetworkIsolation(policy container.EgressPolicy) *RentalExecutor {
+	re.isolateNetworks = true
+	re.egressPolicy = policy
+	return re
+}
+
+// WithSecurityProfile hardens every rental container with the given profile
+func (re *RentalExecutor) WithSecurityProfile(profile container.SecurityProfile) *RentalExecutor {
+	re.security = profile
+	return re
+}
+
+// StartRental allocates port, creates container, starts it, waits for health, returns connection info.
+// The rental is tracked from the moment it is accepted so duplicate starts and
+// stops that race the start are resolved against its phase.
 func (re *RentalExecutor) StartRental(ctx context.Context, req StartRentalRequest) (*ConnectionInfo, error) {
-	// Check for duplicate session
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Check for duplicate session; a failed rental may be retried once its
+	// container is gone
 	re.mu.Lock()
-	if _, exists := re.activeRentals[req.SessionID]; exists {
+	if re.draining {
+		re.mu.Unlock()
+		return nil, ErrDraining
+	}
+	if existing, exists := re.activeRentals[req.SessionID]; exists && (existing.Phase != PhaseFailed || existing.ContainerID != "") {
 		re.mu.Unlock()
 		return nil, ErrSessionAlreadyActive
 	}
+	state := &RentalState{SessionID: req.SessionID, Image: req.Image, cancelStart: cancel}
+	ev, _ := setPhase(state, PhasePending, "")
+	re.activeRentals[req.SessionID] = state
 	re.mu.Unlock()
+	re.emit(ev)
+
+	// Cleanup on failure (defer pattern)
+	var sshPort int
+	var containerID, networkName, workspace string
+	var ports []container.PortMapping
+	fail := func(err error) (*ConnectionInfo, error) {
+		if containerID != "" {
+			// Keep its output for diagnosis, then remove container if created
+			re.captureFailedLogs(state, containerID)
+			if err := re.docker.RemoveContainer(context.Background(), containerID, true); err == nil {
+				// The session may be retried now that its name is free
+				re.mu.Lock()
+				state.ContainerID = ""
+				re.mu.Unlock()
+			}
+		}
+		if workspace != "" {
+			_ = re.docker.RemoveWorkspace(context.Background(), req.SessionID)
+		}
+		if networkName != "" {
+			_ = re.docker.RemoveRentalNetwork(context.Background(), req.SessionID)
+		}
+		re.releaseCPUs(req.SessionID)
+		re.releaseGPUs(req.SessionID)
+		// Release ports
+		if sshPort != 0 {
+			_ = re.portManager.Release(sshPort)
+		}
+		for _, p := range ports {
+			_ = re.portManager.Release(p.HostPort)
+		}
+		return nil, re.failStart(state, err)
+	}
+
+	mode, err := container.ParseAccessMode(string(req.AccessMode))
+	if err != nil {
+		return fail(err)
+	}
+	if err := re.validateRuntimeSpec(req); err != nil {
+		return fail(err)
+	}
+	ipc, err := re.resolveIPC(req)
+	if err != nil {
+		return fail(err)
+	}
+	runtimeClass, runtime, err := re.resolveRuntime(req.RuntimeClass)
+	if err != nil {
+		return fail(err)
+	}
+	if req.GPUDeviceID, err = re.allocateGPUs(req); err != nil {
+		return fail(err)
+	}
+
+	// The access mode's web service is published like any other exposed port
+	exposed := req.ExposedPorts
+	if port := mode.ServicePort(); port != 0 {
+		exposed = append([]container.PortMapping{{ContainerPort: port}}, exposed...)
+	}
+	if err := validateExposedPorts(exposed); err != nil {
+		return fail(err)
+	}
+	diskQuota, err := re.diskQuota(req.DiskQuotaBytes)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Admit and pull the image under the node's image policy. Credentials are
+	// only used here; the container is created from the local image.
+	if err := re.transition(state, PhasePulling, ""); err != nil {
+		return fail(err)
+	}
+	image, err := re.pullImage(ctx, state, req)
+	if err != nil {
+		return fail(err)
+	}
+	if err := re.transition(state, PhaseCreating, ""); err != nil {
+		return fail(err)
+	}
+
+	var accessToken string
+	if mode.NeedsToken() {
+		if accessToken, err = generateAccessToken(); err != nil {
+			return fail(err)
+		}
+	}
 
 	// Allocate SSH port
-	sshPort, err := re.portManager.Allocate(req.SessionID)
+	sshPort, err = re.portManager.Allocate(req.SessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to allocate port: %w", err)
+		return fail(fmt.Errorf("failed to allocate port: %w", err))
 	}
 
-	// Cleanup on failure (defer pattern)
-	var containerID string
-	cleanupOnError := func() {
-		if containerID != "" {
-			// Remove container if created
-			_ = re.docker.RemoveContainer(context.Background(), containerID, true)
+	// Host ports for additional services
+	for _, p := range exposed {
+		hostPort, err := re.portManager.Allocate(req.SessionID)
+		if err != nil {
+			return fail(fmt.Errorf("failed to allocate port for %d: %w", p.ContainerPort, err))
+		}
+		p.HostPort = hostPort
+		if p.Protocol == "" {
+			p.Protocol = "tcp"
+		}
+		ports = append(ports, p)
+	}
+
+	// Dedicated CPUs on the GPUs' NUMA nodes
+	cpus, err := re.allocateCPUs(req)
+	if err != nil {
+		return fail(fmt.Errorf("failed to allocate cpus: %w", err))
+	}
+
+	// Dedicated network with egress policy
+	if re.isolateNetworks {
+		policy := re.egressPolicy
+		if req.EgressPolicy != nil {
+			if err := req.EgressPolicy.Validate(); err != nil {
+				return fail(err)
+			}
+			policy = re.egressPolicy.Narrow(*req.EgressPolicy)
+		}
+		networkName, err = re.docker.CreateRentalNetwork(ctx, req.SessionID, policy)
+		if err != nil {
+			return fail(fmt.Errorf("failed to create rental network: %w", err))
 		}
-		// Release port
-		_ = re.portManager.Release(sshPort)
 	}
 
+	// Workspace volume survives container rebuilds
+	workspace, err = re.docker.CreateWorkspace(ctx, req.SessionID)
+	if err != nil {
+		return fail(err)
+	}
+
+	restartPolicy := re.restartPolicy
+	if req.RestartPolicy != nil {
+		restartPolicy = *req.RestartPolicy
+	}
+
+	// Usage and receipts count from here; the label keeps the time stable
+	// across rebuilds and adoption
+	startedAt := time.Now().UTC().Truncate(time.Second)
+
 	// Create container with SSH on the allocated port
 	containerConfig := container.ContainerConfig{
-		SessionID:   req.SessionID,
-		Image:       req.Image,
-		GPUDeviceID: req.GPUDeviceID,
-		SSHPassword: req.SSHPassword,
-		SSHPort:     sshPort,
-		MemoryBytes: req.MemoryBytes,
-		CPUCount:    req.CPUCount,
+		SessionID:       req.SessionID,
+		Image:           image,
+		GPUDeviceID:     req.GPUDeviceID,
+		SSHPassword:     req.SSHPassword,
+		SSHPort:         sshPort,
+		MemoryBytes:     req.MemoryBytes,
+		CPUCount:        req.CPUCount,
+		CPUSet:          cpus.CPUSet(),
+		MemNodes:        cpus.MemSet(),
+		DiskQuotaBytes:  diskQuota,
+		ScratchMounts:   req.ScratchMounts,
+		ShmSizeBytes:    ipc.shmSize,
+		Ulimits:         ipc.ulimits,
+		IPCMode:         ipc.mode,
+		NetworkName:     networkName,
+		ExtraPorts:      ports,
+		AccessMode:      mode,
+		AccessToken:     accessToken,
+		Env:             req.Env,
+		InitCommand:     req.InitCommand,
+		WorkDir:         req.WorkDir,
+		WorkspaceVolume: workspace,
+		Security:        re.security,
+		Runtime:         runtime,
+		Labels: map[string]string{
+			container.LabelGPUDeviceID:   req.GPUDeviceID,
+			container.LabelAccessMode:    string(mode),
+			container.LabelDiskQuota:     strconv.FormatInt(diskQuota, 10),
+			container.LabelNetwork:       networkName,
+			container.LabelPricePerSec:   req.PricePerSecond,
+			container.LabelRestartPolicy: restartPolicy.String(),
+			container.LabelWorkspace:     workspace,
+			container.LabelCPUSet:        cpus.CPUSet(),
+			container.LabelMemNodes:      cpus.MemSet(),
+			container.LabelSecurity:      re.security.Name,
+			container.LabelRuntimeClass:  runtimeClass,
+			container.LabelStartedAt:     startedAt.Format(time.RFC3339),
+		},
 	}
 
 	containerID, err = re.docker.CreateContainer(ctx, containerConfig)
 	if err != nil {
-		cleanupOnError()
-		return nil, fmt.Errorf("failed to create container: %w", err)
+		return fail(fmt.Errorf("failed to create container: %w", err))
 	}
+	re.mu.Lock()
+	state.ContainerID = containerID // Bootstrap logs are readable from here on
+	re.mu.Unlock()
 
 	// Start container with retry
+	if err := re.transition(state, PhaseStarting, ""); err != nil {
+		return fail(err)
+	}
 	if err := re.docker.StartContainer(ctx, containerID); err != nil {
-		cleanupOnError()
-		return nil, fmt.Errorf("failed to start container: %w", err)
+		return fail(fmt.Errorf("failed to start container: %w", err))
 	}
 
 	// Wait for health check
+	if err := re.transition(state, PhaseWaitingHealthy, ""); err != nil {
+		return fail(err)
+	}
 	if err := re.waitForHealth(ctx, containerID); err != nil {
-		cleanupOnError()
-		return nil, fmt.Errorf("failed health check: %w", err)
+		return fail(fmt.Errorf("failed health check: %w", err))
 	}
 
-	// Track active rental
-	state := &RentalState{
-		SessionID:   req.SessionID,
-		ContainerID: containerID,
-		SSHPort:     sshPort,
-		StartedAt:   time.Now(),
+	// Wait until the renter can actually log in
+	accessPort := sshPort
+	if mode.ServicePort() != 0 {
+		accessPort = ports[0].HostPort
+	}
+	if err := re.waitForReady(ctx, mode, accessPort, accessToken); err != nil {
+		return fail(fmt.Errorf("failed readiness check: %w", err))
 	}
 
+	// Record the running rental. A stop that arrived meanwhile wins.
 	re.mu.Lock()
-	re.activeRentals[req.SessionID] = state
+	ev, err = setPhase(state, PhaseRunning, "")
+	if err == nil {
+		state.ContainerID = containerID
+		state.Image = image
+		state.GPUDeviceID = req.GPUDeviceID
+		state.PricePerSecond = req.PricePerSecond
+		state.RestartPolicy = restartPolicy
+		state.SSHPort = sshPort
+		state.StartedAt = startedAt
+		state.DiskQuotaBytes = diskQuota
+		state.NetworkName = networkName
+		state.WorkspaceVolume = workspace
+		state.CPUSet = cpus.CPUSet()
+		state.MemNodes = cpus.MemSet()
+		state.SecurityProfile = re.security.Name
+		state.RuntimeClass = runtimeClass
+		state.Ports = ports
+		state.AccessMode = mode
+		state.cancelStart = nil
+		state.spec = &containerConfig
+	}
 	re.mu.Unlock()
+	if err != nil {
+		return fail(err)
+	}
+	re.emit(ev)
 
 	// Return connection info
 	connInfo := &ConnectionInfo{
@@ -158,11 +526,90 @@ func (re *RentalExecutor) StartRental(ctx context.Context, req StartRentalReques
 		User:        "ubuntu",
 		Command:     fmt.Sprintf("ssh -p %d ubuntu@%s", sshPort, req.Host),
 		ContainerID: containerID,
+		Ports:       ports,
+		AccessMode:  mode,
+		AccessPort:  accessPort,
+		AccessToken: accessToken,
+	}
+	if mode.ServicePort() != 0 {
+		connInfo.AccessURL = mode.URL(req.Host, accessPort, accessToken)
 	}
 
 	return connInfo, nil
 }
 
+// generateAccessToken returns a random token for web access services
+func generateAccessToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// pullImage prepares the rental image, tracking pull progress for status
+// queries and forwarding it as image_pull_progress events
+func (re *RentalExecutor) pullImage(ctx context.Context, state *RentalState, req StartRentalRequest) (string, error) {
+	re.mu.Lock()
+	state.Pull = &container.PullProgress{Image: req.Image}
+	re.mu.Unlock()
+
+	defer func() {
+		re.mu.Lock()
+		state.Pull = nil
+		re.mu.Unlock()
+	}()
+
+	return re.docker.PrepareImage(ctx, req.Image, req.RegistryAuth, func(p container.PullProgress) {
+		re.mu.Lock()
+		state.Pull = &p
+		re.mu.Unlock()
+
+		re.emit(Event{
+			Type:      EventImagePullProgress,
+			SessionID: req.SessionID,
+			Payload: map[string]interface{}{
+				"image":         p.Image,
+				"status":        p.Status,
+				"current_bytes": p.CurrentBytes,
+				"total_bytes":   p.TotalBytes,
+				"layers_done":   p.LayersDone,
+				"layers_total":  p.LayersTotal,
+				"done":          p.Done,
+			},
+		})
+	})
+}
+
+// validateExposedPorts rejects out-of-range, duplicate and SSH ports
+func validateExposedPorts(ports []container.PortMapping) error {
+	if len(ports) > maxExposedPorts {
+		return fmt.Errorf("%w: at most %d ports may be exposed", ErrInvalidExposedPort, maxExposedPorts)
+	}
+	seen := make(map[string]bool)
+	for _, p := range ports {
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+			return fmt.Errorf("%w: %d", ErrInvalidExposedPort, p.ContainerPort)
+		}
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		if proto != "tcp" && proto != "udp" {
+			return fmt.Errorf("%w: unsupported protocol %q", ErrInvalidExposedPort, p.Protocol)
+		}
+		if p.ContainerPort == 22 && proto == "tcp" {
+			return fmt.Errorf("%w: 22/tcp is reserved for SSH", ErrInvalidExposedPort)
+		}
+		key := fmt.Sprintf("%d/%s", p.ContainerPort, proto)
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate %s", ErrInvalidExposedPort, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
 // waitForHealth polls container health until healthy or timeout
 func (re *RentalExecutor) waitForHealth(ctx context.Context, containerID string) error {
 	deadline := time.Now().Add(re.healthTimeout)
@@ -213,8 +660,17 @@ func (re *RentalExecutor) waitForHealth(ctx context.Context, containerID string)
 	}
 }
 
-// StopRental stops the container and schedules cleanup after grace period
+// StopRental stops the container and schedules cleanup after grace period.
+// A rental that is still starting is moved to stopping and its start is
+// aborted; StartRental then releases what it allocated. Stopping a rental
+// that is already stopping, stopped or failed is a no-op.
 func (re *RentalExecutor) StopRental(ctx context.Context, sessionID string) error {
+	return re.stopRental(ctx, sessionID, true)
+}
+
+// stopRental stops a rental and removes its resources, after the grace
+// period in the background or right away when grace is false
+func (re *RentalExecutor) stopRental(ctx context.Context, sessionID string, grace bool) error {
 	re.mu.Lock()
 	state, exists := re.activeRentals[sessionID]
 	if !exists {
@@ -222,40 +678,104 @@ func (re *RentalExecutor) StopRental(ctx context.Context, sessionID string) erro
 		return ErrSessionNotFound
 	}
 
+	if state.Phase != PhaseRunning && !state.Phase.Starting() {
+		re.mu.Unlock()
+		return nil
+	}
+
+	starting := state.Phase.Starting()
+	reason := ""
+	if starting {
+		reason = "stop requested while " + string(state.Phase)
+	}
+	ev, err := setPhase(state, PhaseStopping, reason)
+	if err != nil {
+		re.mu.Unlock()
+		return err
+	}
+
 	// Mark as stopped
 	now := time.Now()
 	state.StoppedAt = &now
+	cancelStart := state.cancelStart
+	snapshot := *state
 	re.mu.Unlock()
+	re.emit(ev)
 
-	// Stop container gracefully
-	if err := re.docker.StopContainer(ctx, state.ContainerID, 10); err != nil {
-		return fmt.Errorf("failed to stop container: %w", err)
+	if starting {
+		if cancelStart != nil {
+			cancelStart()
+		}
+		return nil
 	}
 
-	// Schedule cleanup in background after grace period
-	go re.scheduleCleanup(sessionID, state.ContainerID, state.SSHPort)
+	// Close out metering while the container's counters are still readable
+	re.finishUsage(ctx, snapshot)
+
+	// Stop container gracefully. Cleanup still runs if the stop fails since
+	// removal is forced.
+	stopErr := re.docker.StopContainer(ctx, snapshot.ContainerID, 10)
+	_ = re.transition(state, PhaseStopped, "")
+
+	if grace {
+		// Schedule cleanup in background after grace period
+		go re.scheduleCleanup(state, snapshot)
+	} else {
+		re.releaseCPUs(snapshot.SessionID)
+		re.releaseGPUs(snapshot.SessionID)
+		re.cleanup(state, snapshot)
+	}
 
+	if stopErr != nil {
+		return fmt.Errorf("failed to stop container: %w", stopErr)
+	}
 	return nil
 }
 
-// scheduleCleanup waits for grace period then removes container and releases port
-func (re *RentalExecutor) scheduleCleanup(sessionID, containerID string, sshPort int) {
-	time.Sleep(re.gracePeriod)
+// scheduleCleanup serves the rental's files during the grace period, then
+// removes container, network and releases ports
+func (re *RentalExecutor) scheduleCleanup(tracked *RentalState, state RentalState) {
+	deadline := time.Now().Add(re.gracePeriod)
+	// The container is stopped; its CPUs and GPUs are free for new rentals
+	re.releaseCPUs(state.SessionID)
+	re.releaseGPUs(state.SessionID)
+	re.startExport(tracked, state, deadline)
+	time.Sleep(time.Until(deadline))
+	re.cleanup(tracked, state)
+}
+
+// cleanup removes a stopped rental's export, container, network and
+// workspace and releases its ports
+func (re *RentalExecutor) cleanup(tracked *RentalState, state RentalState) {
+	_ = re.transition(tracked, PhaseCleaning, "")
 
-	// Remove container
+	// Remove export and container
 	ctx := context.Background()
-	_ = re.docker.RemoveContainer(ctx, containerID, true)
+	if re.dataExport {
+		_ = re.docker.RemoveExport(ctx, state.SessionID)
+	}
+	_ = re.docker.RemoveContainer(ctx, state.ContainerID, true)
 
-	// Release port
-	_ = re.portManager.Release(sshPort)
+	// Remove network once nothing is attached to it
+	if state.NetworkName != "" {
+		_ = re.docker.RemoveRentalNetwork(ctx, state.SessionID)
+	}
+	if state.WorkspaceVolume != "" {
+		_ = re.docker.RemoveWorkspace(ctx, state.SessionID)
+	}
+
+	// Release all ports held by the session
+	for _, port := range state.hostPorts() {
+		_ = re.portManager.Release(port)
+	}
 
 	// Remove from active rentals
-	re.mu.Lock()
-	delete(re.activeRentals, sessionID)
-	re.mu.Unlock()
+	_ = re.transition(tracked, PhaseCleaned, "")
+	re.forget(tracked)
 }
 
-// GetRentalStatus returns the current state of a rental
+// GetRentalStatus returns the current state of a rental in any phase,
+// including rentals still starting and recently failed ones
 func (re *RentalExecutor) GetRentalStatus(sessionID string) (*RentalState, error) {
 	re.mu.RLock()
 	defer re.mu.RUnlock()
@@ -266,29 +786,17 @@ func (re *RentalExecutor) GetRentalStatus(sessionID string) (*RentalState, error
 	}
 
 	// Return copy to prevent external mutation
-	stateCopy := *state
-	if state.StoppedAt != nil {
-		t := *state.StoppedAt
-		stateCopy.StoppedAt = &t
-	}
-
-	return &stateCopy, nil
+	return copyState(state), nil
 }
 
-// ListActiveRentals returns all active rental states
+// ListActiveRentals returns all tracked rental states
 func (re *RentalExecutor) ListActiveRentals() []*RentalState {
 	re.mu.RLock()
 	defer re.mu.RUnlock()
 
 	rentals := make([]*RentalState, 0, len(re.activeRentals))
 	for _, state := range re.activeRentals {
-		// Return copy
-		stateCopy := *state
-		if state.StoppedAt != nil {
-			t := *state.StoppedAt
-			stateCopy.StoppedAt = &t
-		}
-		rentals = append(rentals, &stateCopy)
+		rentals = append(rentals, copyState(state))
 	}
 
 	return rentals
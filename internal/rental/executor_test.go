@@ -3,6 +3,7 @@ package rental
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
@@ -13,18 +14,46 @@ import (
 
 // MockDockerService implements DockerServiceInterface for testing
 type MockDockerService struct {
+	prepareImageFunc     func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error)
 	createContainerFunc  func(ctx context.Context, cfg container.ContainerConfig) (string, error)
 	startContainerFunc   func(ctx context.Context, containerID string) error
 	stopContainerFunc    func(ctx context.Context, containerID string, timeoutSeconds int) error
 	removeContainerFunc  func(ctx context.Context, containerID string, force bool) error
 	inspectContainerFunc func(ctx context.Context, containerID string) (*container.ContainerInfo, error)
+	diskUsageFunc        func(ctx context.Context, containerID string) (int64, error)
+	containerLogsFunc    func(ctx context.Context, containerID string, opts container.LogOptions, w io.Writer) error
+	execFunc             func(ctx context.Context, containerID string, cmd []string, maxBytes int64) (*container.ExecResult, error)
+	statsFunc            func(ctx context.Context, containerID string) (*container.ResourceStats, error)
+	createNetworkFunc    func(ctx context.Context, sessionID string, policy container.EgressPolicy) (string, error)
+	startExportFunc      func(ctx context.Context, cfg container.ExportConfig) (string, error)
+
+	NetworkCreateCalls []container.EgressPolicy
+	NetworkRemoveCalls []string
 
 	// Call tracking
+	PrepareCalls []string
 	CreateCalls  []container.ContainerConfig
 	StartCalls   []string
 	StopCalls    []string
 	RemoveCalls  []string
 	InspectCalls []string
+	ExecCalls    [][]string
+
+	RentalContainers []container.RentalContainer // Returned by ListRentalContainers
+
+	ExportCalls       []container.ExportConfig
+	ExportRemoveCalls []string
+
+	WorkspaceRemoveCalls []string
+	RenameCalls          []string
+}
+
+func (m *MockDockerService) PrepareImage(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+	m.PrepareCalls = append(m.PrepareCalls, image)
+	if m.prepareImageFunc != nil {
+		return m.prepareImageFunc(ctx, image, auth, onProgress)
+	}
+	return image, nil
 }
 
 func (m *MockDockerService) CreateContainer(ctx context.Context, cfg container.ContainerConfig) (string, error) {
@@ -72,6 +101,79 @@ func (m *MockDockerService) InspectContainer(ctx context.Context, containerID st
 	}, nil
 }
 
+func (m *MockDockerService) DiskUsage(ctx context.Context, containerID string) (int64, error) {
+	if m.diskUsageFunc != nil {
+		return m.diskUsageFunc(ctx, containerID)
+	}
+	return 0, nil
+}
+
+func (m *MockDockerService) ContainerLogs(ctx context.Context, containerID string, opts container.LogOptions, w io.Writer) error {
+	if m.containerLogsFunc != nil {
+		return m.containerLogsFunc(ctx, containerID, opts, w)
+	}
+	return nil
+}
+
+func (m *MockDockerService) Exec(ctx context.Context, containerID string, cmd []string, maxBytes int64) (*container.ExecResult, error) {
+	m.ExecCalls = append(m.ExecCalls, cmd)
+	if m.execFunc != nil {
+		return m.execFunc(ctx, containerID, cmd, maxBytes)
+	}
+	return &container.ExecResult{}, nil
+}
+
+func (m *MockDockerService) ContainerStats(ctx context.Context, containerID string) (*container.ResourceStats, error) {
+	if m.statsFunc != nil {
+		return m.statsFunc(ctx, containerID)
+	}
+	return &container.ResourceStats{At: time.Now()}, nil
+}
+
+func (m *MockDockerService) ListRentalContainers(ctx context.Context) ([]container.RentalContainer, error) {
+	return m.RentalContainers, nil
+}
+
+func (m *MockDockerService) StartExport(ctx context.Context, cfg container.ExportConfig) (string, error) {
+	m.ExportCalls = append(m.ExportCalls, cfg)
+	if m.startExportFunc != nil {
+		return m.startExportFunc(ctx, cfg)
+	}
+	return "export-" + cfg.SessionID, nil
+}
+
+func (m *MockDockerService) RemoveExport(ctx context.Context, sessionID string) error {
+	m.ExportRemoveCalls = append(m.ExportRemoveCalls, sessionID)
+	return nil
+}
+
+func (m *MockDockerService) CreateWorkspace(ctx context.Context, sessionID string) (string, error) {
+	return container.WorkspaceVolumeName(sessionID), nil
+}
+
+func (m *MockDockerService) RemoveWorkspace(ctx context.Context, sessionID string) error {
+	m.WorkspaceRemoveCalls = append(m.WorkspaceRemoveCalls, sessionID)
+	return nil
+}
+
+func (m *MockDockerService) RenameContainer(ctx context.Context, containerID, name string) error {
+	m.RenameCalls = append(m.RenameCalls, containerID+" -> "+name)
+	return nil
+}
+
+func (m *MockDockerService) CreateRentalNetwork(ctx context.Context, sessionID string, policy container.EgressPolicy) (string, error) {
+	m.NetworkCreateCalls = append(m.NetworkCreateCalls, policy)
+	if m.createNetworkFunc != nil {
+		return m.createNetworkFunc(ctx, sessionID, policy)
+	}
+	return "wl-rental-" + sessionID, nil
+}
+
+func (m *MockDockerService) RemoveRentalNetwork(ctx context.Context, sessionID string) error {
+	m.NetworkRemoveCalls = append(m.NetworkRemoveCalls, sessionID)
+	return nil
+}
+
 // MockPortManager implements PortManagerInterface for testing
 type MockPortManager struct {
 	allocateFunc func(sessionID string) (int, error)
@@ -79,6 +181,7 @@ type MockPortManager struct {
 
 	// Call tracking
 	AllocateCalls []string
+	ReserveCalls  []int
 	ReleaseCalls  []int
 
 	nextPort int
@@ -93,6 +196,11 @@ func (m *MockPortManager) Allocate(sessionID string) (int, error) {
 	return 30000 + m.nextPort, nil
 }
 
+func (m *MockPortManager) Reserve(port int, sessionID string) error {
+	m.ReserveCalls = append(m.ReserveCalls, port)
+	return nil
+}
+
 func (m *MockPortManager) Release(port int) error {
 	m.ReleaseCalls = append(m.ReleaseCalls, port)
 	if m.releaseFunc != nil {
@@ -107,13 +215,13 @@ func TestStartRental_AllocatesPortAndCreatesContainer(t *testing.T) {
 	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
 
 	req := StartRentalRequest{
-		SessionID:    "session-123",
-		Image:        "nvidia/cuda:12.1-runtime-ubuntu22.04",
-		GPUDeviceID:  "GPU-uuid-456",
+		SessionID:   "session-123",
+		Image:       "nvidia/cuda:12.1-runtime-ubuntu22.04",
+		GPUDeviceID: "GPU-uuid-456",
 		SSHPassword: "ssh-rsa AAAA...",
-		MemoryBytes:  8 * 1024 * 1024 * 1024,
-		CPUCount:     4,
-		Host:         "provider.example.com",
+		MemoryBytes: 8 * 1024 * 1024 * 1024,
+		CPUCount:    4,
+		Host:        "provider.example.com",
 	}
 
 	connInfo, err := executor.StartRental(context.Background(), req)
@@ -395,3 +503,363 @@ func TestListActiveRentals_ReturnsAll(t *testing.T) {
 	assert.True(t, sessionIDs["session-1"])
 	assert.True(t, sessionIDs["session-2"])
 }
+
+func TestStartRental_AppliesDefaultDiskQuota(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).WithDiskQuota(10<<30, false)
+
+	req := StartRentalRequest{
+		SessionID:     "session-123",
+		ScratchMounts: []container.ScratchMount{{Path: "/scratch", SizeBytes: 1 << 30}},
+	}
+	_, err := executor.StartRental(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(10<<30), mockDocker.CreateCalls[0].DiskQuotaBytes)
+	assert.Equal(t, req.ScratchMounts, mockDocker.CreateCalls[0].ScratchMounts)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10<<30), state.DiskQuotaBytes)
+}
+
+func TestStartRental_RejectsDiskQuotaAboveMaximum(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).WithMaxDiskQuota(100 << 30)
+
+	for _, quota := range []int64{200 << 30, -1} {
+		_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", DiskQuotaBytes: quota})
+		assert.ErrorIs(t, err, ErrInvalidDiskQuota)
+	}
+	assert.Empty(t, mockDocker.CreateCalls)
+	assert.Empty(t, mockPort.AllocateCalls)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", DiskQuotaBytes: 50 << 30})
+	require.NoError(t, err)
+	assert.Equal(t, int64(50<<30), mockDocker.CreateCalls[0].DiskQuotaBytes)
+}
+
+func TestStartRental_MaximumDiskQuotaAppliesWithoutQuota(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute).WithMaxDiskQuota(100 << 30)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(100<<30), mockDocker.CreateCalls[0].DiskQuotaBytes)
+}
+
+func TestCheckDiskQuotas_FlagsRentalOverQuota(t *testing.T) {
+	mockDocker := &MockDockerService{
+		diskUsageFunc: func(ctx context.Context, containerID string) (int64, error) {
+			return 2048, nil
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", DiskQuotaBytes: 1024})
+	require.NoError(t, err)
+
+	executor.CheckDiskQuotas(context.Background())
+	executor.CheckDiskQuotas(context.Background())
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.True(t, state.QuotaExceeded)
+	assert.Equal(t, int64(2048), state.DiskUsageBytes)
+	assert.Nil(t, state.StoppedAt, "flag-only policy must not stop the rental")
+
+	// Event is emitted once, not on every pass
+	events = eventsOfType(events, EventDiskQuotaExceeded)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventDiskQuotaExceeded, events[0].Type)
+	assert.Equal(t, false, events[0].Payload["stopped"])
+}
+
+func TestCheckDiskQuotas_StopsRentalWhenConfigured(t *testing.T) {
+	mockDocker := &MockDockerService{
+		diskUsageFunc: func(ctx context.Context, containerID string) (int64, error) {
+			return 2048, nil
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).WithDiskQuota(0, true)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", DiskQuotaBytes: 1024})
+	require.NoError(t, err)
+
+	executor.CheckDiskQuotas(context.Background())
+
+	assert.Len(t, mockDocker.StopCalls, 1)
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.NotNil(t, state.StoppedAt)
+}
+
+func TestStartRental_CreatesIsolatedNetwork(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).
+		WithNetworkIsolation(container.DefaultEgressPolicy())
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.NetworkCreateCalls, 1)
+	assert.Equal(t, container.DefaultEgressPolicy(), mockDocker.NetworkCreateCalls[0])
+	assert.Equal(t, "wl-rental-session-123", mockDocker.CreateCalls[0].NetworkName)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "wl-rental-session-123", state.NetworkName)
+}
+
+func TestStartRental_PerRentalEgressOnlyNarrowsNodePolicy(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	node := container.DefaultEgressPolicy()
+	node.AllowCIDRs = []string{"10.0.0.0/16"}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).WithNetworkIsolation(node)
+
+	// Tries to open the LAN and a CIDR the node doesn't allow
+	override := container.EgressPolicy{AllowCIDRs: []string{"10.0.5.0/24", "192.168.1.0/24"}}
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", EgressPolicy: &override})
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.NetworkCreateCalls, 1)
+	policy := mockDocker.NetworkCreateCalls[0]
+	assert.True(t, policy.BlockPrivate)
+	assert.True(t, policy.BlockHost)
+	assert.Equal(t, []string{"10.0.5.0/24"}, policy.AllowCIDRs)
+}
+
+func TestStartRental_RejectsInvalidEgressCIDR(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute).
+		WithNetworkIsolation(container.DefaultEgressPolicy())
+
+	override := container.EgressPolicy{DenyCIDRs: []string{"not-a-cidr"}}
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", EgressPolicy: &override})
+
+	assert.Error(t, err)
+	assert.Empty(t, mockDocker.NetworkCreateCalls)
+}
+
+func TestStartRental_RemovesNetworkOnFailure(t *testing.T) {
+	mockDocker := &MockDockerService{
+		startContainerFunc: func(ctx context.Context, containerID string) error {
+			return errors.New("start failed")
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).
+		WithNetworkIsolation(container.DefaultEgressPolicy())
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"session-123"}, mockDocker.NetworkRemoveCalls)
+	assert.Len(t, mockPort.ReleaseCalls, 1)
+}
+
+func TestStartRental_NoNetworkWithoutIsolation(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	assert.Empty(t, mockDocker.NetworkCreateCalls)
+	assert.Empty(t, mockDocker.CreateCalls[0].NetworkName)
+}
+
+func TestStartRental_AllocatesExposedPorts(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+	connInfo, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID: "session-123",
+		ExposedPorts: []container.PortMapping{
+			{ContainerPort: 8888},
+			{ContainerPort: 6006, Protocol: "tcp"},
+		},
+	})
+	require.NoError(t, err)
+
+	expected := []container.PortMapping{
+		{ContainerPort: 8888, HostPort: 30002, Protocol: "tcp"},
+		{ContainerPort: 6006, HostPort: 30003, Protocol: "tcp"},
+	}
+	assert.Equal(t, 30001, connInfo.Port)
+	assert.Equal(t, expected, connInfo.Ports)
+	assert.Equal(t, expected, mockDocker.CreateCalls[0].ExtraPorts)
+	assert.Equal(t, []string{"session-123", "session-123", "session-123"}, mockPort.AllocateCalls)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, expected, state.Ports)
+}
+
+func TestStartRental_RejectsInvalidExposedPorts(t *testing.T) {
+	cases := map[string][]container.PortMapping{
+		"ssh":       {{ContainerPort: 22}},
+		"range":     {{ContainerPort: 70000}},
+		"duplicate": {{ContainerPort: 8888}, {ContainerPort: 8888, Protocol: "tcp"}},
+		"protocol":  {{ContainerPort: 8888, Protocol: "sctp"}},
+	}
+	for name, ports := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockDocker := &MockDockerService{}
+			mockPort := &MockPortManager{}
+			executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+			_, err := executor.StartRental(context.Background(), StartRentalRequest{
+				SessionID:    "session-123",
+				ExposedPorts: ports,
+			})
+
+			assert.ErrorIs(t, err, ErrInvalidExposedPort)
+			assert.Empty(t, mockPort.AllocateCalls)
+		})
+	}
+}
+
+func TestStartRental_ReleasesAllPortsOnFailure(t *testing.T) {
+	mockDocker := &MockDockerService{
+		createContainerFunc: func(ctx context.Context, cfg container.ContainerConfig) (string, error) {
+			return "", errors.New("create failed")
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:    "session-123",
+		ExposedPorts: []container.PortMapping{{ContainerPort: 8888}, {ContainerPort: 6006}},
+	})
+
+	require.Error(t, err)
+	assert.ElementsMatch(t, []int{30001, 30002, 30003}, mockPort.ReleaseCalls)
+}
+
+func TestStopRental_ReleasesAllPortsOnCleanup(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 10*time.Millisecond)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:    "session-123",
+		ExposedPorts: []container.PortMapping{{ContainerPort: 8888}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, []int{30001, 30002}, mockPort.ReleaseCalls)
+}
+
+func TestStartRental_RunsAdmittedImage(t *testing.T) {
+	mockDocker := &MockDockerService{
+		prepareImageFunc: func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+			assert.Equal(t, "renter", auth.Username)
+			return "docker.io/pytorch/pytorch@sha256:abc", nil
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:    "session-123",
+		Image:        "pytorch/pytorch",
+		RegistryAuth: &container.RegistryAuth{Username: "renter", Password: "pw"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"pytorch/pytorch"}, mockDocker.PrepareCalls)
+	assert.Equal(t, "docker.io/pytorch/pytorch@sha256:abc", mockDocker.CreateCalls[0].Image)
+	assert.Nil(t, mockDocker.CreateCalls[0].RegistryAuth)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "docker.io/pytorch/pytorch@sha256:abc", state.Image)
+}
+
+func TestStartRental_ImagePolicyRejectionAllocatesNothing(t *testing.T) {
+	mockDocker := &MockDockerService{
+		prepareImageFunc: func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+			return "", &container.ImagePolicyError{Code: container.ImageCodeDenied, Image: image, Reason: "denied"}
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", Image: "bad/image"})
+
+	var policyErr *container.ImagePolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, container.ImageCodeDenied, policyErr.Code)
+	assert.Empty(t, mockPort.AllocateCalls)
+	assert.Empty(t, mockDocker.CreateCalls)
+}
+
+func TestStartRental_ReportsImagePullProgress(t *testing.T) {
+	var executor *RentalExecutor
+	var statusDuringPull *RentalState
+	mockDocker := &MockDockerService{
+		prepareImageFunc: func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+			onProgress(container.PullProgress{Image: image, CurrentBytes: 100, TotalBytes: 1000, LayersTotal: 3})
+			statusDuringPull, _ = executor.GetRentalStatus("session-123")
+			onProgress(container.PullProgress{Image: image, CurrentBytes: 1000, TotalBytes: 1000, LayersDone: 3, LayersTotal: 3, Done: true})
+			return image, nil
+		},
+	}
+	executor = NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", Image: "pytorch/pytorch"})
+	require.NoError(t, err)
+
+	require.NotNil(t, statusDuringPull)
+	require.NotNil(t, statusDuringPull.Pull)
+	assert.Equal(t, int64(100), statusDuringPull.Pull.CurrentBytes)
+	assert.Equal(t, "pytorch/pytorch", statusDuringPull.Image)
+
+	events = eventsOfType(events, EventImagePullProgress)
+	require.Len(t, events, 2)
+	assert.Equal(t, EventImagePullProgress, events[0].Type)
+	assert.Equal(t, "session-123", events[0].SessionID)
+	assert.Equal(t, int64(1000), events[0].Payload["total_bytes"])
+	assert.Equal(t, true, events[1].Payload["done"])
+
+	// Started rentals no longer carry pull progress
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Nil(t, state.Pull)
+}
+
+func TestStartRental_RejectsDuplicateWhilePulling(t *testing.T) {
+	var executor *RentalExecutor
+	var dupErr error
+	mockDocker := &MockDockerService{
+		prepareImageFunc: func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+			_, dupErr = executor.StartRental(ctx, StartRentalRequest{SessionID: "session-123"})
+			return image, nil
+		},
+	}
+	executor = NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+
+	require.NoError(t, err)
+	assert.ErrorIs(t, dupErr, ErrSessionAlreadyActive)
+}
@@ -0,0 +1,64 @@
+package rental
+
+import (
+	"context"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// exportStartTimeout bounds starting a stopped rental's export
+const exportStartTimeout = 5 * time.Minute
+
+// WithDataExport serves a stopped rental's workspace read-only over SFTP on
+// its SSH port, with the same password, until the grace period ends. The
+// Docker service must have a pinned export image (see PinExportImage).
+func (re *RentalExecutor) WithDataExport(enabled bool) *RentalExecutor {
+	re.dataExport = enabled
+	return re
+}
+
+// startExport starts the data export for a stopped rental, available until
+// the cleanup deadline, and reports the outcome to the Hub. The rental is
+// cleaned up on schedule whether or not the export started.
+func (re *RentalExecutor) startExport(tracked *RentalState, state RentalState, deadline time.Time) {
+	if !re.dataExport || state.ContainerID == "" || state.SSHPort == 0 || state.WorkspaceVolume == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportStartTimeout)
+	defer cancel()
+	_, err := re.docker.StartExport(ctx, container.ExportConfig{
+		SessionID:   state.SessionID,
+		ContainerID: state.ContainerID,
+		SSHPort:     state.SSHPort,
+		NetworkName: state.NetworkName,
+
+		WorkspaceVolume: state.WorkspaceVolume,
+		Security:        re.security,
+	})
+	if err != nil {
+		re.emit(Event{
+			Type:      EventDataExport,
+			SessionID: state.SessionID,
+			Payload:   map[string]interface{}{"available": false, "error": err.Error()},
+		})
+		return
+	}
+
+	re.mu.Lock()
+	tracked.ExportUntil = &deadline
+	re.mu.Unlock()
+
+	re.emit(Event{
+		Type:      EventDataExport,
+		SessionID: state.SessionID,
+		Payload: map[string]interface{}{
+			"available":  true,
+			"protocol":   "sftp",
+			"ssh_port":   state.SSHPort,
+			"user":       "ubuntu",
+			"expires_at": deadline.Unix(),
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestStopRental_ServesDataExportUntilCleanup(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 100*time.Millisecond).
+		WithDataExport(true)
+	exports := make(chan Event, 1)
+	executor.OnEvent = func(ev Event) {
+		if ev.Type == EventDataExport {
+			exports <- ev
+		}
+	}
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+
+	ev := <-exports
+	assert.Equal(t, true, ev.Payload["available"])
+	assert.Equal(t, "sftp", ev.Payload["protocol"])
+	assert.Equal(t, 30001, ev.Payload["ssh_port"])
+	assert.Equal(t, []container.ExportConfig{{
+		SessionID:       "session-123",
+		ContainerID:     "container-123",
+		SSHPort:         30001,
+		WorkspaceVolume: "wl-rental-session-123-workspace",
+	}}, mockDocker.ExportCalls)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	require.NotNil(t, state.ExportUntil)
+	assert.Equal(t, state.ExportUntil.Unix(), ev.Payload["expires_at"])
+	assert.Empty(t, mockDocker.ExportRemoveCalls)
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.Equal(t, []string{"session-123"}, mockDocker.ExportRemoveCalls)
+	assert.Equal(t, []string{"container-123"}, mockDocker.RemoveCalls)
+}
+
+func TestStopRental_ReportsFailedDataExport(t *testing.T) {
+	mockDocker := &MockDockerService{
+		startExportFunc: func(ctx context.Context, cfg container.ExportConfig) (string, error) {
+			return "", errors.New("no sshd in image")
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).
+		WithDataExport(true)
+	exports := make(chan Event, 1)
+	executor.OnEvent = func(ev Event) {
+		if ev.Type == EventDataExport {
+			exports <- ev
+		}
+	}
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+
+	ev := <-exports
+	assert.Equal(t, false, ev.Payload["available"])
+	assert.Contains(t, ev.Payload["error"], "no sshd")
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Nil(t, state.ExportUntil)
+}
+
+func TestAdoptRentals_RemovesLeftoverExports(t *testing.T) {
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{
+			{ContainerID: "export-1", SessionID: "session-1", State: "running", Export: true},
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+
+	adopted, err := executor.AdoptRentals(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, adopted)
+	assert.Equal(t, []string{"session-1"}, mockDocker.ExportRemoveCalls)
+	assert.Empty(t, mockDocker.RemoveCalls)
+}
@@ -0,0 +1,61 @@
+package rental
+
+import (
+	"strings"
+
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/inventory"
+)
+
+// GPUAllocator tracks which rental holds each GPU and MIG slice.
+// inventory.Allocator satisfies it.
+type GPUAllocator interface {
+	Allocate(sessionID, gpuDeviceID string) error
+	Release(sessionID string)
+	Assigned(sessionID string) []string
+	Status() []inventory.Unit
+}
+
+// WithGPUAllocator checks each rental's GPUs and MIG slices against the
+// host's and refuses ones already held by another rental
+func (re *RentalExecutor) WithGPUAllocator(gpus GPUAllocator) *RentalExecutor {
+	re.gpus = gpus
+	return re
+}
+
+// GPUUnits returns the node's rentable GPUs and MIG slices and the rental
+// holding each (nil without an allocator)
+func (re *RentalExecutor) GPUUnits() []inventory.Unit {
+	if re.gpus == nil {
+		return nil
+	}
+	return re.gpus.Status()
+}
+
+// allocateGPUs assigns a rental its GPUs and returns them as UUIDs, so the
+// container gets exactly the units held for it even when "all" or indexes
+// were requested
+func (re *RentalExecutor) allocateGPUs(req StartRentalRequest) (string, error) {
+	if re.gpus == nil || req.GPUDeviceID == "" {
+		return req.GPUDeviceID, nil
+	}
+	if err := re.gpus.Allocate(req.SessionID, req.GPUDeviceID); err != nil {
+		return "", err
+	}
+	return strings.Join(re.gpus.Assigned(req.SessionID), ","), nil
+}
+
+// releaseGPUs frees a rental's GPUs
+func (re *RentalExecutor) releaseGPUs(sessionID string) {
+	if re.gpus != nil {
+		re.gpus.Release(sessionID)
+	}
+}
+
+// reserveGPUs re-records the GPUs of an adopted rental from its labels
+func (re *RentalExecutor) reserveGPUs(rc container.RentalContainer) error {
+	if re.gpus == nil || rc.Labels[container.LabelGPUDeviceID] == "" {
+		return nil
+	}
+	return re.gpus.Allocate(rc.SessionID, rc.Labels[container.LabelGPUDeviceID])
+}
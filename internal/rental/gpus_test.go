@@ -0,0 +1,123 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/adapters/nvml"
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/domain"
+	"github.com/worldland/worldland-node/internal/inventory"
+)
+
+// migAllocator tracks an H100 split into two 3g.40gb slices
+func migAllocator() *inventory.Allocator {
+	return inventory.NewAllocator(nvml.MIGLayout(
+		domain.GPUSpec{UUID: "GPU-hhh", Name: "NVIDIA H100", MemoryTotal: 81920},
+		"3g.40gb", "3g.40gb",
+	))
+}
+
+func TestStartRental_AssignsMIGSlices(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	gpus := migAllocator()
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 10*time.Millisecond).WithGPUAllocator(gpus)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-1", GPUDeviceID: "MIG-hhh-0"})
+	require.NoError(t, err)
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-2", GPUDeviceID: "MIG-hhh-1"})
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.CreateCalls, 2)
+	assert.Equal(t, "MIG-hhh-0", mockDocker.CreateCalls[0].GPUDeviceID)
+	units := executor.GPUUnits()
+	require.Len(t, units, 2)
+	assert.Equal(t, "session-1", units[0].SessionID)
+	assert.Equal(t, "session-2", units[1].SessionID)
+
+	// The slice is free again once the container is stopped
+	require.NoError(t, executor.StopRental(context.Background(), "session-1"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, executor.GPUUnits()[0].SessionID)
+}
+
+func TestStartRental_ResolvesAllThroughAllocator(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithGPUAllocator(migAllocator())
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-1", GPUDeviceID: "all"})
+	require.NoError(t, err)
+
+	// The container and its labels name the slices held, not "all"
+	require.Len(t, mockDocker.CreateCalls, 1)
+	assert.Equal(t, "MIG-hhh-0,MIG-hhh-1", mockDocker.CreateCalls[0].GPUDeviceID)
+	assert.Equal(t, "MIG-hhh-0,MIG-hhh-1", mockDocker.CreateCalls[0].Labels[container.LabelGPUDeviceID])
+	state, err := executor.GetRentalStatus("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, state.GPUCount())
+}
+
+func TestStartRental_RejectsSliceInUse(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, time.Minute).WithGPUAllocator(migAllocator())
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-1", GPUDeviceID: "MIG-hhh-0"})
+	require.NoError(t, err)
+
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-2", GPUDeviceID: "MIG-hhh-0"})
+
+	assert.True(t, errors.Is(err, inventory.ErrUnitInUse))
+	assert.Len(t, mockDocker.CreateCalls, 1)
+	assert.Len(t, mockPort.AllocateCalls, 1)
+}
+
+func TestStartRental_RejectsMIGParent(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithGPUAllocator(migAllocator())
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-1", GPUDeviceID: "GPU-hhh"})
+
+	assert.True(t, errors.Is(err, inventory.ErrMIGParent))
+	assert.Empty(t, mockDocker.CreateCalls)
+}
+
+func TestAdoptRentals_ReservesGPUs(t *testing.T) {
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{{
+			ContainerID: "container-1",
+			SessionID:   "session-1",
+			State:       "running",
+			Labels:      map[string]string{container.LabelGPUDeviceID: "MIG-hhh-1"},
+		}},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithGPUAllocator(migAllocator())
+
+	_, err := executor.AdoptRentals(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", executor.GPUUnits()[1].SessionID)
+}
+
+func TestAdoptRentals_CountsGPUsOfAll(t *testing.T) {
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{{
+			ContainerID: "container-1",
+			SessionID:   "session-1",
+			State:       "running",
+			Labels:      map[string]string{container.LabelGPUDeviceID: "all"},
+		}},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithGPUAllocator(migAllocator())
+
+	_, err := executor.AdoptRentals(context.Background())
+	require.NoError(t, err)
+
+	state, err := executor.GetRentalStatus("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, "MIG-hhh-0,MIG-hhh-1", state.GPUDeviceID)
+	assert.Equal(t, 2, state.GPUCount())
+}
@@ -0,0 +1,106 @@
+package rental
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+var ErrInvalidIPCSettings = errors.New("invalid shared memory, ulimit or ipc settings")
+
+// IPCLimits are the operator's defaults and maxima for rentals' /dev/shm,
+// ulimits and IPC mode. The zero value leaves Docker's defaults in place and
+// only lets requests size /dev/shm within their memory limit.
+type IPCLimits struct {
+	ShmFraction float64 // Default /dev/shm as a fraction of the memory limit (0 = Docker's 64 MB)
+	ShmMaxBytes int64   // Largest /dev/shm a rental gets (0 = its memory limit)
+
+	Ulimits    []container.Ulimit // Applied unless the request sets the same limit
+	MaxUlimits []container.Ulimit // Highest hard limit a request may set; unlisted limits can't be requested
+
+	IPCMode  string   // Default IPC mode (empty = Docker's default)
+	IPCModes []string // Modes a request may choose
+}
+
+// ipcSettings are a rental's resolved IPC settings
+type ipcSettings struct {
+	shmSize int64
+	ulimits []container.Ulimit
+	mode    string
+}
+
+// WithIPCLimits sets defaults and maxima for rentals' shared memory, ulimits
+// and IPC mode. /dev/shm is tmpfs and counts against the memory limit.
+func (re *RentalExecutor) WithIPCLimits(limits IPCLimits) *RentalExecutor {
+	re.ipcLimits = limits
+	return re
+}
+
+// resolveIPC applies the operator defaults to a request and checks what the
+// request asks for against the operator maxima
+func (re *RentalExecutor) resolveIPC(req StartRentalRequest) (ipcSettings, error) {
+	limits := re.ipcLimits
+
+	// /dev/shm pages are charged to the container, so it never exceeds the memory limit
+	maxShm := limits.ShmMaxBytes
+	if req.MemoryBytes > 0 && (maxShm == 0 || maxShm > req.MemoryBytes) {
+		maxShm = req.MemoryBytes
+	}
+	shm := req.ShmSizeBytes
+	switch {
+	case shm < 0:
+		return ipcSettings{}, fmt.Errorf("%w: negative shm size", ErrInvalidIPCSettings)
+	case shm > 0 && maxShm > 0 && shm > maxShm:
+		return ipcSettings{}, fmt.Errorf("%w: shm size %d above the maximum of %d", ErrInvalidIPCSettings, shm, maxShm)
+	case shm == 0:
+		shm = int64(limits.ShmFraction * float64(req.MemoryBytes))
+	}
+	if maxShm > 0 && shm > maxShm {
+		shm = maxShm
+	}
+
+	for _, u := range req.Ulimits {
+		if err := u.Validate(); err != nil {
+			return ipcSettings{}, fmt.Errorf("%w: %v", ErrInvalidIPCSettings, err)
+		}
+		max, ok := findUlimit(limits.MaxUlimits, u.Name)
+		if !ok {
+			return ipcSettings{}, fmt.Errorf("%w: ulimit %s may not be set", ErrInvalidIPCSettings, u.Name)
+		}
+		if container.LimitExceeds(u.Hard, max.Hard) {
+			return ipcSettings{}, fmt.Errorf("%w: ulimit %s above the maximum of %d", ErrInvalidIPCSettings, u.Name, max.Hard)
+		}
+	}
+
+	mode := limits.IPCMode
+	if req.IPCMode != "" {
+		if _, err := container.ParseIPCMode(req.IPCMode); err != nil {
+			return ipcSettings{}, fmt.Errorf("%w: %v", ErrInvalidIPCSettings, err)
+		}
+		allowed := false
+		for _, m := range limits.IPCModes {
+			allowed = allowed || m == req.IPCMode
+		}
+		if !allowed {
+			return ipcSettings{}, fmt.Errorf("%w: ipc mode %q not allowed", ErrInvalidIPCSettings, req.IPCMode)
+		}
+		mode = req.IPCMode
+	}
+
+	return ipcSettings{
+		shmSize: shm,
+		ulimits: container.MergeUlimits(limits.Ulimits, req.Ulimits),
+		mode:    mode,
+	}, nil
+}
+
+// findUlimit returns the limit with the given name
+func findUlimit(ulimits []container.Ulimit, name string) (container.Ulimit, bool) {
+	for _, u := range ulimits {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return container.Ulimit{}, false
+}
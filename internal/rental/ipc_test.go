@@ -0,0 +1,109 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func ipcTestLimits() IPCLimits {
+	return IPCLimits{
+		ShmFraction: 0.5,
+		ShmMaxBytes: 16 << 30,
+		Ulimits: []container.Ulimit{
+			{Name: "memlock", Soft: -1, Hard: -1},
+			{Name: "stack", Soft: 64 << 20, Hard: 64 << 20},
+		},
+		MaxUlimits: []container.Ulimit{
+			{Name: "memlock", Soft: -1, Hard: -1},
+			{Name: "nofile", Soft: 1 << 20, Hard: 1 << 20},
+		},
+		IPCMode:  container.IPCModePrivate,
+		IPCModes: []string{container.IPCModePrivate, container.IPCModeShareable},
+	}
+}
+
+func TestStartRental_AppliesIPCDefaults(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithIPCLimits(ipcTestLimits())
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", MemoryBytes: 8 << 30})
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.CreateCalls, 1)
+	cfg := mockDocker.CreateCalls[0]
+	assert.Equal(t, int64(4<<30), cfg.ShmSizeBytes)
+	assert.Equal(t, ipcTestLimits().Ulimits, cfg.Ulimits)
+	assert.Equal(t, container.IPCModePrivate, cfg.IPCMode)
+}
+
+func TestStartRental_RequestedIPCSettings(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithIPCLimits(ipcTestLimits())
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:    "session-123",
+		MemoryBytes:  8 << 30,
+		ShmSizeBytes: 6 << 30,
+		Ulimits:      []container.Ulimit{{Name: "nofile", Soft: 65536, Hard: 1 << 20}},
+		IPCMode:      container.IPCModeShareable,
+	})
+	require.NoError(t, err)
+
+	cfg := mockDocker.CreateCalls[0]
+	assert.Equal(t, int64(6<<30), cfg.ShmSizeBytes)
+	assert.Equal(t, []container.Ulimit{
+		{Name: "memlock", Soft: -1, Hard: -1},
+		{Name: "nofile", Soft: 65536, Hard: 1 << 20},
+		{Name: "stack", Soft: 64 << 20, Hard: 64 << 20},
+	}, cfg.Ulimits)
+	assert.Equal(t, container.IPCModeShareable, cfg.IPCMode)
+}
+
+func TestResolveIPC(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, time.Minute).WithIPCLimits(ipcTestLimits())
+
+	// No memory limit: Docker's default
+	ipc, err := executor.resolveIPC(StartRentalRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, ipc.shmSize)
+
+	// The fraction is capped by the operator maximum
+	ipc, err = executor.resolveIPC(StartRentalRequest{MemoryBytes: 64 << 30})
+	require.NoError(t, err)
+	assert.Equal(t, int64(16<<30), ipc.shmSize)
+
+	rejected := []StartRentalRequest{
+		{MemoryBytes: 8 << 30, ShmSizeBytes: 9 << 30},
+		{MemoryBytes: 64 << 30, ShmSizeBytes: 32 << 30},
+		{ShmSizeBytes: -1},
+		{Ulimits: []container.Ulimit{{Name: "stack", Soft: 1 << 20, Hard: 1 << 20}}},
+		{Ulimits: []container.Ulimit{{Name: "nofile", Soft: -1, Hard: -1}}},
+		{Ulimits: []container.Ulimit{{Name: "nproc", Soft: 10, Hard: 10}}},
+		{IPCMode: container.IPCModeHost},
+		{IPCMode: "container:other"},
+	}
+	for _, req := range rejected {
+		_, err := executor.resolveIPC(req)
+		assert.ErrorIs(t, err, ErrInvalidIPCSettings, "%+v", req)
+	}
+}
+
+func TestResolveIPC_ZeroLimitsKeepDockerDefaults(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, time.Minute)
+
+	ipc, err := executor.resolveIPC(StartRentalRequest{MemoryBytes: 8 << 30})
+	require.NoError(t, err)
+	assert.Zero(t, ipc.shmSize)
+	assert.Empty(t, ipc.ulimits)
+	assert.Empty(t, ipc.mode)
+
+	// Requests may still size /dev/shm within their memory limit
+	ipc, err = executor.resolveIPC(StartRentalRequest{MemoryBytes: 8 << 30, ShmSizeBytes: 2 << 30})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2<<30), ipc.shmSize)
+}
@@ -0,0 +1,169 @@
+package rental
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid rental phase transition")
+	ErrStartAborted      = errors.New("rental stopped while starting")
+)
+
+// Phase is a step in a rental's lifecycle
+type Phase string
+
+const (
+	PhasePending        Phase = "pending"
+	PhasePulling        Phase = "pulling"
+	PhaseCreating       Phase = "creating"
+	PhaseStarting       Phase = "starting"
+	PhaseWaitingHealthy Phase = "waiting-healthy"
+	PhaseRunning        Phase = "running"
+	PhaseRebuilding     Phase = "rebuilding"
+	PhaseStopping       Phase = "stopping"
+	PhaseStopped        Phase = "stopped"
+	PhaseCleaning       Phase = "cleaning"
+	PhaseCleaned        Phase = "cleaned"
+	PhaseFailed         Phase = "failed"
+)
+
+// failedRetention is how long a failed rental stays queryable through
+// GetRentalStatus before it is forgotten
+const failedRetention = 10 * time.Minute
+
+// phaseTransitions lists the phases reachable from each phase. A stop may
+// arrive at any point before cleanup; the start path notices the stopping
+// phase at its next step and unwinds.
+var phaseTransitions = map[Phase][]Phase{
+	"":                  {PhasePending, PhaseRunning}, // Running when adopted (see AdoptRentals)
+	PhasePending:        {PhasePulling, PhaseStopping, PhaseFailed},
+	PhasePulling:        {PhaseCreating, PhaseStopping, PhaseFailed},
+	PhaseCreating:       {PhaseStarting, PhaseStopping, PhaseFailed},
+	PhaseStarting:       {PhaseWaitingHealthy, PhaseStopping, PhaseFailed},
+	PhaseWaitingHealthy: {PhaseRunning, PhaseStopping, PhaseFailed},
+	PhaseRunning:        {PhaseRebuilding, PhaseStopping, PhaseFailed},
+	PhaseRebuilding:     {PhaseRunning, PhaseStopping, PhaseFailed},
+	PhaseStopping:       {PhaseStopped, PhaseFailed},
+	PhaseStopped:        {PhaseCleaning},
+	PhaseCleaning:       {PhaseCleaned},
+	PhaseFailed:         {PhaseCleaning},
+}
+
+// CanTransition reports whether a rental in phase p may move to next
+func (p Phase) CanTransition(next Phase) bool {
+	for _, allowed := range phaseTransitions[p] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Starting reports whether the rental is still being brought up, or brought
+// up again by a rebuild
+func (p Phase) Starting() bool {
+	switch p {
+	case PhasePending, PhasePulling, PhaseCreating, PhaseStarting, PhaseWaitingHealthy, PhaseRebuilding:
+		return true
+	}
+	return false
+}
+
+// PhaseTransition records a single phase change
+type PhaseTransition struct {
+	From   Phase
+	To     Phase
+	At     time.Time
+	Reason string // Why the rental moved (failure reason, stop source, ...)
+}
+
+// setPhase validates and records a phase change. The caller must hold re.mu
+// and emit the returned event after unlocking.
+func setPhase(state *RentalState, next Phase, reason string) (Event, error) {
+	prev := state.Phase
+	if !prev.CanTransition(next) {
+		return Event{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, prev, next)
+	}
+
+	now := time.Now()
+	state.Phase = next
+	state.History = append(state.History, PhaseTransition{From: prev, To: next, At: now, Reason: reason})
+	if next == PhaseFailed {
+		state.FailureReason = reason
+	}
+
+	payload := map[string]interface{}{
+		"phase":          string(next),
+		"previous_phase": string(prev),
+		"at":             now.UTC().Format(time.RFC3339),
+	}
+	if reason != "" {
+		payload["reason"] = reason
+	}
+	return Event{Type: EventPhaseChanged, SessionID: state.SessionID, Payload: payload}, nil
+}
+
+// transition moves a tracked rental to the next phase and emits a
+// rental_phase_changed event
+func (re *RentalExecutor) transition(state *RentalState, next Phase, reason string) error {
+	re.mu.Lock()
+	ev, err := setPhase(state, next, reason)
+	re.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	re.emit(ev)
+	return nil
+}
+
+// failStart records why a start did not complete. Resources must already be
+// released. A start interrupted by StopRental finishes the stop (stopped,
+// cleaning, cleaned) and reports ErrStartAborted; any other failure leaves
+// the rental in the failed phase for failedRetention so status queries can
+// see the reason.
+func (re *RentalExecutor) failStart(state *RentalState, cause error) error {
+	re.mu.RLock()
+	aborted := state.Phase == PhaseStopping
+	re.mu.RUnlock()
+
+	if aborted {
+		_ = re.transition(state, PhaseStopped, "")
+		_ = re.transition(state, PhaseCleaning, "")
+		_ = re.transition(state, PhaseCleaned, "")
+		re.forget(state)
+		return ErrStartAborted
+	}
+
+	_ = re.transition(state, PhaseFailed, cause.Error())
+	time.AfterFunc(failedRetention, func() { re.forget(state) })
+	return cause
+}
+
+// forget drops a rental from tracking unless it has already been replaced
+func (re *RentalExecutor) forget(state *RentalState) {
+	re.mu.Lock()
+	if re.activeRentals[state.SessionID] == state {
+		delete(re.activeRentals, state.SessionID)
+	}
+	re.mu.Unlock()
+}
+
+// copyState returns a snapshot of state that is safe to hand to callers
+func copyState(state *RentalState) *RentalState {
+	stateCopy := *state
+	if state.StoppedAt != nil {
+		t := *state.StoppedAt
+		stateCopy.StoppedAt = &t
+	}
+	if state.Pull != nil {
+		pull := state.Pull.Snapshot()
+		stateCopy.Pull = &pull
+	}
+	stateCopy.History = append([]PhaseTransition(nil), state.History...)
+	stateCopy.cancelStart = nil
+	stateCopy.spec = nil
+	stateCopy.failedLogs = nil
+	return &stateCopy
+}
@@ -0,0 +1,232 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// eventsOfType filters recorded events down to one type
+func eventsOfType(events []Event, eventType string) []Event {
+	var out []Event
+	for _, ev := range events {
+		if ev.Type == eventType {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// phasesOf returns the phase sequence carried by rental_phase_changed events
+func phasesOf(events []Event) []Phase {
+	var out []Phase
+	for _, ev := range eventsOfType(events, EventPhaseChanged) {
+		out = append(out, Phase(ev.Payload["phase"].(string)))
+	}
+	return out
+}
+
+func TestPhase_CanTransition(t *testing.T) {
+	tests := []struct {
+		from, to Phase
+		want     bool
+	}{
+		{"", PhasePending, true},
+		{"", PhaseRunning, true},
+		{PhasePending, PhasePulling, true},
+		{PhasePulling, PhaseCreating, true},
+		{PhaseWaitingHealthy, PhaseRunning, true},
+		{PhaseStarting, PhaseStopping, true},
+		{PhaseRunning, PhaseStopping, true},
+		{PhaseStopping, PhaseStopped, true},
+		{PhaseStopped, PhaseCleaning, true},
+		{PhaseCleaning, PhaseCleaned, true},
+		{PhaseFailed, PhaseCleaning, true},
+		{PhasePending, PhaseRunning, false},
+		{PhaseStopping, PhaseRunning, false},
+		{PhaseStopped, PhaseStopping, false},
+		{PhaseCleaned, PhasePending, false},
+		{PhaseRunning, PhaseCleaning, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.from.CanTransition(tt.to), "%q -> %q", tt.from, tt.to)
+	}
+}
+
+func TestStartRental_RecordsPhaseHistory(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+
+	want := []Phase{PhasePending, PhasePulling, PhaseCreating, PhaseStarting, PhaseWaitingHealthy, PhaseRunning}
+	require.Len(t, state.History, len(want))
+	for i, tr := range state.History {
+		assert.Equal(t, want[i], tr.To)
+		assert.False(t, tr.At.IsZero())
+	}
+	assert.Equal(t, want, phasesOf(events))
+	assert.Equal(t, "waiting-healthy", events[len(events)-1].Payload["previous_phase"])
+}
+
+func TestStartRental_FailureKeepsFailedPhase(t *testing.T) {
+	mockDocker := &MockDockerService{
+		createContainerFunc: func(ctx context.Context, cfg container.ContainerConfig) (string, error) {
+			return "", errors.New("no such image")
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.Error(t, err)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseFailed, state.Phase)
+	assert.Contains(t, state.FailureReason, "no such image")
+
+	// A failed rental neither blocks a retry nor needs stopping
+	assert.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	mockDocker.createContainerFunc = nil
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	state, err = executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+	assert.Empty(t, state.FailureReason)
+}
+
+func TestStartRental_RetryAfterFailedStartOfCreatedContainer(t *testing.T) {
+	mockDocker := &MockDockerService{
+		startContainerFunc: func(ctx context.Context, containerID string) error {
+			return errors.New("oci runtime error")
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.Error(t, err)
+	assert.Len(t, mockDocker.RemoveCalls, 1)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseFailed, state.Phase)
+	assert.Empty(t, state.ContainerID)
+
+	// The failed container is gone, so the retry is not a duplicate
+	mockDocker.startContainerFunc = nil
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	state, err = executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+}
+
+func TestStartRental_BlocksRetryWhileFailedContainerRemains(t *testing.T) {
+	mockDocker := &MockDockerService{
+		startContainerFunc: func(ctx context.Context, containerID string) error {
+			return errors.New("oci runtime error")
+		},
+		removeContainerFunc: func(ctx context.Context, containerID string, force bool) error {
+			return errors.New("device or resource busy")
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.Error(t, err)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.NotEmpty(t, state.ContainerID)
+
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	assert.ErrorIs(t, err, ErrSessionAlreadyActive)
+}
+
+func TestStopRental_WhilePullingAbortsStart(t *testing.T) {
+	var executor *RentalExecutor
+	var stopErr error
+	mockDocker := &MockDockerService{
+		prepareImageFunc: func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+			stopErr = executor.StopRental(context.Background(), "session-123")
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor = NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+
+	assert.ErrorIs(t, err, ErrStartAborted)
+	assert.NoError(t, stopErr)
+	assert.Empty(t, mockPort.AllocateCalls)
+	assert.Empty(t, mockDocker.CreateCalls)
+	assert.Equal(t, []Phase{PhasePending, PhasePulling, PhaseStopping, PhaseStopped, PhaseCleaning, PhaseCleaned}, phasesOf(events))
+
+	_, err = executor.GetRentalStatus("session-123")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestStopRental_WhileWaitingHealthyReleasesResources(t *testing.T) {
+	var executor *RentalExecutor
+	mockDocker := &MockDockerService{
+		inspectContainerFunc: func(ctx context.Context, containerID string) (*container.ContainerInfo, error) {
+			// Stop lands just as the container turns healthy
+			require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+			return &container.ContainerInfo{ContainerID: containerID, State: "running", Health: "healthy"}, nil
+		},
+	}
+	mockPort := &MockPortManager{}
+	executor = NewRentalExecutor(mockDocker, mockPort, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+
+	assert.ErrorIs(t, err, ErrStartAborted)
+	assert.Equal(t, []string{"container-123"}, mockDocker.RemoveCalls)
+	assert.Equal(t, []int{30001}, mockPort.ReleaseCalls)
+	assert.Empty(t, mockDocker.StopCalls)
+}
+
+func TestStopRental_IsIdempotent(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 50*time.Millisecond)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	events = nil
+
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseStopped, state.Phase)
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.Len(t, mockDocker.StopCalls, 1)
+	assert.Len(t, mockDocker.RemoveCalls, 1)
+	assert.Equal(t, []int{30001}, mockPort.ReleaseCalls)
+	assert.Equal(t, []Phase{PhaseStopping, PhaseStopped, PhaseCleaning, PhaseCleaned}, phasesOf(events))
+}
@@ -0,0 +1,70 @@
+package rental
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// ErrLogsUnavailable is returned for rentals that have no container yet
+var ErrLogsUnavailable = errors.New("rental has no container logs")
+
+const (
+	failedLogTail     = 200       // Lines kept from a container that failed to start
+	failedLogMaxBytes = 64 * 1024 // Size cap for those lines
+)
+
+// RentalLogs writes the rental container's output (including the access
+// service bootstrap) to w. Logs are read from the container while it exists,
+// which includes the cleanup grace period after a stop. A rental that failed
+// to start serves the tail captured before its container was removed.
+func (re *RentalExecutor) RentalLogs(ctx context.Context, sessionID string, opts container.LogOptions, w io.Writer) error {
+	re.mu.RLock()
+	state, exists := re.activeRentals[sessionID]
+	if !exists {
+		re.mu.RUnlock()
+		return ErrSessionNotFound
+	}
+	containerID := state.ContainerID
+	saved := state.failedLogs
+	re.mu.RUnlock()
+
+	if containerID != "" {
+		return re.docker.ContainerLogs(ctx, containerID, opts, w)
+	}
+	if saved == nil {
+		return ErrLogsUnavailable
+	}
+
+	max := opts.MaxBytes
+	if max <= 0 {
+		max = container.DefaultLogMaxBytes
+	}
+	if int64(len(saved)) > max {
+		_, _ = w.Write(saved[:max])
+		return container.ErrLogLimitReached
+	}
+	_, err := w.Write(saved)
+	return err
+}
+
+// captureFailedLogs keeps the tail of a container's output before it is
+// removed so a failed start can still be diagnosed
+func (re *RentalExecutor) captureFailedLogs(state *RentalState, containerID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	opts := container.LogOptions{Tail: failedLogTail, MaxBytes: failedLogMaxBytes}
+	err := re.docker.ContainerLogs(ctx, containerID, opts, &buf)
+
+	re.mu.Lock()
+	if err == nil || buf.Len() > 0 {
+		state.failedLogs = buf.Bytes()
+	}
+	re.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package rental
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestRentalLogs_ReadsFromContainer(t *testing.T) {
+	var gotID string
+	var gotOpts container.LogOptions
+	mockDocker := &MockDockerService{
+		containerLogsFunc: func(ctx context.Context, containerID string, opts container.LogOptions, w io.Writer) error {
+			gotID, gotOpts = containerID, opts
+			_, err := io.WriteString(w, "sshd listening\n")
+			return err
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = executor.RentalLogs(context.Background(), "session-123", container.LogOptions{Tail: 50}, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "container-123", gotID)
+	assert.Equal(t, 50, gotOpts.Tail)
+	assert.Equal(t, "sshd listening\n", out.String())
+}
+
+func TestRentalLogs_AvailableDuringGracePeriod(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+
+	assert.NoError(t, executor.RentalLogs(context.Background(), "session-123", container.LogOptions{}, io.Discard))
+}
+
+func TestRentalLogs_KeepsOutputOfFailedStart(t *testing.T) {
+	mockDocker := &MockDockerService{
+		inspectContainerFunc: func(ctx context.Context, containerID string) (*container.ContainerInfo, error) {
+			return &container.ContainerInfo{ContainerID: containerID, State: "exited"}, nil
+		},
+		containerLogsFunc: func(ctx context.Context, containerID string, opts container.LogOptions, w io.Writer) error {
+			assert.Equal(t, failedLogTail, opts.Tail)
+			_, err := io.WriteString(w, "E: Unable to locate package openssh-server\n")
+			return err
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"container-123"}, mockDocker.RemoveCalls)
+
+	// The container is gone; logs come from the captured tail
+	mockDocker.containerLogsFunc = func(ctx context.Context, containerID string, opts container.LogOptions, w io.Writer) error {
+		t.Fatal("removed container should not be read")
+		return nil
+	}
+	var out bytes.Buffer
+	err = executor.RentalLogs(context.Background(), "session-123", container.LogOptions{}, &out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "openssh-server")
+}
+
+func TestRentalLogs_Errors(t *testing.T) {
+	var executor *RentalExecutor
+	var pullingErr error
+	mockDocker := &MockDockerService{
+		prepareImageFunc: func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+			pullingErr = executor.RentalLogs(ctx, "session-123", container.LogOptions{}, io.Discard)
+			return image, nil
+		},
+	}
+	executor = NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, pullingErr, ErrLogsUnavailable)
+	assert.ErrorIs(t, executor.RentalLogs(context.Background(), "nonexistent", container.LogOptions{}, io.Discard), ErrSessionNotFound)
+}
@@ -0,0 +1,293 @@
+package rental
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/domain"
+	"github.com/worldland/worldland-node/internal/inventory"
+)
+
+// defaultUsageRetention is how long usage of ended rentals stays retrievable
+const defaultUsageRetention = 24 * time.Hour
+
+// GPUMetricsSource supplies current per-device GPU metrics.
+// domain.GPUProvider satisfies it.
+type GPUMetricsSource interface {
+	GetMetrics() ([]domain.GPUMetrics, error)
+}
+
+// UsageRecord aggregates what a rental consumed. Byte and CPU counters are
+// totals over the rental; averages and peaks are over the samples taken.
+type UsageRecord struct {
+	SessionID    string     `json:"sessionId"`
+	StartedAt    time.Time  `json:"startedAt"`
+	EndedAt      *time.Time `json:"endedAt,omitempty"`
+	LastSampleAt time.Time  `json:"lastSampleAt"`
+	Samples      int        `json:"samples"`
+
+	CPUSeconds      float64 `json:"cpuSeconds"`
+	MemoryAvgBytes  uint64  `json:"memoryAvgBytes"`
+	MemoryPeakBytes uint64  `json:"memoryPeakBytes"`
+	NetRxBytes      uint64  `json:"netRxBytes"`
+	NetTxBytes      uint64  `json:"netTxBytes"`
+	BlockReadBytes  uint64  `json:"blockReadBytes"`
+	BlockWriteBytes uint64  `json:"blockWriteBytes"`
+
+	GPUSamples        int     `json:"gpuSamples"`
+	GPUUtilAvgPercent float64 `json:"gpuUtilAvgPercent"` // Mean over samples and assigned devices
+	GPUMemoryAvgMB    uint64  `json:"gpuMemoryAvgMb"`    // Summed over assigned devices
+	GPUMemoryPeakMB   uint64  `json:"gpuMemoryPeakMb"`
+
+	memorySum    float64
+	gpuUtilSum   float64
+	gpuMemorySum float64
+
+	last    container.ResourceStats // Latest raw counters of the current container run
+	carried container.ResourceStats // Counters of earlier runs (before a restart)
+}
+
+// add folds one sample into the record
+func (u *UsageRecord) add(stats *container.ResourceStats, gpus []domain.GPUMetrics) {
+	u.Samples++
+	u.LastSampleAt = stats.At
+
+	// Docker counters are cumulative since the container started. A
+	// restarted container counts from zero again, so earlier runs carry over.
+	if stats.CPUNanos < u.last.CPUNanos {
+		u.carried.CPUNanos += u.last.CPUNanos
+		u.carried.NetRxBytes += u.last.NetRxBytes
+		u.carried.NetTxBytes += u.last.NetTxBytes
+		u.carried.BlockReadBytes += u.last.BlockReadBytes
+		u.carried.BlockWriteBytes += u.last.BlockWriteBytes
+	}
+	u.last = *stats
+	u.CPUSeconds = float64(u.carried.CPUNanos+stats.CPUNanos) / float64(time.Second)
+	u.NetRxBytes = u.carried.NetRxBytes + stats.NetRxBytes
+	u.NetTxBytes = u.carried.NetTxBytes + stats.NetTxBytes
+	u.BlockReadBytes = u.carried.BlockReadBytes + stats.BlockReadBytes
+	u.BlockWriteBytes = u.carried.BlockWriteBytes + stats.BlockWriteBytes
+
+	u.memorySum += float64(stats.MemoryBytes)
+	u.MemoryAvgBytes = uint64(u.memorySum / float64(u.Samples))
+	if stats.MemoryBytes > u.MemoryPeakBytes {
+		u.MemoryPeakBytes = stats.MemoryBytes
+	}
+
+	if len(gpus) == 0 {
+		return
+	}
+	var util float64
+	var memMB uint64
+	for _, g := range gpus {
+		util += float64(g.GPUUtil)
+		memMB += g.MemoryUsed
+	}
+	u.GPUSamples++
+	u.gpuUtilSum += util / float64(len(gpus))
+	u.gpuMemorySum += float64(memMB)
+	u.GPUUtilAvgPercent = u.gpuUtilSum / float64(u.GPUSamples)
+	u.GPUMemoryAvgMB = uint64(u.gpuMemorySum / float64(u.GPUSamples))
+	if memMB > u.GPUMemoryPeakMB {
+		u.GPUMemoryPeakMB = memMB
+	}
+}
+
+// Payload returns the record with snake_case keys for Hub messages
+func (u *UsageRecord) Payload() map[string]interface{} {
+	p := map[string]interface{}{
+		"session_id":           u.SessionID,
+		"started_at":           u.StartedAt.UTC().Format(time.RFC3339),
+		"samples":              u.Samples,
+		"cpu_seconds":          u.CPUSeconds,
+		"memory_avg_bytes":     u.MemoryAvgBytes,
+		"memory_peak_bytes":    u.MemoryPeakBytes,
+		"net_rx_bytes":         u.NetRxBytes,
+		"net_tx_bytes":         u.NetTxBytes,
+		"block_read_bytes":     u.BlockReadBytes,
+		"block_write_bytes":    u.BlockWriteBytes,
+		"gpu_samples":          u.GPUSamples,
+		"gpu_util_avg_percent": u.GPUUtilAvgPercent,
+		"gpu_memory_avg_mb":    u.GPUMemoryAvgMB,
+		"gpu_memory_peak_mb":   u.GPUMemoryPeakMB,
+	}
+	if !u.LastSampleAt.IsZero() {
+		p["last_sample_at"] = u.LastSampleAt.UTC().Format(time.RFC3339)
+	}
+	if u.EndedAt != nil {
+		p["ended_at"] = u.EndedAt.UTC().Format(time.RFC3339)
+	}
+	return p
+}
+
+// WithMetering sets the GPU metrics source for usage samples and how long
+// usage of ended rentals is kept (0 = default)
+func (re *RentalExecutor) WithMetering(gpu GPUMetricsSource, retention time.Duration) *RentalExecutor {
+	re.gpuMetrics = gpu
+	if retention > 0 {
+		re.usageRetention = retention
+	}
+	return re
+}
+
+// SampleUsage records a resource sample for every running rental and drops
+// usage of rentals that ended more than the retention period ago
+func (re *RentalExecutor) SampleUsage(ctx context.Context) {
+	re.mu.RLock()
+	running := make([]RentalState, 0, len(re.activeRentals))
+	for _, state := range re.activeRentals {
+		if state.Phase == PhaseRunning {
+			running = append(running, *state)
+		}
+	}
+	re.mu.RUnlock()
+
+	var gpus []domain.GPUMetrics
+	if re.gpuMetrics != nil && len(running) > 0 {
+		gpus, _ = re.gpuMetrics.GetMetrics() // Container stats are still worth recording
+	}
+	for _, state := range running {
+		re.sampleRental(ctx, state, gpus)
+	}
+
+	cutoff := time.Now().Add(-re.usageRetention)
+	re.mu.Lock()
+	for id, rec := range re.usage {
+		if rec.EndedAt != nil && rec.EndedAt.Before(cutoff) {
+			delete(re.usage, id)
+		}
+	}
+	re.mu.Unlock()
+}
+
+// MeterUsage runs SampleUsage every interval until ctx is cancelled
+func (re *RentalExecutor) MeterUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			re.SampleUsage(ctx)
+		}
+	}
+}
+
+// sampleRental adds one sample to a rental's usage record
+func (re *RentalExecutor) sampleRental(ctx context.Context, state RentalState, gpus []domain.GPUMetrics) {
+	stats, err := re.docker.ContainerStats(ctx, state.ContainerID)
+	if err != nil {
+		return // Container may be going away; keep what we have
+	}
+	units := re.GPUUnits()
+
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	rec, exists := re.usage[state.SessionID]
+	if !exists || rec.EndedAt != nil {
+		rec = &UsageRecord{SessionID: state.SessionID, StartedAt: state.StartedAt}
+		re.usage[state.SessionID] = rec
+	}
+	rec.add(stats, assignedGPUs(state.GPUDeviceID, gpus, units))
+}
+
+// finishUsage takes a last sample, closes the rental's usage record and
+// reports it with a rental_usage_final event. The event is sent even when no
+// sample could be taken (the final receipt depends on it), with an empty
+// record if need be.
+func (re *RentalExecutor) finishUsage(ctx context.Context, state RentalState) {
+	var gpus []domain.GPUMetrics
+	if re.gpuMetrics != nil {
+		gpus, _ = re.gpuMetrics.GetMetrics()
+	}
+	re.sampleRental(ctx, state, gpus)
+
+	re.mu.Lock()
+	rec, exists := re.usage[state.SessionID]
+	if !exists || rec.EndedAt != nil {
+		rec = &UsageRecord{SessionID: state.SessionID, StartedAt: state.StartedAt}
+		re.usage[state.SessionID] = rec
+	}
+	now := time.Now()
+	rec.EndedAt = &now
+	payload := rec.Payload()
+	re.mu.Unlock()
+
+	re.emit(Event{Type: EventUsageFinal, SessionID: state.SessionID, Payload: payload})
+}
+
+// GetUsage returns the usage record of a running or recently ended rental
+func (re *RentalExecutor) GetUsage(sessionID string) (*UsageRecord, error) {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	rec, exists := re.usage[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+	return copyUsage(rec), nil
+}
+
+// ActiveUsage returns usage records of rentals that haven't ended
+func (re *RentalExecutor) ActiveUsage() []*UsageRecord {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	records := make([]*UsageRecord, 0, len(re.usage))
+	for _, rec := range re.usage {
+		if rec.EndedAt == nil {
+			records = append(records, copyUsage(rec))
+		}
+	}
+	return records
+}
+
+// copyUsage returns a snapshot of rec that is safe to hand to callers
+func copyUsage(rec *UsageRecord) *UsageRecord {
+	c := *rec
+	if rec.EndedAt != nil {
+		t := *rec.EndedAt
+		c.EndedAt = &t
+	}
+	return &c
+}
+
+// assignedGPUs picks the metrics of a rental's devices. deviceIDs is the
+// rental's GPU assignment: comma-separated UUIDs or indexes, where a MIG
+// slice is given as "<gpu>:<slice>" or by its UUID, looked up in units.
+// NVML reports metrics per physical GPU, so a slice counts as its parent,
+// and a parent shared by several of the rental's slices counts once.
+func assignedGPUs(deviceIDs string, metrics []domain.GPUMetrics, units []inventory.Unit) []domain.GPUMetrics {
+	if deviceIDs == "" {
+		return nil
+	}
+	parents := make(map[string]string, len(units)) // MIG slice UUID -> GPU UUID
+	for _, u := range units {
+		if u.IsMIGSlice() {
+			parents[u.UUID] = u.ParentUUID
+		}
+	}
+
+	var out []domain.GPUMetrics
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(deviceIDs, ",") {
+		id = strings.TrimSpace(id)
+		if parent, ok := parents[id]; ok {
+			id = parent
+		}
+		id, _, _ = strings.Cut(id, ":")
+		for _, m := range metrics {
+			if (m.UUID == id || strconv.Itoa(m.Index) == id) && !seen[m.UUID] {
+				seen[m.UUID] = true
+				out = append(out, m)
+				break
+			}
+		}
+	}
+	return out
+}
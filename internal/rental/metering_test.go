@@ -0,0 +1,171 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/adapters/nvml"
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/domain"
+	"github.com/worldland/worldland-node/internal/inventory"
+)
+
+// fakeGPUMetrics returns fixed GPU metrics
+type fakeGPUMetrics []domain.GPUMetrics
+
+func (f fakeGPUMetrics) GetMetrics() ([]domain.GPUMetrics, error) {
+	return f, nil
+}
+
+func TestSampleUsage_AggregatesContainerAndGPUSamples(t *testing.T) {
+	samples := []container.ResourceStats{
+		{CPUNanos: 2_000_000_000, MemoryBytes: 1 << 30, NetRxBytes: 100, BlockWriteBytes: 10},
+		{CPUNanos: 5_000_000_000, MemoryBytes: 3 << 30, NetRxBytes: 400, BlockWriteBytes: 50},
+	}
+	calls := 0
+	mockDocker := &MockDockerService{
+		statsFunc: func(ctx context.Context, containerID string) (*container.ResourceStats, error) {
+			s := samples[calls]
+			s.At = time.Now()
+			calls++
+			return &s, nil
+		},
+	}
+	gpus := fakeGPUMetrics{
+		{UUID: "GPU-aaa", GPUUtil: 90, MemoryUsed: 10000},
+		{UUID: "GPU-bbb", GPUUtil: 10, MemoryUsed: 500},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+	executor.WithMetering(gpus, 0)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", GPUDeviceID: "GPU-aaa"})
+	require.NoError(t, err)
+
+	executor.SampleUsage(context.Background())
+	executor.SampleUsage(context.Background())
+
+	usage, err := executor.GetUsage("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.Samples)
+	assert.InDelta(t, 5.0, usage.CPUSeconds, 0.001)
+	assert.Equal(t, uint64(2<<30), usage.MemoryAvgBytes)
+	assert.Equal(t, uint64(3<<30), usage.MemoryPeakBytes)
+	assert.Equal(t, uint64(400), usage.NetRxBytes)
+	assert.Equal(t, uint64(50), usage.BlockWriteBytes)
+	assert.Equal(t, 2, usage.GPUSamples)
+	assert.InDelta(t, 90.0, usage.GPUUtilAvgPercent, 0.001)
+	assert.Equal(t, uint64(10000), usage.GPUMemoryPeakMB)
+	assert.Nil(t, usage.EndedAt)
+
+	assert.Len(t, executor.ActiveUsage(), 1)
+}
+
+func TestUsageRecord_CarriesCountersAcrossRestarts(t *testing.T) {
+	rec := &UsageRecord{}
+
+	rec.add(&container.ResourceStats{CPUNanos: 4_000_000_000, NetTxBytes: 100}, nil)
+	rec.add(&container.ResourceStats{CPUNanos: 1_000_000_000, NetTxBytes: 30}, nil) // Restarted container
+	rec.add(&container.ResourceStats{CPUNanos: 2_000_000_000, NetTxBytes: 50}, nil)
+
+	assert.InDelta(t, 6.0, rec.CPUSeconds, 0.001)
+	assert.Equal(t, uint64(150), rec.NetTxBytes)
+}
+
+func TestStopRental_FinalizesUsage(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 10*time.Millisecond)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	time.Sleep(50 * time.Millisecond)
+
+	// Usage outlives the rental's cleanup
+	_, err = executor.GetRentalStatus("session-123")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	usage, err := executor.GetUsage("session-123")
+	require.NoError(t, err)
+	assert.NotNil(t, usage.EndedAt)
+	assert.Equal(t, 1, usage.Samples)
+	assert.Empty(t, executor.ActiveUsage())
+
+	final := eventsOfType(events, EventUsageFinal)
+	require.Len(t, final, 1)
+	assert.Equal(t, 1, final[0].Payload["samples"])
+	assert.Contains(t, final[0].Payload, "ended_at")
+}
+
+func TestStopRental_FinalizesUsageWithoutStats(t *testing.T) {
+	docker := &MockDockerService{statsFunc: func(ctx context.Context, containerID string) (*container.ResourceStats, error) {
+		return nil, errors.New("no such container")
+	}}
+	executor := NewRentalExecutor(docker, &MockPortManager{}, 10*time.Millisecond)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+
+	final := eventsOfType(events, EventUsageFinal)
+	require.Len(t, final, 1)
+	assert.Equal(t, 0, final[0].Payload["samples"])
+	assert.Contains(t, final[0].Payload, "ended_at")
+}
+
+func TestSampleUsage_DropsExpiredRecords(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+	executor.WithMetering(nil, time.Hour)
+
+	ended := time.Now().Add(-2 * time.Hour)
+	executor.usage["old"] = &UsageRecord{SessionID: "old", EndedAt: &ended}
+	recent := time.Now()
+	executor.usage["recent"] = &UsageRecord{SessionID: "recent", EndedAt: &recent}
+
+	executor.SampleUsage(context.Background())
+
+	_, err := executor.GetUsage("old")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = executor.GetUsage("recent")
+	assert.NoError(t, err)
+}
+
+func TestAssignedGPUs(t *testing.T) {
+	aaa := domain.GPUMetrics{UUID: "GPU-aaa", Index: 0}
+	ccc := domain.GPUMetrics{UUID: "GPU-ccc", Index: 2}
+	metrics := []domain.GPUMetrics{aaa, {UUID: "GPU-bbb", Index: 1}, ccc}
+
+	assert.Nil(t, assignedGPUs("", metrics, nil))
+	assert.Equal(t, []domain.GPUMetrics{{UUID: "GPU-bbb", Index: 1}}, assignedGPUs("GPU-bbb", metrics, nil))
+	assert.Equal(t, []domain.GPUMetrics{aaa, ccc}, assignedGPUs("0, 2", metrics, nil))
+	assert.Empty(t, assignedGPUs("GPU-zzz", metrics, nil))
+}
+
+func TestAssignedGPUs_MatchesNVMLIndexNotPosition(t *testing.T) {
+	// GPU 1 failed to report, so GPU 2 is second in the list
+	ccc := domain.GPUMetrics{UUID: "GPU-ccc", Index: 2}
+	metrics := []domain.GPUMetrics{{UUID: "GPU-aaa", Index: 0}, ccc}
+
+	assert.Equal(t, []domain.GPUMetrics{ccc}, assignedGPUs("2", metrics, nil))
+	assert.Empty(t, assignedGPUs("1", metrics, nil))
+}
+
+func TestAssignedGPUs_MIGSlicesCountAsTheirParent(t *testing.T) {
+	a100 := domain.GPUMetrics{UUID: "GPU-a100", Index: 1}
+	metrics := []domain.GPUMetrics{{UUID: "GPU-aaa", Index: 0}, a100}
+	var units []inventory.Unit
+	for _, spec := range nvml.MIGLayout(domain.GPUSpec{UUID: "GPU-a100", Index: 1}, "3g.40gb", "3g.40gb") {
+		units = append(units, inventory.Unit{GPUSpec: spec})
+	}
+
+	assert.Equal(t, []domain.GPUMetrics{a100}, assignedGPUs("1:0", metrics, nil))
+	assert.Equal(t, []domain.GPUMetrics{a100}, assignedGPUs("MIG-a100-1", metrics, units))
+	assert.Equal(t, []domain.GPUMetrics{a100}, assignedGPUs("MIG-a100-0,1:1", metrics, units))
+	assert.Empty(t, assignedGPUs("MIG-a100-0", metrics, nil))
+}
@@ -0,0 +1,234 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// ErrInvalidRestartPolicy is returned for restart policies that can't be parsed
+var ErrInvalidRestartPolicy = errors.New("invalid restart policy")
+
+// defaultMaxRestarts applies to "on-failure" without an explicit limit
+const defaultMaxRestarts = 3
+
+// RestartMode selects when a crashed rental container is restarted
+type RestartMode string
+
+const (
+	RestartNever     RestartMode = "never"
+	RestartOnFailure RestartMode = "on-failure"
+)
+
+// RestartPolicy decides what happens when a running rental's container
+// exits, is OOM killed or turns unhealthy
+type RestartPolicy struct {
+	Mode        RestartMode
+	MaxRestarts int // Restarts allowed over the rental's lifetime (on-failure only)
+}
+
+// ParseRestartPolicy parses "never", "on-failure" or "on-failure:N".
+// An empty string means never.
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	mode, limit, hasLimit := strings.Cut(strings.TrimSpace(s), ":")
+	switch RestartMode(mode) {
+	case "", RestartNever:
+		if hasLimit {
+			return RestartPolicy{}, fmt.Errorf("%w: %q takes no limit", ErrInvalidRestartPolicy, s)
+		}
+		return RestartPolicy{Mode: RestartNever}, nil
+	case RestartOnFailure:
+		if !hasLimit {
+			return RestartPolicy{Mode: RestartOnFailure, MaxRestarts: defaultMaxRestarts}, nil
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return RestartPolicy{}, fmt.Errorf("%w: limit in %q must be a positive number", ErrInvalidRestartPolicy, s)
+		}
+		return RestartPolicy{Mode: RestartOnFailure, MaxRestarts: n}, nil
+	}
+	return RestartPolicy{}, fmt.Errorf("%w: %q (expected never or on-failure[:N])", ErrInvalidRestartPolicy, s)
+}
+
+// String returns the policy in ParseRestartPolicy syntax
+func (p RestartPolicy) String() string {
+	if p.Mode == RestartOnFailure {
+		return fmt.Sprintf("%s:%d", p.Mode, p.MaxRestarts)
+	}
+	return string(RestartNever)
+}
+
+// WithRestartPolicy sets the restart policy for rentals that don't request one
+func (re *RentalExecutor) WithRestartPolicy(policy RestartPolicy) *RentalExecutor {
+	re.restartPolicy = policy
+	return re
+}
+
+// crash describes why a running rental's container is no longer usable
+type crash struct {
+	reason    string
+	exitCode  int
+	oomKilled bool
+	exited    bool // false when the container is still running but unhealthy
+}
+
+// detectCrash inspects container state for an exit, OOM kill or failed
+// health check. Returns nil while the container is fine.
+func detectCrash(info *container.ContainerInfo) *crash {
+	switch {
+	case info.State == "exited" || info.State == "dead":
+		c := &crash{exitCode: info.ExitCode, oomKilled: info.OOMKilled, exited: true}
+		if info.OOMKilled {
+			c.reason = "container was killed for running out of memory"
+		} else {
+			c.reason = fmt.Sprintf("container exited with code %d", info.ExitCode)
+		}
+		return c
+	case info.Health == "unhealthy":
+		return &crash{reason: "container health check failed"}
+	}
+	return nil
+}
+
+// payload returns the crash details with snake_case keys for Hub events
+func (c *crash) payload(restarts int) map[string]interface{} {
+	return map[string]interface{}{
+		"reason":     c.reason,
+		"exit_code":  c.exitCode,
+		"oom_killed": c.oomKilled,
+		"restarts":   restarts,
+	}
+}
+
+// CheckRentals inspects the container of every running rental. A container
+// that exited, was OOM killed or turned unhealthy is restarted when the
+// rental's policy allows it (rental_restarted); otherwise the rental moves to
+// the failed phase and its resources are cleaned up after the grace period
+// (rental_failed).
+func (re *RentalExecutor) CheckRentals(ctx context.Context) {
+	re.mu.RLock()
+	running := make([]*RentalState, 0, len(re.activeRentals))
+	for _, state := range re.activeRentals {
+		if state.Phase == PhaseRunning {
+			running = append(running, state)
+		}
+	}
+	re.mu.RUnlock()
+
+	for _, state := range running {
+		re.mu.RLock()
+		containerID := state.ContainerID
+		re.mu.RUnlock()
+
+		info, err := re.docker.InspectContainer(ctx, containerID)
+		if err != nil {
+			continue // Docker may be briefly unavailable; next pass will retry
+		}
+		if c := detectCrash(info); c != nil {
+			re.handleCrash(ctx, state, c)
+		}
+	}
+}
+
+// MonitorRentals runs CheckRentals every interval until ctx is cancelled
+func (re *RentalExecutor) MonitorRentals(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			re.CheckRentals(ctx)
+		}
+	}
+}
+
+// handleCrash applies the rental's restart policy to a detected crash. A
+// clean exit (code 0) is not restarted, but still ends the rental.
+func (re *RentalExecutor) handleCrash(ctx context.Context, state *RentalState, c *crash) {
+	re.mu.Lock()
+	if state.Phase != PhaseRunning {
+		// A stop raced the inspect; the container exit is expected
+		re.mu.Unlock()
+		return
+	}
+	failure := !c.exited || c.exitCode != 0 || c.oomKilled
+	policy := state.RestartPolicy
+	restart := failure && policy.Mode == RestartOnFailure && state.Restarts < policy.MaxRestarts
+	if restart {
+		state.Restarts++
+	}
+	restarts := state.Restarts
+	containerID := state.ContainerID
+	re.mu.Unlock()
+
+	if restart {
+		if err := re.restartContainer(ctx, containerID, c.exited); err != nil {
+			c.reason = fmt.Sprintf("%s; restart failed: %v", c.reason, err)
+			re.failRunning(ctx, state, c)
+			return
+		}
+
+		// Don't leave a container running behind a stop that arrived meanwhile
+		re.mu.RLock()
+		stopped := state.Phase != PhaseRunning
+		re.mu.RUnlock()
+		if stopped {
+			_ = re.docker.StopContainer(ctx, containerID, 10)
+			return
+		}
+
+		payload := c.payload(restarts)
+		payload["max_restarts"] = policy.MaxRestarts
+		re.emit(Event{Type: EventRentalRestarted, SessionID: state.SessionID, Payload: payload})
+		return
+	}
+
+	re.failRunning(ctx, state, c)
+}
+
+// restartContainer starts an exited container again, or stops and starts an
+// unhealthy one
+func (re *RentalExecutor) restartContainer(ctx context.Context, containerID string, exited bool) error {
+	if !exited {
+		if err := re.docker.StopContainer(ctx, containerID, 10); err != nil {
+			return err
+		}
+	}
+	return re.docker.StartContainer(ctx, containerID)
+}
+
+// failRunning moves a running rental to the failed phase, closes its usage,
+// reports rental_failed and schedules cleanup after the grace period so its
+// logs stay readable in the meantime. A container that is still running
+// (unhealthy) is stopped so it releases the GPU right away.
+func (re *RentalExecutor) failRunning(ctx context.Context, state *RentalState, c *crash) {
+	re.mu.Lock()
+	ev, err := setPhase(state, PhaseFailed, c.reason)
+	if err != nil {
+		re.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	state.StoppedAt = &now
+	restarts := state.Restarts
+	snapshot := *state
+	re.mu.Unlock()
+	re.emit(ev)
+
+	re.finishUsage(ctx, snapshot)
+	if !c.exited {
+		_ = re.docker.StopContainer(ctx, snapshot.ContainerID, 10)
+	}
+
+	re.emit(Event{Type: EventRentalFailed, SessionID: state.SessionID, Payload: c.payload(restarts)})
+
+	go re.scheduleCleanup(state, snapshot)
+}
@@ -0,0 +1,162 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RestartPolicy
+	}{
+		{"", RestartPolicy{Mode: RestartNever}},
+		{"never", RestartPolicy{Mode: RestartNever}},
+		{"on-failure", RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3}},
+		{"on-failure:5", RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 5}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRestartPolicy(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"always", "on-failure:0", "on-failure:x", "never:2"} {
+		_, err := ParseRestartPolicy(bad)
+		assert.ErrorIs(t, err, ErrInvalidRestartPolicy, bad)
+	}
+
+	assert.Equal(t, "on-failure:5", RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 5}.String())
+	assert.Equal(t, "never", RestartPolicy{}.String())
+}
+
+// startMonitored starts a rental and then reports each inspect result in turn
+func startMonitored(t *testing.T, mockDocker *MockDockerService, executor *RentalExecutor, policy *RestartPolicy, infos ...container.ContainerInfo) {
+	t.Helper()
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", RestartPolicy: policy})
+	require.NoError(t, err)
+
+	mockDocker.inspectContainerFunc = func(ctx context.Context, containerID string) (*container.ContainerInfo, error) {
+		info := infos[0]
+		if len(infos) > 1 {
+			infos = infos[1:]
+		}
+		return &info, nil
+	}
+}
+
+func TestCheckRentals_RestartsWithinPolicyThenFails(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 10*time.Millisecond)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	policy := RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 1}
+	startMonitored(t, mockDocker, executor, &policy,
+		container.ContainerInfo{State: "exited", ExitCode: 1},
+		container.ContainerInfo{State: "exited", ExitCode: 137, OOMKilled: true},
+	)
+
+	executor.CheckRentals(context.Background())
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+	assert.Equal(t, 1, state.Restarts)
+	assert.Equal(t, []string{"container-123", "container-123"}, mockDocker.StartCalls)
+
+	restarted := eventsOfType(events, EventRentalRestarted)
+	require.Len(t, restarted, 1)
+	assert.Equal(t, 1, restarted[0].Payload["exit_code"])
+	assert.Equal(t, false, restarted[0].Payload["oom_killed"])
+	assert.Equal(t, 1, restarted[0].Payload["restarts"])
+
+	// The second crash exhausts the policy
+	executor.CheckRentals(context.Background())
+
+	state, err = executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseFailed, state.Phase)
+	assert.Contains(t, state.FailureReason, "out of memory")
+	assert.Len(t, mockDocker.StartCalls, 2)
+
+	failed := eventsOfType(events, EventRentalFailed)
+	require.Len(t, failed, 1)
+	assert.Equal(t, 137, failed[0].Payload["exit_code"])
+	assert.Equal(t, true, failed[0].Payload["oom_killed"])
+	assert.Len(t, eventsOfType(events, EventUsageFinal), 1)
+
+	// Resources are released after the grace period
+	time.Sleep(50 * time.Millisecond)
+	_, err = executor.GetRentalStatus("session-123")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.Contains(t, mockDocker.RemoveCalls, "container-123")
+}
+
+func TestCheckRentals_CleanExitIsNotRestarted(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+	executor.WithRestartPolicy(RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3})
+
+	startMonitored(t, mockDocker, executor, nil, container.ContainerInfo{State: "exited", ExitCode: 0})
+
+	executor.CheckRentals(context.Background())
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseFailed, state.Phase)
+	assert.Equal(t, 0, state.Restarts)
+	assert.Len(t, mockDocker.StartCalls, 1)
+}
+
+func TestCheckRentals_UnhealthyWithoutRestartStopsContainer(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	startMonitored(t, mockDocker, executor, nil, container.ContainerInfo{State: "running", Health: "unhealthy"})
+
+	executor.CheckRentals(context.Background())
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseFailed, state.Phase)
+	assert.Equal(t, "container health check failed", state.FailureReason)
+	assert.Equal(t, []string{"container-123"}, mockDocker.StopCalls)
+	require.Len(t, eventsOfType(events, EventRentalFailed), 1)
+
+	// The failed rental can't be replaced until its container is cleaned up
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	assert.ErrorIs(t, err, ErrSessionAlreadyActive)
+}
+
+func TestCheckRentals_IgnoresHealthyAndStoppedRentals(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	executor.CheckRentals(context.Background())
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+
+	// An exit caused by a stop is expected
+	require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+	mockDocker.inspectContainerFunc = func(ctx context.Context, containerID string) (*container.ContainerInfo, error) {
+		return &container.ContainerInfo{State: "exited", ExitCode: 143}, nil
+	}
+	executor.CheckRentals(context.Background())
+
+	state, err = executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseStopped, state.Phase)
+}
@@ -0,0 +1,117 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidDiskQuota is returned for a negative quota or one above the node maximum
+var ErrInvalidDiskQuota = errors.New("invalid disk quota")
+
+// WithDiskQuota sets the default writable layer quota applied to rentals that
+// don't request one, and whether rentals exceeding their quota are stopped
+// (true) or only flagged (false).
+func (re *RentalExecutor) WithDiskQuota(defaultBytes int64, stopOnExceed bool) *RentalExecutor {
+	re.defaultDiskQuota = defaultBytes
+	re.stopOnQuotaExceeded = stopOnExceed
+	return re
+}
+
+// WithMaxDiskQuota caps the writable layer quota a rental may request.
+// Rentals without a quota get the maximum instead of none.
+func (re *RentalExecutor) WithMaxDiskQuota(maxBytes int64) *RentalExecutor {
+	re.maxDiskQuota = maxBytes
+	return re
+}
+
+// diskQuota picks a rental's writable layer quota: the requested one or the
+// node default, checked against the node maximum
+func (re *RentalExecutor) diskQuota(requested int64) (int64, error) {
+	if requested < 0 {
+		return 0, fmt.Errorf("%w: %d bytes", ErrInvalidDiskQuota, requested)
+	}
+	quota := requested
+	if quota == 0 {
+		quota = re.defaultDiskQuota
+	}
+	if re.maxDiskQuota <= 0 {
+		return quota, nil
+	}
+	if quota == 0 {
+		return re.maxDiskQuota, nil
+	}
+	if quota > re.maxDiskQuota {
+		return 0, fmt.Errorf("%w: %d bytes exceeds node maximum of %d", ErrInvalidDiskQuota, quota, re.maxDiskQuota)
+	}
+	return quota, nil
+}
+
+// CheckDiskQuotas measures the writable layer of every running rental with a
+// quota and flags (or stops) those that exceed it. This is the fallback
+// enforcement for storage drivers that can't apply storage-opt size limits.
+func (re *RentalExecutor) CheckDiskQuotas(ctx context.Context) {
+	re.mu.RLock()
+	candidates := make([]RentalState, 0, len(re.activeRentals))
+	for _, state := range re.activeRentals {
+		if state.DiskQuotaBytes > 0 && state.Phase == PhaseRunning {
+			candidates = append(candidates, *state)
+		}
+	}
+	re.mu.RUnlock()
+
+	for _, c := range candidates {
+		usage, err := re.docker.DiskUsage(ctx, c.ContainerID)
+		if err != nil {
+			continue // Container may be going away; next pass will retry
+		}
+
+		re.mu.Lock()
+		state, exists := re.activeRentals[c.SessionID]
+		if !exists {
+			re.mu.Unlock()
+			continue
+		}
+		state.DiskUsageBytes = usage
+		newlyExceeded := usage > state.DiskQuotaBytes && !state.QuotaExceeded
+		if newlyExceeded {
+			state.QuotaExceeded = true
+		}
+		re.mu.Unlock()
+
+		if !newlyExceeded {
+			continue
+		}
+
+		stopped := false
+		if re.stopOnQuotaExceeded {
+			stopped = re.StopRental(ctx, c.SessionID) == nil
+		}
+
+		re.emit(Event{
+			Type:      EventDiskQuotaExceeded,
+			SessionID: c.SessionID,
+			Payload: map[string]interface{}{
+				"usage_bytes": usage,
+				"quota_bytes": c.DiskQuotaBytes,
+				"stopped":     stopped,
+			},
+		})
+	}
+}
+
+// MonitorDiskQuotas runs CheckDiskQuotas every interval until ctx is cancelled
+func (re *RentalExecutor) MonitorDiskQuotas(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			re.CheckDiskQuotas(ctx)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package rental
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+var ErrAccessNotReady = errors.New("rental access service not ready within timeout")
+
+// ReadinessProber checks that a rental's access service accepts renters
+type ReadinessProber interface {
+	Ready(ctx context.Context, mode container.AccessMode, hostPort int, token string) error
+}
+
+// ServiceProber probes access services through their published host ports
+type ServiceProber struct {
+	host   string
+	client *http.Client
+}
+
+// NewServiceProber creates a prober that connects via the local host
+func NewServiceProber() *ServiceProber {
+	return &ServiceProber{
+		host:   "127.0.0.1",
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Ready runs the mode's probe once:
+//   - ssh: the server sends an SSH protocol banner
+//   - jupyter: /api/status answers 200 with the access token
+//   - code-server: /healthz answers 200
+func (p *ServiceProber) Ready(ctx context.Context, mode container.AccessMode, hostPort int, token string) error {
+	addr := net.JoinHostPort(p.host, strconv.Itoa(hostPort))
+	switch mode {
+	case container.AccessModeJupyter:
+		return p.httpOK(ctx, "http://"+addr+"/api/status?token="+url.QueryEscape(token))
+	case container.AccessModeCodeServer:
+		return p.httpOK(ctx, "http://"+addr+"/healthz")
+	default:
+		return p.sshBanner(ctx, addr)
+	}
+}
+
+// httpOK requires a 200 response from the URL
+func (p *ServiceProber) httpOK(ctx context.Context, u string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sshBanner requires an SSH identification string from the server
+func (p *ServiceProber) sshBanner(ctx context.Context, addr string) error {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no SSH banner: %w", err)
+	}
+	if !strings.HasPrefix(line, "SSH-") {
+		return fmt.Errorf("unexpected SSH banner %q", strings.TrimSpace(line))
+	}
+	return nil
+}
+
+// WithReadinessProber makes StartRental wait until the access service passes
+// its probe, up to the given timeout
+func (re *RentalExecutor) WithReadinessProber(p ReadinessProber, timeout time.Duration) *RentalExecutor {
+	re.prober = p
+	re.readyTimeout = timeout
+	return re
+}
+
+// waitForReady polls the readiness probe until it passes or times out
+func (re *RentalExecutor) waitForReady(ctx context.Context, mode container.AccessMode, hostPort int, token string) error {
+	if re.prober == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(re.readyTimeout)
+	for {
+		if err := re.prober.Ready(ctx, mode, hostPort, token); err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrAccessNotReady
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(re.healthInterval):
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// mockProber implements ReadinessProber for testing
+type mockProber struct {
+	failures int // Number of probes to fail before succeeding
+
+	Calls []int // Probed host ports
+	Modes []container.AccessMode
+}
+
+func (m *mockProber) Ready(ctx context.Context, mode container.AccessMode, hostPort int, token string) error {
+	m.Calls = append(m.Calls, hostPort)
+	m.Modes = append(m.Modes, mode)
+	if len(m.Calls) <= m.failures {
+		return errors.New("not ready")
+	}
+	return nil
+}
+
+func serverPort(t *testing.T, rawURL string) int {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	return port
+}
+
+func TestServiceProber_Jupyter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/status" || r.URL.Query().Get("token") != "tok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := NewServiceProber()
+	port := serverPort(t, srv.URL)
+
+	assert.NoError(t, p.Ready(context.Background(), container.AccessModeJupyter, port, "tok"))
+	assert.Error(t, p.Ready(context.Background(), container.AccessModeJupyter, port, "wrong"))
+}
+
+func TestServiceProber_CodeServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	p := NewServiceProber()
+	assert.NoError(t, p.Ready(context.Background(), container.AccessModeCodeServer, serverPort(t, srv.URL), ""))
+}
+
+func TestServiceProber_SSHBanner(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("SSH-2.0-OpenSSH_8.9\r\n"))
+			conn.Close()
+		}
+	}()
+
+	p := NewServiceProber()
+	port := ln.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, p.Ready(context.Background(), container.AccessModeSSH, port, ""))
+}
+
+func TestStartRental_JupyterModePublishesServiceAndReturnsURL(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	prober := &mockProber{failures: 1}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).
+		WithReadinessProber(prober, time.Second)
+	executor.healthInterval = time.Millisecond
+
+	connInfo, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:  "session-123",
+		Host:       "provider.example.com",
+		AccessMode: container.AccessModeJupyter,
+	})
+	require.NoError(t, err)
+
+	cfg := mockDocker.CreateCalls[0]
+	assert.Equal(t, container.AccessModeJupyter, cfg.AccessMode)
+	assert.Len(t, cfg.AccessToken, 48)
+	assert.Equal(t, []container.PortMapping{{ContainerPort: 8888, HostPort: 30002, Protocol: "tcp"}}, cfg.ExtraPorts)
+
+	assert.Equal(t, cfg.AccessToken, connInfo.AccessToken)
+	assert.Equal(t, 30002, connInfo.AccessPort)
+	assert.Equal(t, "http://provider.example.com:30002/lab?token="+cfg.AccessToken, connInfo.AccessURL)
+	assert.Equal(t, []int{30002, 30002}, prober.Calls)
+}
+
+func TestStartRental_SSHModeProbesSSHPort(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	prober := &mockProber{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).
+		WithReadinessProber(prober, time.Second)
+
+	connInfo, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{30001}, prober.Calls)
+	assert.Equal(t, []container.AccessMode{container.AccessModeSSH}, prober.Modes)
+	assert.Empty(t, connInfo.AccessURL)
+	assert.Empty(t, mockDocker.CreateCalls[0].AccessToken)
+}
+
+func TestStartRental_CleansUpWhenAccessNeverReady(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).
+		WithReadinessProber(&mockProber{failures: 1000}, 10*time.Millisecond)
+	executor.healthInterval = time.Millisecond
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:  "session-123",
+		AccessMode: container.AccessModeCodeServer,
+	})
+
+	assert.ErrorIs(t, err, ErrAccessNotReady)
+	assert.Equal(t, []string{"container-123"}, mockDocker.RemoveCalls)
+	assert.ElementsMatch(t, []int{30001, 30002}, mockPort.ReleaseCalls)
+}
+
+func TestStartRental_RejectsUnknownAccessMode(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:  "session-123",
+		AccessMode: "vnc",
+	})
+
+	assert.ErrorIs(t, err, container.ErrInvalidAccessMode)
+}
@@ -0,0 +1,208 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/worldland/worldland-node/internal/container"
+	"github.com/worldland/worldland-node/internal/domain"
+)
+
+// ErrRebuildUnavailable is returned for rentals whose container config is unknown
+var ErrRebuildUnavailable = errors.New("rental was adopted after a node restart and can't be rebuilt")
+
+// RebuildRentalRequest contains parameters for rebuilding a rental
+type RebuildRentalRequest struct {
+	SessionID    string
+	Image        string
+	RegistryAuth *container.RegistryAuth // Private image credentials, used for the pull only
+	Host         string                  // Host address for SSH command
+}
+
+// RebuildRental replaces a running rental's container with one from a new
+// image. The GPU, ports, credentials, access token and workspace volume are
+// kept; everything outside the workspace is lost. If the new container
+// doesn't come up, the old one is started again and the rental keeps
+// running. A stop during the rebuild aborts it like a stop during a start.
+func (re *RentalExecutor) RebuildRental(ctx context.Context, req RebuildRentalRequest) (*ConnectionInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	re.mu.Lock()
+	state, exists := re.activeRentals[req.SessionID]
+	if !exists {
+		re.mu.Unlock()
+		return nil, ErrSessionNotFound
+	}
+	if state.Phase != PhaseRunning {
+		re.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrRentalNotRunning, state.Phase)
+	}
+	if state.spec == nil {
+		re.mu.Unlock()
+		return nil, ErrRebuildUnavailable
+	}
+	ev, _ := setPhase(state, PhaseRebuilding, "rebuild with "+req.Image)
+	state.cancelStart = cancel
+	spec := *state.spec
+	snapshot := *state
+	re.mu.Unlock()
+	re.emit(ev)
+
+	oldID := snapshot.ContainerID
+	var newID string
+	renamed := false
+	fail := func(err error) (*ConnectionInfo, error) {
+		return nil, re.failRebuild(state, oldID, newID, renamed, err)
+	}
+
+	image, err := re.pullImage(ctx, state, StartRentalRequest{SessionID: req.SessionID, Image: req.Image, RegistryAuth: req.RegistryAuth})
+	if err != nil {
+		return fail(err)
+	}
+
+	// Record the old container's counters before they reset
+	var gpus []domain.GPUMetrics
+	if re.gpuMetrics != nil {
+		gpus, _ = re.gpuMetrics.GetMetrics()
+	}
+	re.sampleRental(ctx, snapshot, gpus)
+
+	// Free the ports and the container name, keeping the old container for
+	// a rollback
+	if err := re.docker.StopContainer(ctx, oldID, 10); err != nil {
+		return fail(fmt.Errorf("failed to stop container: %w", err))
+	}
+	if err := re.docker.RenameContainer(ctx, oldID, req.SessionID+"-prev"); err != nil {
+		return fail(err)
+	}
+	renamed = true
+
+	spec.Image = image
+	newID, err = re.docker.CreateContainer(ctx, spec)
+	if err != nil {
+		return fail(fmt.Errorf("failed to create container: %w", err))
+	}
+	if err := re.docker.StartContainer(ctx, newID); err != nil {
+		return fail(fmt.Errorf("failed to start container: %w", err))
+	}
+	if err := re.waitForHealth(ctx, newID); err != nil {
+		return fail(fmt.Errorf("failed health check: %w", err))
+	}
+	accessPort := spec.SSHPort
+	if spec.AccessMode.ServicePort() != 0 {
+		accessPort = snapshot.Ports[0].HostPort
+	}
+	if err := re.waitForReady(ctx, spec.AccessMode, accessPort, spec.AccessToken); err != nil {
+		return fail(fmt.Errorf("failed readiness check: %w", err))
+	}
+
+	// A stop that arrived meanwhile wins
+	re.mu.Lock()
+	ev, err = setPhase(state, PhaseRunning, "rebuilt with "+image)
+	if err == nil {
+		state.ContainerID = newID
+		state.Image = image
+		state.spec = &spec
+		state.Restarts = 0
+		state.cancelStart = nil
+	}
+	re.mu.Unlock()
+	if err != nil {
+		return fail(err)
+	}
+	re.emit(ev)
+	_ = re.docker.RemoveContainer(context.Background(), oldID, true)
+
+	re.emit(Event{
+		Type:      EventRentalRebuilt,
+		SessionID: req.SessionID,
+		Payload: map[string]interface{}{
+			"container_id":          newID,
+			"previous_container_id": oldID,
+			"image":                 image,
+		},
+	})
+
+	connInfo := &ConnectionInfo{
+		Host:        req.Host,
+		Port:        spec.SSHPort,
+		User:        "ubuntu",
+		Command:     fmt.Sprintf("ssh -p %d ubuntu@%s", spec.SSHPort, req.Host),
+		ContainerID: newID,
+		Ports:       snapshot.Ports,
+		AccessMode:  spec.AccessMode,
+		AccessPort:  accessPort,
+		AccessToken: spec.AccessToken,
+	}
+	if spec.AccessMode.ServicePort() != 0 {
+		connInfo.AccessURL = spec.AccessMode.URL(req.Host, accessPort, spec.AccessToken)
+	}
+	return connInfo, nil
+}
+
+// failRebuild removes the new container and restores the old one. A rebuild
+// interrupted by StopRental finishes the stop and reports ErrStartAborted;
+// otherwise the old container is started again and the rental returns to
+// running, or fails if that doesn't work either.
+func (re *RentalExecutor) failRebuild(state *RentalState, oldID, newID string, renamed bool, cause error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if newID != "" {
+		_ = re.docker.RemoveContainer(ctx, newID, true)
+	}
+	if renamed {
+		_ = re.docker.RenameContainer(ctx, oldID, state.SessionID)
+	}
+
+	// finishStop completes a stop that arrived during the rebuild
+	finishStop := func(snapshot RentalState) error {
+		re.finishUsage(ctx, snapshot)
+		_ = re.docker.StopContainer(ctx, oldID, 10)
+		_ = re.transition(state, PhaseStopped, "")
+		go re.scheduleCleanup(state, snapshot)
+		return ErrStartAborted
+	}
+
+	re.mu.Lock()
+	aborted := state.Phase == PhaseStopping
+	state.cancelStart = nil
+	snapshot := *state
+	re.mu.Unlock()
+	if aborted {
+		return finishStop(snapshot)
+	}
+
+	restoreErr := re.docker.StartContainer(ctx, oldID)
+	if restoreErr == nil {
+		restoreErr = re.waitForHealth(ctx, oldID)
+	}
+
+	re.mu.Lock()
+	if state.Phase == PhaseStopping {
+		snapshot = *state
+		re.mu.Unlock()
+		return finishStop(snapshot)
+	}
+	if restoreErr != nil {
+		reason := fmt.Sprintf("rebuild failed: %v; restoring previous container failed: %v", cause, restoreErr)
+		ev, _ := setPhase(state, PhaseFailed, reason)
+		now := time.Now()
+		state.StoppedAt = &now
+		snapshot = *state
+		re.mu.Unlock()
+		re.emit(ev)
+		re.emit(Event{Type: EventRentalFailed, SessionID: state.SessionID, Payload: map[string]interface{}{"reason": reason}})
+		re.finishUsage(ctx, snapshot)
+		_ = re.docker.StopContainer(ctx, oldID, 10)
+		go re.scheduleCleanup(state, snapshot)
+		return cause
+	}
+	ev, _ := setPhase(state, PhaseRunning, "rebuild failed: "+cause.Error())
+	re.mu.Unlock()
+	re.emit(ev)
+	return cause
+}
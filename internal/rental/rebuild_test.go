@@ -0,0 +1,137 @@
+package rental
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+// startForRebuild starts a rental whose next container gets ID "container-new"
+func startForRebuild(t *testing.T, mockDocker *MockDockerService) *RentalExecutor {
+	t.Helper()
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:   "session-123",
+		Image:       "nvidia/cuda:11.8.0-runtime-ubuntu22.04",
+		GPUDeviceID: "GPU-aaa",
+		SSHPassword: "secret",
+	})
+	require.NoError(t, err)
+	mockDocker.createContainerFunc = func(ctx context.Context, cfg container.ContainerConfig) (string, error) {
+		return "container-new", nil
+	}
+	return executor
+}
+
+func TestRebuildRental_ReplacesContainerKeepingPortsAndWorkspace(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := startForRebuild(t, mockDocker)
+	var events []Event
+	executor.OnEvent = func(ev Event) { events = append(events, ev) }
+
+	info, err := executor.RebuildRental(context.Background(), RebuildRentalRequest{
+		SessionID: "session-123",
+		Image:     "nvidia/cuda:12.4.1-runtime-ubuntu22.04",
+		Host:      "provider.example.com",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "container-new", info.ContainerID)
+	assert.Equal(t, 30001, info.Port)
+
+	require.Len(t, mockDocker.CreateCalls, 2)
+	old, rebuilt := mockDocker.CreateCalls[0], mockDocker.CreateCalls[1]
+	assert.Equal(t, "nvidia/cuda:12.4.1-runtime-ubuntu22.04", rebuilt.Image)
+	assert.Equal(t, old.SSHPort, rebuilt.SSHPort)
+	assert.Equal(t, "secret", rebuilt.SSHPassword)
+	assert.Equal(t, "GPU-aaa", rebuilt.GPUDeviceID)
+	assert.Equal(t, "wl-rental-session-123-workspace", rebuilt.WorkspaceVolume)
+
+	assert.Equal(t, []string{"container-123"}, mockDocker.StopCalls)
+	assert.Equal(t, []string{"container-123 -> session-123-prev"}, mockDocker.RenameCalls)
+	assert.Equal(t, []string{"container-123"}, mockDocker.RemoveCalls)
+	assert.Empty(t, mockDocker.WorkspaceRemoveCalls)
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+	assert.Equal(t, "container-new", state.ContainerID)
+	assert.Equal(t, "nvidia/cuda:12.4.1-runtime-ubuntu22.04", state.Image)
+
+	assert.Equal(t, []Phase{PhaseRebuilding, PhaseRunning}, phasesOf(events))
+	last := events[len(events)-1]
+	assert.Equal(t, EventRentalRebuilt, last.Type)
+	assert.Equal(t, "container-new", last.Payload["container_id"])
+	assert.Equal(t, "container-123", last.Payload["previous_container_id"])
+}
+
+func TestRebuildRental_RestoresOldContainerOnFailure(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := startForRebuild(t, mockDocker)
+	mockDocker.startContainerFunc = func(ctx context.Context, containerID string) error {
+		if containerID == "container-new" {
+			return errors.New("cuda driver mismatch")
+		}
+		return nil
+	}
+
+	_, err := executor.RebuildRental(context.Background(), RebuildRentalRequest{SessionID: "session-123", Image: "bad:image"})
+
+	assert.ErrorContains(t, err, "cuda driver mismatch")
+	assert.Equal(t, []string{"container-new"}, mockDocker.RemoveCalls)
+	assert.Equal(t, []string{
+		"container-123 -> session-123-prev",
+		"container-123 -> session-123",
+	}, mockDocker.RenameCalls)
+	assert.Equal(t, "container-123", mockDocker.StartCalls[len(mockDocker.StartCalls)-1])
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseRunning, state.Phase)
+	assert.Equal(t, "container-123", state.ContainerID)
+	assert.Equal(t, "nvidia/cuda:11.8.0-runtime-ubuntu22.04", state.Image)
+}
+
+func TestRebuildRental_StopDuringRebuildAbortsIt(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := startForRebuild(t, mockDocker)
+	mockDocker.prepareImageFunc = func(ctx context.Context, image string, auth *container.RegistryAuth, onProgress container.PullProgressFunc) (string, error) {
+		require.NoError(t, executor.StopRental(context.Background(), "session-123"))
+		return "", ctx.Err()
+	}
+
+	_, err := executor.RebuildRental(context.Background(), RebuildRentalRequest{SessionID: "session-123", Image: "new:image"})
+
+	assert.ErrorIs(t, err, ErrStartAborted)
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, PhaseStopped, state.Phase)
+	assert.Equal(t, []string{"container-123"}, mockDocker.StopCalls)
+	assert.Len(t, mockDocker.CreateCalls, 1)
+}
+
+func TestRebuildRental_RejectsAdoptedAndStoppedRentals(t *testing.T) {
+	mockDocker := &MockDockerService{
+		RentalContainers: []container.RentalContainer{
+			{ContainerID: "container-1", SessionID: "session-1", State: "running"},
+		},
+	}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute)
+	_, err := executor.AdoptRentals(context.Background())
+	require.NoError(t, err)
+
+	_, err = executor.RebuildRental(context.Background(), RebuildRentalRequest{SessionID: "session-1", Image: "new:image"})
+	assert.ErrorIs(t, err, ErrRebuildUnavailable)
+
+	require.NoError(t, executor.StopRental(context.Background(), "session-1"))
+	_, err = executor.RebuildRental(context.Background(), RebuildRentalRequest{SessionID: "session-1", Image: "new:image"})
+	assert.ErrorIs(t, err, ErrRentalNotRunning)
+
+	_, err = executor.RebuildRental(context.Background(), RebuildRentalRequest{SessionID: "missing", Image: "new:image"})
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
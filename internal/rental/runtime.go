@@ -0,0 +1,69 @@
+package rental
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+var (
+	ErrUnknownRuntimeClass = errors.New("unknown runtime class")
+	ErrRuntimeUnavailable  = errors.New("container runtime not available on this node")
+)
+
+// DefaultRuntimeClass is the class of rentals that don't ask for one
+const DefaultRuntimeClass = "default"
+
+// WithRuntimes sets the runtime classes rentals may ask for, e.g. "sandboxed"
+// mapped to gVisor, and the runtimes the Docker daemon advertises (nil = not
+// checked). Without a "default" class rentals use container.DefaultRuntime.
+func (re *RentalExecutor) WithRuntimes(classes map[string]container.Runtime, available []string) *RentalExecutor {
+	re.runtimeClasses = classes
+	re.availableRuntimes = available
+	return re
+}
+
+// RuntimeClasses returns the classes rentals can start with on this node, sorted
+func (re *RentalExecutor) RuntimeClasses() []string {
+	classes := []string{}
+	if _, ok := re.runtimeClasses[DefaultRuntimeClass]; !ok {
+		if _, _, err := re.resolveRuntime(DefaultRuntimeClass); err == nil {
+			classes = append(classes, DefaultRuntimeClass)
+		}
+	}
+	for class := range re.runtimeClasses {
+		if _, _, err := re.resolveRuntime(class); err == nil {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// resolveRuntime returns the runtime of a rental class. Classes whose runtime
+// the daemon doesn't advertise are refused.
+func (re *RentalExecutor) resolveRuntime(class string) (string, container.Runtime, error) {
+	if class == "" {
+		class = DefaultRuntimeClass
+	}
+	runtime, ok := re.runtimeClasses[class]
+	if !ok {
+		if class != DefaultRuntimeClass {
+			return "", container.Runtime{}, fmt.Errorf("%w: %q", ErrUnknownRuntimeClass, class)
+		}
+		runtime = container.DefaultRuntime
+	}
+
+	if re.availableRuntimes != nil {
+		available := false
+		for _, name := range re.availableRuntimes {
+			available = available || name == runtime.Name
+		}
+		if !available {
+			return "", container.Runtime{}, fmt.Errorf("%w: %s (class %s)", ErrRuntimeUnavailable, runtime.Name, class)
+		}
+	}
+	return class, runtime, nil
+}
@@ -0,0 +1,65 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func runtimeTestClasses() map[string]container.Runtime {
+	return map[string]container.Runtime{
+		DefaultRuntimeClass: container.DefaultRuntime,
+		"sandboxed":         {Name: "runsc", GPUs: container.GPUAttachDeviceRequest},
+		"kata":              {Name: "kata", GPUs: container.GPUAttachDeviceRequest},
+	}
+}
+
+func TestStartRental_UsesRuntimeClass(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).
+		WithRuntimes(runtimeTestClasses(), []string{"nvidia", "runc", "runsc"})
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", RuntimeClass: "sandboxed"})
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.CreateCalls, 1)
+	assert.Equal(t, container.Runtime{Name: "runsc", GPUs: container.GPUAttachDeviceRequest}, mockDocker.CreateCalls[0].Runtime)
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "sandboxed", state.RuntimeClass)
+}
+
+func TestStartRental_RefusesUnavailableRuntime(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	mockPort := &MockPortManager{}
+	executor := NewRentalExecutor(mockDocker, mockPort, time.Minute).
+		WithRuntimes(runtimeTestClasses(), []string{"nvidia", "runc", "runsc"})
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123", RuntimeClass: "kata"})
+	assert.ErrorIs(t, err, ErrRuntimeUnavailable)
+
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-456", RuntimeClass: "firecracker"})
+	assert.ErrorIs(t, err, ErrUnknownRuntimeClass)
+
+	assert.Empty(t, mockDocker.CreateCalls)
+	assert.Empty(t, mockPort.AllocateCalls)
+	assert.Equal(t, []string{"default", "sandboxed"}, executor.RuntimeClasses())
+}
+
+func TestResolveRuntime_Defaults(t *testing.T) {
+	// No classes configured: the nvidia runtime, unchecked
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, time.Minute)
+	class, runtime, err := executor.resolveRuntime("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRuntimeClass, class)
+	assert.Equal(t, container.DefaultRuntime, runtime)
+
+	// The default class is checked against the daemon too
+	executor.WithRuntimes(nil, []string{"runc"})
+	_, _, err = executor.resolveRuntime("")
+	assert.ErrorIs(t, err, ErrRuntimeUnavailable)
+}
@@ -0,0 +1,29 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestStartRental_AppliesSecurityProfile(t *testing.T) {
+	profile, err := container.SecurityPreset(container.SecurityProfileStrict)
+	require.NoError(t, err)
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, time.Minute).WithSecurityProfile(profile)
+
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{SessionID: "session-123"})
+	require.NoError(t, err)
+
+	require.Len(t, mockDocker.CreateCalls, 1)
+	assert.Equal(t, profile, mockDocker.CreateCalls[0].Security)
+	assert.Equal(t, "strict", mockDocker.CreateCalls[0].Labels[container.LabelSecurity])
+
+	state, err := executor.GetRentalStatus("session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "strict", state.SecurityProfile)
+}
@@ -0,0 +1,83 @@
+package rental
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+var (
+	ErrEnvNotAllowed      = errors.New("environment variable not allowed")
+	ErrInvalidEnv         = errors.New("invalid environment variable")
+	ErrInvalidWorkDir     = errors.New("invalid working directory")
+	ErrInvalidInitCommand = errors.New("invalid init command")
+)
+
+// Limits on renter-supplied runtime settings
+const (
+	maxEnvVars        = 128
+	maxEnvValueBytes  = 32 * 1024
+	maxInitCommandLen = 16 * 1024
+)
+
+// DefaultEnvDenylist blocks variables that would change GPU visibility or
+// inject code into every process
+var DefaultEnvDenylist = []string{"NVIDIA_*", "CUDA_VISIBLE_DEVICES", "LD_PRELOAD", "LD_LIBRARY_PATH"}
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// WithEnvDenylist sets patterns (path.Match syntax, e.g. "NVIDIA_*") for
+// environment variables renters may not set. Node-reserved variables are
+// always denied.
+func (re *RentalExecutor) WithEnvDenylist(patterns []string) *RentalExecutor {
+	re.envDenylist = patterns
+	return re
+}
+
+// validateRuntimeSpec checks renter env, init command and working directory
+func (re *RentalExecutor) validateRuntimeSpec(req StartRentalRequest) error {
+	if len(req.Env) > maxEnvVars {
+		return fmt.Errorf("%w: at most %d variables", ErrInvalidEnv, maxEnvVars)
+	}
+	for _, key := range req.Env.Keys() {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: bad name %q", ErrInvalidEnv, key)
+		}
+		value := req.Env[key]
+		if len(value) > maxEnvValueBytes || strings.ContainsRune(value, 0) {
+			return fmt.Errorf("%w: bad value for %s", ErrInvalidEnv, key)
+		}
+		if re.envDenied(key) {
+			return fmt.Errorf("%w: %s", ErrEnvNotAllowed, key)
+		}
+	}
+
+	if len(req.InitCommand) > maxInitCommandLen || strings.ContainsRune(req.InitCommand, 0) {
+		return ErrInvalidInitCommand
+	}
+
+	if req.WorkDir != "" {
+		if !path.IsAbs(req.WorkDir) || path.Clean(req.WorkDir) != req.WorkDir ||
+			strings.ContainsAny(req.WorkDir, "\x00\n\"") {
+			return fmt.Errorf("%w: %q", ErrInvalidWorkDir, req.WorkDir)
+		}
+	}
+	return nil
+}
+
+// envDenied reports whether a variable is reserved or matches the denylist
+func (re *RentalExecutor) envDenied(key string) bool {
+	if container.IsReservedEnv(key) {
+		return true
+	}
+	for _, pattern := range re.envDenylist {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
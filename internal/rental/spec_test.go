@@ -0,0 +1,91 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/worldland/worldland-node/internal/container"
+)
+
+func TestStartRental_PassesEnvInitCommandAndWorkDir(t *testing.T) {
+	mockDocker := &MockDockerService{}
+	executor := NewRentalExecutor(mockDocker, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:   "session-123",
+		Env:         container.Env{"HF_HOME": "/data/hf"},
+		InitCommand: "python serve.py",
+		WorkDir:     "/workspace",
+	})
+	require.NoError(t, err)
+
+	cfg := mockDocker.CreateCalls[0]
+	assert.Equal(t, container.Env{"HF_HOME": "/data/hf"}, cfg.Env)
+	assert.Equal(t, "python serve.py", cfg.InitCommand)
+	assert.Equal(t, "/workspace", cfg.WorkDir)
+}
+
+func TestStartRental_RejectsDeniedEnv(t *testing.T) {
+	cases := map[string]string{
+		"default denylist": "NVIDIA_VISIBLE_DEVICES",
+		"wildcard":         "NVIDIA_REQUIRE_CUDA",
+		"reserved":         "SSH_PASSWORD",
+		"operator":         "MY_BLOCKED_VAR",
+	}
+	for name, key := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockDocker := &MockDockerService{}
+			mockPort := &MockPortManager{}
+			executor := NewRentalExecutor(mockDocker, mockPort, 1*time.Minute).
+				WithEnvDenylist(append(DefaultEnvDenylist, "MY_BLOCKED_*"))
+
+			_, err := executor.StartRental(context.Background(), StartRentalRequest{
+				SessionID: "session-123",
+				Env:       container.Env{key: "x"},
+			})
+
+			assert.ErrorIs(t, err, ErrEnvNotAllowed)
+			assert.Empty(t, mockPort.AllocateCalls)
+			assert.Empty(t, mockDocker.CreateCalls)
+		})
+	}
+}
+
+func TestStartRental_ReservedEnvDeniedWithEmptyDenylist(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute).
+		WithEnvDenylist(nil)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID: "session-123",
+		Env:       container.Env{"ACCESS_TOKEN": "x"},
+	})
+
+	assert.ErrorIs(t, err, ErrEnvNotAllowed)
+}
+
+func TestStartRental_RejectsMalformedSpec(t *testing.T) {
+	executor := NewRentalExecutor(&MockDockerService{}, &MockPortManager{}, 1*time.Minute)
+
+	_, err := executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID: "session-123",
+		Env:       container.Env{"BAD-NAME": "x"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidEnv)
+
+	for _, dir := range []string{"workspace", "/a/../etc", "/a/"} {
+		_, err = executor.StartRental(context.Background(), StartRentalRequest{
+			SessionID: "session-123",
+			WorkDir:   dir,
+		})
+		assert.ErrorIs(t, err, ErrInvalidWorkDir, dir)
+	}
+
+	_, err = executor.StartRental(context.Background(), StartRentalRequest{
+		SessionID:   "session-123",
+		InitCommand: "echo \x00",
+	})
+	assert.ErrorIs(t, err, ErrInvalidInitCommand)
+}
@@ -1,18 +1,22 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"time"
 
 	"github.com/worldland/worldland-node/internal/adapters/mtls"
+	"github.com/worldland/worldland-node/internal/container"
 	"github.com/worldland/worldland-node/internal/domain"
 	"github.com/worldland/worldland-node/internal/mining"
+	"github.com/worldland/worldland-node/internal/receipt"
 	"github.com/worldland/worldland-node/internal/rental"
 )
 
@@ -31,6 +35,14 @@ type NodeDaemon struct {
 
 	// Mining daemon (set via WithMiningDaemon)
 	miningDaemon *mining.MiningDaemon
+
+	// Image cache (set via WithImageCache)
+	imageCache *container.ImageCache
+
+	// Usage receipts (set via WithReceipts)
+	receipts        *receipt.Issuer
+	receiptInterval time.Duration
+	defaultPrice    string // Wei per GPU second when the Hub doesn't send one
 }
 
 // NewNodeDaemon creates a new node daemon
@@ -47,6 +59,7 @@ func NewNodeDaemon(gpuProvider domain.GPUProvider, nodeID string) *NodeDaemon {
 func (d *NodeDaemon) WithRentalExecutor(executor *rental.RentalExecutor, hostAddr string) *NodeDaemon {
 	d.rentalExecutor = executor
 	d.hostAddr = hostAddr
+	executor.OnEvent = d.forwardRentalEvent
 	return d
 }
 
@@ -56,6 +69,21 @@ func (d *NodeDaemon) WithMiningDaemon(md *mining.MiningDaemon) *NodeDaemon {
 	return d
 }
 
+// WithImageCache enables image prefetch commands and cached image reporting
+func (d *NodeDaemon) WithImageCache(cache *container.ImageCache) *NodeDaemon {
+	d.imageCache = cache
+	return d
+}
+
+// WithReceipts enables signed usage receipts: one per running rental every
+// interval, and a final one when the rental stops
+func (d *NodeDaemon) WithReceipts(issuer *receipt.Issuer, interval time.Duration, defaultPrice string) *NodeDaemon {
+	d.receipts = issuer
+	d.receiptInterval = interval
+	d.defaultPrice = defaultPrice
+	return d
+}
+
 // ConnectToHub establishes mTLS connection to Hub
 func (d *NodeDaemon) ConnectToHub(hubAddr string, cert tls.Certificate, rootCAs *x509.CertPool) error {
 	d.mtlsClient = mtls.NewClient(hubAddr, cert, rootCAs)
@@ -86,6 +114,10 @@ func (d *NodeDaemon) Start() error {
 	// Start metrics reporting
 	go d.reportMetrics()
 
+	if d.receipts != nil && d.rentalExecutor != nil {
+		go d.issueReceipts()
+	}
+
 	// Wait for stop signal
 	<-d.stopCh
 	return nil
@@ -100,12 +132,22 @@ func (d *NodeDaemon) handleCommand(cmd mtls.Command) mtls.CommandAck {
 		return d.handleStartRental(cmd)
 	case "stop_rental":
 		return d.handleStopRental(cmd)
+	case "rebuild_rental":
+		return d.handleRebuildRental(cmd)
 	case "start_job":
 		// Legacy alias for start_rental
 		return d.handleStartRental(cmd)
 	case "stop_job":
 		// Legacy alias for stop_rental
 		return d.handleStopRental(cmd)
+	case "prefetch_images":
+		return d.handlePrefetchImages(cmd)
+	case "rental_logs":
+		return d.handleRentalLogs(cmd)
+	case "exec_diagnostic":
+		return d.handleExecDiagnostic(cmd)
+	case "drain":
+		return d.handleDrain(cmd)
 	default:
 		log.Printf("Unknown command type: %s", cmd.Type)
 		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "unknown command"}
@@ -128,6 +170,11 @@ func (d *NodeDaemon) handleStartRental(cmd mtls.Command) mtls.CommandAck {
 		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "missing session_id"}
 	}
 
+	// Checked before mining is paused for the rental
+	if d.rentalExecutor.Draining() {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: rental.ErrDraining.Error(), ErrorCode: "NODE_DRAINING"}
+	}
+
 	image, _ := cmd.Payload["image"].(string)
 	if image == "" {
 		image = "nvidia/cuda:12.1.1-runtime-ubuntu22.04"
@@ -146,7 +193,43 @@ func (d *NodeDaemon) handleStartRental(cmd mtls.Command) mtls.CommandAck {
 		memoryMB = int64(v)
 	}
 
-	log.Printf("Starting rental: session=%s image=%s gpu=%s", sessionID, image, gpuDeviceID)
+	// Optional disk quota (0 = node default)
+	var diskQuotaBytes int64
+	if v, ok := cmd.Payload["disk_quota_gb"].(float64); ok {
+		diskQuotaBytes = int64(v * 1024 * 1024 * 1024)
+	}
+	scratchMounts := parseScratchMounts(cmd.Payload["scratch_mounts"])
+	var shmSizeBytes int64
+	if v, ok := cmd.Payload["shm_size_mb"].(float64); ok {
+		shmSizeBytes = int64(v) * 1024 * 1024
+	}
+	ulimits := parseUlimits(cmd.Payload["ulimits"])
+	ipcMode, _ := cmd.Payload["ipc_mode"].(string)
+	runtimeClass, _ := cmd.Payload["runtime_class"].(string)
+	egressPolicy := parseEgressPolicy(cmd.Payload["egress_policy"])
+	exposedPorts := parseExposedPorts(cmd.Payload["expose_ports"])
+	accessMode, _ := cmd.Payload["access_mode"].(string)
+	env := parseEnv(cmd.Payload["env"])
+	initCommand, _ := cmd.Payload["init_command"].(string)
+	workDir, _ := cmd.Payload["workdir"].(string)
+	registryAuth := parseRegistryAuth(cmd.Payload["registry_auth"])
+	pricePerSecond, _ := cmd.Payload["price_per_sec"].(string)
+	if pricePerSecond == "" {
+		pricePerSecond = d.defaultPrice
+	}
+
+	// Optional restart policy ("never", "on-failure[:N]"; absent = node default)
+	var restartPolicy *rental.RestartPolicy
+	if v, ok := cmd.Payload["restart_policy"].(string); ok && v != "" {
+		policy, err := rental.ParseRestartPolicy(v)
+		if err != nil {
+			return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: err.Error(), ErrorCode: "INVALID_RESTART_POLICY"}
+		}
+		restartPolicy = &policy
+	}
+
+	// env prints with values redacted
+	log.Printf("Starting rental: session=%s image=%s gpu=%s mode=%s env=%v", sessionID, image, gpuDeviceID, accessMode, env)
 
 	// Pause mining to release GPU for rental
 	if d.miningDaemon != nil && gpuDeviceID != "" {
@@ -163,25 +246,52 @@ func (d *NodeDaemon) handleStartRental(cmd mtls.Command) mtls.CommandAck {
 	defer cancel()
 
 	connInfo, err := d.rentalExecutor.StartRental(ctx, rental.StartRentalRequest{
-		SessionID:    sessionID,
-		Image:        image,
-		GPUDeviceID:  gpuDeviceID,
+		SessionID:   sessionID,
+		Image:       image,
+		GPUDeviceID: gpuDeviceID,
 		SSHPassword: sshPassword,
-		MemoryBytes:  memoryMB * 1024 * 1024,
-		CPUCount:     cpuCount,
-		Host:         d.hostAddr,
+		MemoryBytes: memoryMB * 1024 * 1024,
+		CPUCount:    cpuCount,
+		Host:        d.hostAddr,
+
+		DiskQuotaBytes: diskQuotaBytes,
+		ScratchMounts:  scratchMounts,
+		ShmSizeBytes:   shmSizeBytes,
+		Ulimits:        ulimits,
+		IPCMode:        ipcMode,
+		RuntimeClass:   runtimeClass,
+		EgressPolicy:   egressPolicy,
+		ExposedPorts:   exposedPorts,
+		AccessMode:     container.AccessMode(accessMode),
+		Env:            env,
+		InitCommand:    initCommand,
+		PricePerSecond: pricePerSecond,
+		RestartPolicy:  restartPolicy,
+		WorkDir:        workDir,
+		RegistryAuth:   registryAuth,
 	})
 	if err != nil {
 		log.Printf("Failed to start rental %s: %v", sessionID, err)
+		code, _ := rental.ErrorCode(err)
 		return mtls.CommandAck{
 			CommandID: cmd.ID,
 			Status:    "error",
 			Error:     fmt.Sprintf("failed to start rental: %v", err),
+			ErrorCode: code,
 		}
 	}
 
 	log.Printf("Rental started: session=%s ssh=%s:%d", sessionID, connInfo.Host, connInfo.Port)
 
+	return mtls.CommandAck{
+		CommandID: cmd.ID,
+		Status:    "ok",
+		Payload:   d.connectionPayload(sessionID, connInfo),
+	}
+}
+
+// connectionPayload builds the ack payload telling the Hub how to reach a rental
+func (d *NodeDaemon) connectionPayload(sessionID string, connInfo *rental.ConnectionInfo) map[string]interface{} {
 	// Resolve public IP if host is hostname
 	sshHost := d.hostAddr
 	if sshHost == "" || sshHost == "localhost" {
@@ -189,16 +299,68 @@ func (d *NodeDaemon) handleStartRental(cmd mtls.Command) mtls.CommandAck {
 		sshHost = getOutboundIP()
 	}
 
+	payload := map[string]interface{}{
+		"session_id":   sessionID,
+		"ssh_host":     sshHost,
+		"ssh_port":     float64(connInfo.Port),
+		"ssh_user":     connInfo.User,
+		"container_id": connInfo.ContainerID,
+		"ports":        portsPayload(connInfo.Ports),
+		"access_mode":  string(connInfo.AccessMode),
+	}
+	if connInfo.AccessURL != "" {
+		// URL is built from the node's configured host; rebuild it with the resolved address
+		payload["access_url"] = connInfo.AccessMode.URL(sshHost, connInfo.AccessPort, connInfo.AccessToken)
+		payload["access_token"] = connInfo.AccessToken
+	}
+	return payload
+}
+
+// handleRebuildRental replaces a running rental's container with a new image,
+// keeping its GPU, ports, credentials and workspace
+func (d *NodeDaemon) handleRebuildRental(cmd mtls.Command) mtls.CommandAck {
+	if d.rentalExecutor == nil {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "rental executor not configured"}
+	}
+
+	sessionID, _ := cmd.Payload["session_id"].(string)
+	if sessionID == "" {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "missing session_id"}
+	}
+	image, _ := cmd.Payload["image"].(string)
+	if image == "" {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "missing image"}
+	}
+
+	log.Printf("Rebuilding rental: session=%s image=%s", sessionID, image)
+
+	// Same budget as start_rental: the new image may need a large pull
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	connInfo, err := d.rentalExecutor.RebuildRental(ctx, rental.RebuildRentalRequest{
+		SessionID:    sessionID,
+		Image:        image,
+		RegistryAuth: parseRegistryAuth(cmd.Payload["registry_auth"]),
+		Host:         d.hostAddr,
+	})
+	if err != nil {
+		log.Printf("Failed to rebuild rental %s: %v", sessionID, err)
+		code, _ := rental.ErrorCode(err)
+		return mtls.CommandAck{
+			CommandID: cmd.ID,
+			Status:    "error",
+			Error:     fmt.Sprintf("failed to rebuild rental: %v", err),
+			ErrorCode: code,
+		}
+	}
+
+	log.Printf("Rental rebuilt: session=%s container=%s", sessionID, connInfo.ContainerID)
+
 	return mtls.CommandAck{
 		CommandID: cmd.ID,
 		Status:    "ok",
-		Payload: map[string]interface{}{
-			"session_id":   sessionID,
-			"ssh_host":     sshHost,
-			"ssh_port":     float64(connInfo.Port),
-			"ssh_user":     connInfo.User,
-			"container_id": connInfo.ContainerID,
-		},
+		Payload:   d.connectionPayload(sessionID, connInfo),
 	}
 }
 
@@ -235,12 +397,90 @@ func (d *NodeDaemon) handleStopRental(cmd mtls.Command) mtls.CommandAck {
 	log.Printf("Rental stopped: session=%s", sessionID)
 
 	// Resume mining after rental ends - GPU is now available
-	if d.miningDaemon != nil {
-		resumeCtx, resumeCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		if err := d.miningDaemon.ResumeAfterRental(resumeCtx, []string{}); err != nil {
-			log.Printf("Warning: failed to resume mining after rental: %v", err)
+	d.resumeMining()
+
+	return mtls.CommandAck{
+		CommandID: cmd.ID,
+		Status:    "ok",
+		Payload: map[string]interface{}{
+			"session_id": sessionID,
+		},
+	}
+}
+
+// handleRentalLogs returns the last lines of a rental container's output.
+// Following logs is only offered by the node API; acks carry a snapshot.
+func (d *NodeDaemon) handleRentalLogs(cmd mtls.Command) mtls.CommandAck {
+	if d.rentalExecutor == nil {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "rental executor not configured"}
+	}
+
+	sessionID, _ := cmd.Payload["session_id"].(string)
+	if sessionID == "" {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "missing session_id"}
+	}
+
+	opts := container.LogOptions{Tail: 200, MaxBytes: 256 * 1024}
+	if v, ok := cmd.Payload["tail"].(float64); ok && v > 0 {
+		opts.Tail = int(v)
+	}
+	if v, ok := cmd.Payload["max_bytes"].(float64); ok && v > 0 {
+		opts.MaxBytes = int64(v)
+	}
+	if opts.MaxBytes > container.DefaultLogMaxBytes {
+		opts.MaxBytes = container.DefaultLogMaxBytes
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := d.rentalExecutor.RentalLogs(ctx, sessionID, opts, &buf)
+	truncated := errors.Is(err, container.ErrLogLimitReached)
+	if err != nil && !truncated {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: err.Error()}
+	}
+
+	return mtls.CommandAck{
+		CommandID: cmd.ID,
+		Status:    "ok",
+		Payload: map[string]interface{}{
+			"session_id": sessionID,
+			"logs":       buf.String(),
+			"truncated":  truncated,
+		},
+	}
+}
+
+// handleExecDiagnostic runs an operator-allowlisted command in a rental
+// container for support staff. Every invocation is audited by the executor.
+func (d *NodeDaemon) handleExecDiagnostic(cmd mtls.Command) mtls.CommandAck {
+	if d.rentalExecutor == nil {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "rental executor not configured"}
+	}
+
+	sessionID, _ := cmd.Payload["session_id"].(string)
+	command, _ := cmd.Payload["command"].(string)
+	if sessionID == "" || command == "" {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "missing session_id or command"}
+	}
+	requestedBy, _ := cmd.Payload["requested_by"].(string)
+
+	log.Printf("Diagnostic exec: session=%s command=%s requested_by=%s", sessionID, command, requestedBy)
+
+	result, err := d.rentalExecutor.RunDiagnostic(context.Background(), rental.DiagnosticRequest{
+		SessionID:   sessionID,
+		Command:     command,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		log.Printf("Diagnostic exec failed: session=%s command=%s: %v", sessionID, command, err)
+		return mtls.CommandAck{
+			CommandID: cmd.ID,
+			Status:    "error",
+			Error:     err.Error(),
+			ErrorCode: diagnosticErrorCode(err),
 		}
-		resumeCancel()
 	}
 
 	return mtls.CommandAck{
@@ -248,10 +488,365 @@ func (d *NodeDaemon) handleStopRental(cmd mtls.Command) mtls.CommandAck {
 		Status:    "ok",
 		Payload: map[string]interface{}{
 			"session_id": sessionID,
+			"command":    command,
+			"exit_code":  float64(result.ExitCode),
+			"stdout":     result.Stdout,
+			"stderr":     result.Stderr,
+			"truncated":  result.Truncated,
+			"timed_out":  result.TimedOut,
+		},
+	}
+}
+
+// diagnosticErrorCode maps diagnostic failures to stable ack error codes
+func diagnosticErrorCode(err error) string {
+	switch {
+	case errors.Is(err, rental.ErrDiagnosticNotAllowed):
+		return "DIAGNOSTIC_NOT_ALLOWED"
+	case errors.Is(err, rental.ErrRentalNotRunning):
+		return "RENTAL_NOT_RUNNING"
+	case errors.Is(err, rental.ErrSessionNotFound):
+		return "RENTAL_NOT_FOUND"
+	}
+	return ""
+}
+
+// handleDrain turns drain mode on (default) or off. A draining node rejects
+// start_rental while its current rentals keep running.
+func (d *NodeDaemon) handleDrain(cmd mtls.Command) mtls.CommandAck {
+	if d.rentalExecutor == nil {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "rental executor not configured"}
+	}
+
+	enabled := true
+	if v, ok := cmd.Payload["enabled"].(bool); ok {
+		enabled = v
+	}
+	d.rentalExecutor.SetDraining(enabled)
+	log.Printf("Drain mode set by Hub: draining=%v live_rentals=%d", enabled, d.rentalExecutor.LiveRentals())
+
+	return mtls.CommandAck{
+		CommandID: cmd.ID,
+		Status:    "ok",
+		Payload: map[string]interface{}{
+			"draining":     enabled,
+			"live_rentals": d.rentalExecutor.LiveRentals(),
 		},
 	}
 }
 
+// handlePrefetchImages pulls images in the background so later rentals start
+// without a pull. Results are reported with an images_prefetched message.
+// Pulls carry no renter credentials, so private images fail with a per-image
+// code unless an operator credential helper covers their registry.
+func (d *NodeDaemon) handlePrefetchImages(cmd mtls.Command) mtls.CommandAck {
+	if d.imageCache == nil {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "image cache not configured"}
+	}
+
+	images := toStringSlice(cmd.Payload["images"])
+	if len(images) == 0 {
+		return mtls.CommandAck{CommandID: cmd.ID, Status: "error", Error: "missing images"}
+	}
+
+	log.Printf("Prefetching %d image(s): %v", len(images), images)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+		defer cancel()
+
+		results := d.imageCache.Prefetch(ctx, images)
+		if _, err := d.imageCache.Evict(ctx); err != nil {
+			log.Printf("Warning: image cache eviction failed: %v", err)
+		}
+
+		out := make([]interface{}, 0, len(results))
+		for _, r := range results {
+			out = append(out, map[string]interface{}{
+				"image": r.Image,
+				"ref":   r.Ref,
+				"error": r.Error,
+				"code":  r.Code,
+			})
+		}
+		d.sendEvent("images_prefetched", "", map[string]interface{}{
+			"command_id": cmd.ID,
+			"results":    out,
+		})
+	}()
+
+	return mtls.CommandAck{
+		CommandID: cmd.ID,
+		Status:    "ok",
+		Payload: map[string]interface{}{
+			"accepted": float64(len(images)),
+		},
+	}
+}
+
+// resumeMining restarts mining on GPUs released by a rental
+func (d *NodeDaemon) resumeMining() {
+	if d.miningDaemon == nil {
+		return
+	}
+	resumeCtx, resumeCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer resumeCancel()
+	if err := d.miningDaemon.ResumeAfterRental(resumeCtx, []string{}); err != nil {
+		log.Printf("Warning: failed to resume mining after rental: %v", err)
+	}
+}
+
+// parseScratchMounts reads [{"path": "/scratch", "size_mb": 1024}, ...] from a command payload
+func parseScratchMounts(raw interface{}) []container.ScratchMount {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	mounts := make([]container.ScratchMount, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := m["path"].(string)
+		if path == "" {
+			continue
+		}
+		sizeMB, _ := m["size_mb"].(float64)
+		mounts = append(mounts, container.ScratchMount{
+			Path:      path,
+			SizeBytes: int64(sizeMB) * 1024 * 1024,
+		})
+	}
+	return mounts
+}
+
+// parseUlimits reads [{"name": "nofile", "soft": 65536, "hard": 65536}, ...]
+// from a command payload. A missing hard limit equals the soft one; -1 is unlimited.
+func parseUlimits(raw interface{}) []container.Ulimit {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	ulimits := make([]container.Ulimit, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		soft, ok := m["soft"].(float64)
+		if name == "" || !ok {
+			continue
+		}
+		hard, ok := m["hard"].(float64)
+		if !ok {
+			hard = soft
+		}
+		ulimits = append(ulimits, container.Ulimit{Name: name, Soft: int64(soft), Hard: int64(hard)})
+	}
+	return ulimits
+}
+
+// parseExposedPorts parses additional ports to publish. Entries are either a
+// bare container port (8888) or {"container_port": 8888, "protocol": "udp"}.
+func parseExposedPorts(raw interface{}) []container.PortMapping {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	ports := make([]container.PortMapping, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case float64:
+			ports = append(ports, container.PortMapping{ContainerPort: int(v)})
+		case map[string]interface{}:
+			port, _ := v["container_port"].(float64)
+			protocol, _ := v["protocol"].(string)
+			ports = append(ports, container.PortMapping{ContainerPort: int(port), Protocol: protocol})
+		}
+	}
+	return ports
+}
+
+// portsPayload converts published ports for the start_rental ack
+func portsPayload(ports []container.PortMapping) []interface{} {
+	out := make([]interface{}, 0, len(ports))
+	for _, p := range ports {
+		out = append(out, map[string]interface{}{
+			"container_port": float64(p.ContainerPort),
+			"host_port":      float64(p.HostPort),
+			"protocol":       p.Protocol,
+		})
+	}
+	return out
+}
+
+// parseEgressPolicy reads per-rental egress restrictions, which the executor
+// applies on top of the node policy (see container.EgressPolicy.Narrow):
+// {"block_private": true, "allow_cidrs": ["10.1.0.0/16"], ...}. Returns nil if absent.
+func parseEgressPolicy(raw interface{}) *container.EgressPolicy {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var policy container.EgressPolicy
+	policy.BlockPrivate, _ = m["block_private"].(bool)
+	policy.BlockLinkLocal, _ = m["block_link_local"].(bool)
+	policy.BlockRentals, _ = m["block_rentals"].(bool)
+	policy.BlockHost, _ = m["block_host"].(bool)
+	if _, ok := m["allow_cidrs"]; ok {
+		policy.AllowCIDRs = append([]string{}, toStringSlice(m["allow_cidrs"])...)
+	}
+	policy.DenyCIDRs = toStringSlice(m["deny_cidrs"])
+	return &policy
+}
+
+// parseEnv reads {"HF_HOME": "/data/hf", ...} from a command payload.
+// Non-string values are ignored.
+func parseEnv(raw interface{}) container.Env {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	env := make(container.Env, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			env[k] = s
+		}
+	}
+	return env
+}
+
+// parseRegistryAuth reads {"username", "password", "identity_token",
+// "server_address"} from a command payload. Returns nil if absent.
+func parseRegistryAuth(raw interface{}) *container.RegistryAuth {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	auth := &container.RegistryAuth{}
+	auth.Username, _ = m["username"].(string)
+	auth.Password, _ = m["password"].(string)
+	auth.IdentityToken, _ = m["identity_token"].(string)
+	auth.ServerAddress, _ = m["server_address"].(string)
+	return auth
+}
+
+// toStringSlice converts a JSON array of strings, skipping non-string items
+func toStringSlice(raw interface{}) []string {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// forwardRentalEvent relays an executor event to the Hub over the mTLS channel
+func (d *NodeDaemon) forwardRentalEvent(ev rental.Event) {
+	// Pull progress is frequent; only forward it
+	if ev.Type != rental.EventImagePullProgress {
+		log.Printf("Rental event: %s session=%s %v", ev.Type, ev.SessionID, ev.Payload)
+	}
+
+	// The rental's last usage sample closes its billing span
+	if ev.Type == rental.EventUsageFinal && d.receipts != nil {
+		if state, err := d.rentalExecutor.GetRentalStatus(ev.SessionID); err == nil {
+			d.sendReceipt(state, true)
+		}
+	}
+
+	if ev.Type == rental.EventDiskQuotaExceeded {
+		if stopped, _ := ev.Payload["stopped"].(bool); stopped {
+			d.resumeMining()
+		}
+	}
+
+	// A crashed rental no longer holds its GPU
+	if ev.Type == rental.EventRentalFailed {
+		d.resumeMining()
+	}
+
+	d.sendEvent(ev.Type, ev.SessionID, ev.Payload)
+}
+
+// issueReceipts sends a periodic receipt for every running rental
+func (d *NodeDaemon) issueReceipts() {
+	ticker := time.NewTicker(d.receiptInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			for _, state := range d.rentalExecutor.ListActiveRentals() {
+				if state.Phase == rental.PhaseRunning {
+					d.sendReceipt(state, false)
+				}
+			}
+		}
+	}
+}
+
+// sendReceipt signs, stores and sends a usage receipt covering the rental
+// from its start until now (or until it stopped)
+func (d *NodeDaemon) sendReceipt(state *rental.RentalState, final bool) {
+	end := time.Now()
+	if state.StoppedAt != nil {
+		end = *state.StoppedAt
+	}
+
+	r, err := d.receipts.Issue(receipt.Usage{
+		SessionID:      state.SessionID,
+		StartedAt:      state.StartedAt,
+		EndedAt:        end,
+		GPUCount:       state.GPUCount(),
+		PricePerSecond: state.PricePerSecond,
+		Final:          final,
+	})
+	if err != nil {
+		log.Printf("Failed to issue usage receipt for %s: %v", state.SessionID, err)
+		return
+	}
+
+	d.sendEvent("usage_receipt", state.SessionID, map[string]interface{}{
+		"receipt": r,
+	})
+}
+
+// sendEvent sends a typed message to the Hub. sessionID may be empty for
+// node-level events.
+func (d *NodeDaemon) sendEvent(eventType, sessionID string, fields map[string]interface{}) {
+	if d.mtlsClient == nil {
+		return
+	}
+
+	payload := map[string]interface{}{}
+	if sessionID != "" {
+		payload["session_id"] = sessionID
+	}
+	for k, v := range fields {
+		payload[k] = v
+	}
+
+	msg := map[string]interface{}{
+		"type":    eventType,
+		"payload": payload,
+	}
+
+	data, _ := json.Marshal(msg)
+	if err := d.mtlsClient.Send(data); err != nil {
+		log.Printf("Failed to send %s event: %v", eventType, err)
+	}
+}
+
 // reportMetrics periodically collects and reports GPU metrics + mining status
 func (d *NodeDaemon) reportMetrics() {
 	ticker := time.NewTicker(d.metricsInterval)
@@ -299,6 +894,28 @@ func (d *NodeDaemon) buildHeartbeat(gpuMetrics []domain.GPUMetrics) []byte {
 		}
 	}
 
+	// Rental phases let the Hub reconcile sessions it thinks are running
+	if d.rentalExecutor != nil {
+		payload["draining"] = d.rentalExecutor.Draining()
+		payload["runtime_classes"] = d.rentalExecutor.RuntimeClasses()
+		// Whole GPUs and MIG slices are rented separately
+		if units := d.rentalExecutor.GPUUnits(); units != nil {
+			payload["gpu_units"] = units
+		}
+		payload["rentals"] = rentalPhases(d.rentalExecutor.ListActiveRentals())
+
+		usage := []map[string]interface{}{}
+		for _, rec := range d.rentalExecutor.ActiveUsage() {
+			usage = append(usage, rec.Payload())
+		}
+		payload["rental_usage"] = usage
+	}
+
+	// Cached images let the Hub prefer nodes that can start a rental without a pull
+	if d.imageCache != nil {
+		payload["cached_images"] = d.cachedImageRefs()
+	}
+
 	msg := map[string]interface{}{
 		"type":    "heartbeat",
 		"payload": payload,
@@ -308,6 +925,42 @@ func (d *NodeDaemon) buildHeartbeat(gpuMetrics []domain.GPUMetrics) []byte {
 	return data
 }
 
+// rentalPhases summarises tracked rentals for the heartbeat
+func rentalPhases(states []*rental.RentalState) []map[string]interface{} {
+	rentals := make([]map[string]interface{}, 0, len(states))
+	for _, state := range states {
+		entry := map[string]interface{}{
+			"session_id": state.SessionID,
+			"phase":      string(state.Phase),
+		}
+		if n := len(state.History); n > 0 {
+			entry["phase_since"] = state.History[n-1].At.UTC().Format(time.RFC3339)
+		}
+		if state.FailureReason != "" {
+			entry["failure_reason"] = state.FailureReason
+		}
+		if state.Restarts > 0 {
+			entry["restarts"] = state.Restarts
+		}
+		rentals = append(rentals, entry)
+	}
+	return rentals
+}
+
+// cachedImageRefs returns the tags and digests of images on this node. The
+// image cache only lists images again after a pull or removal.
+func (d *NodeDaemon) cachedImageRefs() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	refs, err := d.imageCache.Refs(ctx)
+	if err != nil {
+		log.Printf("Failed to list cached images: %v", err)
+		return nil
+	}
+	return refs
+}
+
 // Stop gracefully stops the daemon
 func (d *NodeDaemon) Stop() {
 	close(d.stopCh)
@@ -0,0 +1,216 @@
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInsufficientCPUs = errors.New("not enough free cpus")
+	ErrCPUInUse         = errors.New("cpu already assigned")
+)
+
+// Assignment is the cpuset given to a rental
+type Assignment struct {
+	CPUs []int
+	Mems []int // NUMA nodes the CPUs belong to
+}
+
+// CPUSet returns the CPUs in cpu list format (empty = unpinned)
+func (a Assignment) CPUSet() string {
+	return FormatCPUList(a.CPUs)
+}
+
+// MemSet returns the memory nodes in cpu list format
+func (a Assignment) MemSet() string {
+	return FormatCPUList(a.Mems)
+}
+
+// Allocator hands out non-overlapping cpusets on the NUMA nodes of the
+// rented GPUs
+type Allocator struct {
+	mu       sync.Mutex
+	topo     *Topology
+	reserved map[int]bool   // Host CPUs never assigned
+	assigned map[int]string // cpu -> sessionID
+}
+
+// NewAllocator creates an allocator. Reserved CPUs are left for the host.
+func NewAllocator(topo *Topology, reserved []int) *Allocator {
+	a := &Allocator{
+		topo:     topo,
+		reserved: make(map[int]bool),
+		assigned: make(map[int]string),
+	}
+	for _, cpu := range reserved {
+		a.reserved[cpu] = true
+	}
+	return a
+}
+
+// Allocate assigns count free CPUs to a session, split evenly across the
+// NUMA nodes of its GPUs (a comma-separated list of UUIDs or indexes). A
+// rental without GPUs, or with GPUs the topology doesn't know, may use any
+// node. When the GPUs' nodes are short of free CPUs the rest come from other
+// nodes, nearest-numbered first.
+func (a *Allocator) Allocate(sessionID, gpuDeviceID string, count int) (Assignment, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	preferred := a.gpuNodes(gpuDeviceID)
+	free := make(map[int][]int) // node -> free CPUs
+	total := 0
+	for _, n := range a.topo.Nodes {
+		for _, cpu := range n.CPUs {
+			if !a.reserved[cpu] && a.assigned[cpu] == "" {
+				free[n.ID] = append(free[n.ID], cpu)
+				total++
+			}
+		}
+	}
+	if total < count {
+		return Assignment{}, fmt.Errorf("%w: want %d, %d free", ErrInsufficientCPUs, count, total)
+	}
+
+	var cpus []int
+	take := func(node, n int) {
+		if n > len(free[node]) {
+			n = len(free[node])
+		}
+		cpus = append(cpus, free[node][:n]...)
+		free[node] = free[node][n:]
+	}
+
+	// Even split across the GPUs' nodes, then whatever they have left
+	for i, node := range preferred {
+		share := count / len(preferred)
+		if i < count%len(preferred) {
+			share++
+		}
+		take(node, share)
+	}
+	for _, node := range preferred {
+		take(node, count-len(cpus))
+	}
+	for _, node := range a.spillOrder(preferred) {
+		take(node, count-len(cpus))
+	}
+
+	for _, cpu := range cpus {
+		a.assigned[cpu] = sessionID
+	}
+	return a.assignment(cpus), nil
+}
+
+// Reserve records CPUs already pinned to a session, e.g. a rental adopted
+// after a node restart
+func (a *Allocator) Reserve(sessionID string, cpus []int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, cpu := range cpus {
+		if owner := a.assigned[cpu]; owner != "" && owner != sessionID {
+			return fmt.Errorf("%w: cpu %d (session %s)", ErrCPUInUse, cpu, owner)
+		}
+	}
+	for _, cpu := range cpus {
+		a.assigned[cpu] = sessionID
+	}
+	return nil
+}
+
+// Release frees a session's CPUs. Releasing an unknown session is a no-op.
+func (a *Allocator) Release(sessionID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for cpu, owner := range a.assigned {
+		if owner == sessionID {
+			delete(a.assigned, cpu)
+		}
+	}
+}
+
+// Free returns the number of unassigned CPUs per NUMA node
+func (a *Allocator) Free() map[int]int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	free := make(map[int]int)
+	for _, n := range a.topo.Nodes {
+		for _, cpu := range n.CPUs {
+			if !a.reserved[cpu] && a.assigned[cpu] == "" {
+				free[n.ID]++
+			}
+		}
+	}
+	return free
+}
+
+// gpuNodes returns the distinct NUMA nodes of the listed GPUs in order
+func (a *Allocator) gpuNodes(gpuDeviceID string) []int {
+	var nodes []int
+	seen := make(map[int]bool)
+	for _, id := range strings.Split(gpuDeviceID, ",") {
+		node, ok := a.topo.GPUNodes[strings.TrimSpace(id)]
+		if ok && !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// spillOrder returns the remaining nodes ordered by distance in node number
+// from the first preferred node
+func (a *Allocator) spillOrder(preferred []int) []int {
+	home := 0
+	if len(preferred) > 0 {
+		home = preferred[0]
+	}
+	skip := make(map[int]bool)
+	for _, node := range preferred {
+		skip[node] = true
+	}
+
+	var nodes []int
+	for _, n := range a.topo.Nodes {
+		if !skip[n.ID] {
+			nodes = append(nodes, n.ID)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return abs(nodes[i]-home) < abs(nodes[j]-home)
+	})
+	return nodes
+}
+
+// assignment builds the cpuset and memory nodes for the chosen CPUs
+func (a *Allocator) assignment(cpus []int) Assignment {
+	sort.Ints(cpus)
+	var mems []int
+	for _, n := range a.topo.Nodes {
+		for _, cpu := range n.CPUs {
+			if contains(cpus, cpu) {
+				mems = append(mems, n.ID)
+				break
+			}
+		}
+	}
+	return Assignment{CPUs: cpus, Mems: mems}
+}
+
+func contains(sorted []int, v int) bool {
+	i := sort.SearchInts(sorted, v)
+	return i < len(sorted) && sorted[i] == v
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,81 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dualSocketAllocator(t *testing.T, reserved ...int) *Allocator {
+	t.Helper()
+	topo, err := Read("testdata/dual-socket", dualSocketGPUs)
+	require.NoError(t, err)
+	return NewAllocator(topo, reserved)
+}
+
+func TestAllocate_PinsToGPUNode(t *testing.T) {
+	a := dualSocketAllocator(t, 0)
+
+	first, err := a.Allocate("session-1", "GPU-bbb", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "8-11", first.CPUSet())
+	assert.Equal(t, "1", first.MemSet())
+
+	// Index works like UUID; reserved cpu 0 is skipped
+	second, err := a.Allocate("session-2", "0", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "1-4", second.CPUSet())
+	assert.Equal(t, "0", second.MemSet())
+
+	// No overlap with session-1
+	third, err := a.Allocate("session-3", "GPU-bbb", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "12-15", third.CPUSet())
+}
+
+func TestAllocate_SplitsAcrossGPUNodes(t *testing.T) {
+	a := dualSocketAllocator(t)
+
+	got, err := a.Allocate("session-1", "GPU-aaa,GPU-bbb", 5)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0-2,8-9", got.CPUSet())
+	assert.Equal(t, "0-1", got.MemSet())
+}
+
+func TestAllocate_SpillsToOtherNode(t *testing.T) {
+	a := dualSocketAllocator(t)
+	_, err := a.Allocate("big", "GPU-aaa", 14)
+	require.NoError(t, err)
+
+	got, err := a.Allocate("session-1", "GPU-aaa", 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, "22-23", FormatCPUList(got.CPUs[2:]))
+	assert.Equal(t, "8-9", FormatCPUList(got.CPUs[:2]))
+	assert.Equal(t, "0-1", got.MemSet())
+}
+
+func TestAllocate_InsufficientCPUs(t *testing.T) {
+	a := dualSocketAllocator(t)
+
+	_, err := a.Allocate("session-1", "GPU-aaa", 33)
+	assert.ErrorIs(t, err, ErrInsufficientCPUs)
+	assert.Equal(t, map[int]int{0: 16, 1: 16}, a.Free(), "a failed allocation assigns nothing")
+}
+
+func TestReserveAndRelease(t *testing.T) {
+	a := dualSocketAllocator(t)
+
+	require.NoError(t, a.Reserve("adopted", []int{8, 9}))
+	assert.ErrorIs(t, a.Reserve("other", []int{9}), ErrCPUInUse)
+
+	got, err := a.Allocate("session-1", "GPU-bbb", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "10-11", got.CPUSet())
+
+	a.Release("adopted")
+	a.Release("session-1")
+	assert.Equal(t, map[int]int{0: 16, 1: 16}, a.Free())
+}
@@ -0,0 +1,186 @@
+// Package topology discovers the host's NUMA layout and GPU affinity from
+// sysfs and assigns rentals dedicated CPUs next to their GPUs.
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidCPUList = errors.New("invalid cpu list")
+	ErrUnknownGPU     = errors.New("gpu not found in topology")
+)
+
+// Node is a NUMA node and the CPUs it holds
+type Node struct {
+	ID   int
+	CPUs []int
+}
+
+// GPU locates a GPU on the PCI bus. Rentals name GPUs by UUID or index.
+type GPU struct {
+	UUID  string
+	Index int
+	BusID string // PCI bus ID, e.g. "0000:3b:00.0"
+}
+
+// Topology is the host's NUMA layout and the NUMA node of each GPU
+type Topology struct {
+	Nodes    []Node
+	GPUNodes map[string]int // GPU UUID and index -> NUMA node
+}
+
+// Read discovers NUMA nodes under sysRoot (normally "/sys") and the node each
+// GPU is attached to. Hosts without NUMA information get a single node 0
+// holding every online CPU, and GPUs whose node the kernel doesn't know are
+// placed on node 0.
+func Read(sysRoot string, gpus []GPU) (*Topology, error) {
+	nodes, err := readNodes(sysRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Topology{Nodes: nodes, GPUNodes: make(map[string]int)}
+	for _, gpu := range gpus {
+		node, err := readGPUNode(sysRoot, gpu.BusID)
+		if err != nil {
+			return nil, fmt.Errorf("gpu %s: %w", gpu.UUID, err)
+		}
+		if t.node(node) == nil {
+			node = nodes[0].ID
+		}
+		if gpu.UUID != "" {
+			t.GPUNodes[gpu.UUID] = node
+		}
+		t.GPUNodes[strconv.Itoa(gpu.Index)] = node
+	}
+	return t, nil
+}
+
+// readNodes reads node*/cpulist, falling back to the online CPUs
+func readNodes(sysRoot string) ([]Node, error) {
+	dirs, _ := filepath.Glob(filepath.Join(sysRoot, "devices/system/node/node[0-9]*"))
+
+	var nodes []Node
+	for _, dir := range dirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		cpus, err := readCPUList(filepath.Join(dir, "cpulist"))
+		if err != nil {
+			return nil, fmt.Errorf("numa node %d: %w", id, err)
+		}
+		if len(cpus) > 0 { // Memory-only nodes (e.g. CXL) have no CPUs
+			nodes = append(nodes, Node{ID: id, CPUs: cpus})
+		}
+	}
+	if len(nodes) > 0 {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+		return nodes, nil
+	}
+
+	cpus, err := readCPUList(filepath.Join(sysRoot, "devices/system/cpu/online"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read online cpus: %w", err)
+	}
+	return []Node{{ID: 0, CPUs: cpus}}, nil
+}
+
+// readGPUNode returns the NUMA node of a PCI device (-1 = unknown)
+func readGPUNode(sysRoot, busID string) (int, error) {
+	path := filepath.Join(sysRoot, "bus/pci/devices", NormalizeBusID(busID), "numa_node")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read numa node: %w", err)
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse numa node %q: %w", data, err)
+	}
+	return node, nil
+}
+
+// node returns the node with the given ID, or nil
+func (t *Topology) node(id int) *Node {
+	for i := range t.Nodes {
+		if t.Nodes[i].ID == id {
+			return &t.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeBusID converts NVML's bus ID format ("00000000:3B:00.0") to the
+// sysfs one ("0000:3b:00.0")
+func NormalizeBusID(busID string) string {
+	busID = strings.ToLower(strings.TrimSpace(busID))
+	if domain, rest, ok := strings.Cut(busID, ":"); ok && len(domain) == 8 {
+		busID = domain[4:] + ":" + rest
+	}
+	return busID
+}
+
+// readCPUList reads a kernel cpu list file
+func readCPUList(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCPUList(strings.TrimSpace(string(data)))
+}
+
+// ParseCPUList parses the kernel's cpu list format ("0-3,8,10-11") into
+// sorted CPU numbers
+func ParseCPUList(s string) ([]int, error) {
+	var cpus []int
+	if s == "" {
+		return cpus, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCPUList, s)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidCPUList, s)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// FormatCPUList formats CPU numbers in the kernel's cpu list format, as
+// accepted by Docker's --cpuset-cpus and --cpuset-mems
+func FormatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,58 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dualSocketGPUs = []GPU{
+	{UUID: "GPU-aaa", Index: 0, BusID: "00000000:3B:00.0"},
+	{UUID: "GPU-bbb", Index: 1, BusID: "00000000:AF:00.0"},
+}
+
+func TestRead_DualSocket(t *testing.T) {
+	topo, err := Read("testdata/dual-socket", dualSocketGPUs)
+
+	require.NoError(t, err)
+	require.Len(t, topo.Nodes, 2, "memory-only node2 has no cpus")
+	assert.Equal(t, 0, topo.Nodes[0].ID)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 16, 17, 18, 19, 20, 21, 22, 23}, topo.Nodes[0].CPUs)
+	assert.Equal(t, 1, topo.Nodes[1].ID)
+	assert.Equal(t, map[string]int{"GPU-aaa": 0, "0": 0, "GPU-bbb": 1, "1": 1}, topo.GPUNodes)
+}
+
+func TestRead_SingleNodeFallback(t *testing.T) {
+	topo, err := Read("testdata/single-node", []GPU{{UUID: "GPU-aaa", BusID: "0000:01:00.0"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, []Node{{ID: 0, CPUs: []int{0, 1, 2, 3}}}, topo.Nodes)
+	assert.Equal(t, 0, topo.GPUNodes["GPU-aaa"], "unknown numa node (-1) maps to node 0")
+}
+
+func TestRead_MissingGPU(t *testing.T) {
+	_, err := Read("testdata/dual-socket", []GPU{{UUID: "GPU-zzz", BusID: "0000:00:01.0"}})
+	assert.Error(t, err)
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := ParseCPUList("0-3,8,10-11")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 8, 10, 11}, cpus)
+
+	for _, bad := range []string{"a", "3-1", "-1", "1,,2"} {
+		_, err := ParseCPUList(bad)
+		assert.ErrorIs(t, err, ErrInvalidCPUList, bad)
+	}
+}
+
+func TestFormatCPUList(t *testing.T) {
+	assert.Equal(t, "0-3,8,10-11", FormatCPUList([]int{11, 0, 1, 2, 3, 8, 10}))
+	assert.Equal(t, "", FormatCPUList(nil))
+}
+
+func TestNormalizeBusID(t *testing.T) {
+	assert.Equal(t, "0000:3b:00.0", NormalizeBusID("00000000:3B:00.0"))
+	assert.Equal(t, "0000:3b:00.0", NormalizeBusID("0000:3b:00.0"))
+}